@@ -27,6 +27,9 @@ func InitDB() {
 		connectWithTraditionalConfig()
 	}
 
+	// Restringir as consultas à organização do usuário autenticado
+	registerTenantScope(DB)
+
 	// Executar migrações
 	runMigrations()
 }
@@ -129,12 +132,49 @@ func connectWithTraditionalConfig() {
 }
 
 func runMigrations() {
+	// Converter dados que dependem do tipo antigo das colunas
+	runDataMigrations(schemaPreparations)
+
 	err := DB.AutoMigrate(
+		&models.Organization{},
+		&models.Store{},
 		&models.User{},
 		&models.Category{},
 		&models.Product{},
+		&models.ProductVariant{},
+		&models.ProductBarcode{},
+		&models.StoreProduct{},
+		&models.StoreVariant{},
+		&models.ScaleLabelLayout{},
+		&models.ProductBatch{},
+		&models.ProductBatchMovement{},
+		&models.PriceChange{},
+		&models.ScheduledPrice{},
 		&models.Sale{},
 		&models.SaleItem{},
+		&models.SalePayment{},
+		&models.StockMovement{},
+		&models.Customer{},
+		&models.CashSession{},
+		&models.CashMovement{},
+		&models.CashSessionCount{},
+		&models.FiscalDocument{},
+		&models.FiscalSequence{},
+		&models.FiscalInvalidation{},
+		&models.PrinterProfile{},
+		&models.Promotion{},
+		&models.PromotionItem{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.SupplierProduct{},
+		&models.PurchaseInvoice{},
+		&models.InventoryCount{},
+		&models.InventoryCountItem{},
+		&models.InventoryCountEntry{},
+		&models.SupervisorAuthorization{},
 	)
 
 	if err != nil {
@@ -142,6 +182,9 @@ func runMigrations() {
 		// Continuar mesmo com erros de constraint
 	}
 
+	// Aplicar migrações de dados pendentes
+	runDataMigrations(dataMigrations)
+
 	log.Println("Migrações executadas com sucesso")
 
 	// Criar usuário admin padrão se não existir
@@ -156,12 +199,20 @@ func createDefaultAdmin() {
 	DB.Model(&models.User{}).Count(&count)
 
 	if count == 0 {
+		organization, store, err := defaultOrganization(DB)
+		if err != nil {
+			log.Printf("Erro ao criar organização padrão: %v", err)
+			return
+		}
+
 		admin := models.User{
-			Name:     "Administrador",
-			Email:    "admin@pdv.com",
-			Password: "admin123", // Será hasheada no modelo
-			Role:     "admin",
-			Active:   true,
+			OrganizationID: &organization.ID,
+			StoreID:        &store.ID,
+			Name:           "Administrador",
+			Email:          "admin@pdv.com",
+			Password:       "admin123", // Será hasheada no modelo
+			Role:           "admin",
+			Active:         true,
 		}
 
 		if err := DB.Create(&admin).Error; err != nil {
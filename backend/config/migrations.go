@@ -0,0 +1,470 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pdv-backend/models"
+)
+
+// schemaMigration registra as migrações de dados já aplicadas no banco
+type schemaMigration struct {
+	Name      string    `gorm:"primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// dataMigration representa uma conversão de dados executada uma única vez
+type dataMigration struct {
+	Name string
+	Run  func(tx *gorm.DB) error
+}
+
+// schemaPreparations lista, em ordem, as migrações que precisam rodar antes do AutoMigrate
+// alterar o tipo das colunas ou criar índices
+var schemaPreparations = []dataMigration{
+	{Name: "20261016_money_to_centavos", Run: convertMoneyToCentavos},
+	{Name: "20261017_single_open_cash_session", Run: closeDuplicateCashSessions},
+	{Name: "20261018_legacy_fiscal_sequences", Run: renameLegacyFiscalSequences},
+}
+
+// dataMigrations lista, em ordem, as migrações executadas após o AutoMigrate
+var dataMigrations = []dataMigration{
+	{Name: "20261016_backfill_sale_payments", Run: backfillSalePayments},
+	{Name: "20261016_default_organization", Run: assignDefaultOrganization},
+	{Name: "20261016_store_stock", Run: assignStoreStock},
+	{Name: "20261017_fiscal_organization", Run: assignFiscalOrganization},
+	{Name: "20261017_customer_organization", Run: assignCustomerOrganization},
+	{Name: "20261017_organization_scope", Run: assignOrganizationScope},
+	{Name: "20261017_organization_barcodes", Run: assignOrganizationBarcodes},
+	{Name: "20261017_printer_organization", Run: assignPrinterOrganization},
+	{Name: "20261017_batch_stores", Run: assignBatchStores},
+	{Name: "20261018_fiscal_sequence_organization", Run: assignFiscalSequenceOrganization},
+	{Name: "20261018_inventory_count_stores", Run: assignInventoryCountStores},
+	{Name: "20261018_store_variant_stock", Run: assignStoreVariantStock},
+	{Name: "20261018_inventory_count_variants", Run: replaceInventoryCountItemIndex},
+}
+
+// runDataMigrations aplica as migrações de dados pendentes, cada uma em sua própria transação
+func runDataMigrations(migrations []dataMigration) {
+	if err := DB.AutoMigrate(&schemaMigration{}); err != nil {
+		log.Printf("Erro ao criar tabela de migrações: %v", err)
+		return
+	}
+
+	for _, migration := range migrations {
+		var count int64
+		DB.Model(&schemaMigration{}).Where("name = ?", migration.Name).Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			log.Printf("Erro ao aplicar migração %s: %v", migration.Name, err)
+			continue
+		}
+
+		log.Printf("Migração %s aplicada", migration.Name)
+	}
+}
+
+// moneyColumns lista as colunas monetárias que passaram de reais (ponto flutuante) para centavos
+var moneyColumns = map[string][]string{
+	"products":            {"price", "cost_price"},
+	"sales":               {"total", "discount", "tax", "final_total", "amount_received", "change"},
+	"sale_items":          {"unit_price", "total"},
+	"sale_payments":       {"amount", "amount_received", "change"},
+	"cash_sessions":       {"opening_amount"},
+	"cash_movements":      {"amount"},
+	"cash_session_counts": {"expected", "counted", "difference"},
+}
+
+// convertMoneyToCentavos multiplica os valores existentes por 100 ainda com a coluna em
+// ponto flutuante, para que o AutoMigrate converta o tipo para inteiro sem perder os centavos
+func convertMoneyToCentavos(tx *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		if !tx.Migrator().HasTable(table) {
+			continue
+		}
+		for _, column := range columns {
+			if !tx.Migrator().HasColumn(table, column) {
+				continue
+			}
+			if err := tx.Exec("UPDATE ? SET ? = ROUND(? * 100) WHERE ? IS NOT NULL",
+				clause.Table{Name: table}, clause.Column{Name: column}, clause.Column{Name: column}, clause.Column{Name: column}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// closeDuplicateCashSessions fecha os caixas abertos excedentes de cada operador, mantendo o
+// mais antigo (o usado pelas vendas), para permitir o índice único de caixas abertos
+func closeDuplicateCashSessions(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable("cash_sessions") {
+		return nil
+	}
+	return tx.Exec(`UPDATE cash_sessions SET status = ?, closed_at = ?, closing_notes = ?
+		WHERE status = ? AND id NOT IN (SELECT MIN(id) FROM cash_sessions WHERE status = ? GROUP BY user_id)`,
+		models.CashSessionClosed, time.Now(), "Fechado automaticamente: outro caixa do operador estava aberto",
+		models.CashSessionOpen, models.CashSessionOpen).Error
+}
+
+// renameLegacyFiscalSequences preserva a numeração por série, que passou a ser por
+// organização e série, para que o AutoMigrate crie a tabela com a nova chave primária
+func renameLegacyFiscalSequences(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable("fiscal_sequences") || tx.Migrator().HasColumn("fiscal_sequences", "organization_id") {
+		return nil
+	}
+	return tx.Migrator().RenameTable("fiscal_sequences", "fiscal_sequences_legacy")
+}
+
+// backfillSalePayments cria o registro de pagamento das vendas anteriores ao pagamento dividido
+func backfillSalePayments(tx *gorm.DB) error {
+	var sales []models.Sale
+	return tx.Where("id NOT IN (?)", tx.Model(&models.SalePayment{}).Select("sale_id")).
+		FindInBatches(&sales, 500, func(batch *gorm.DB, _ int) error {
+			payments := make([]models.SalePayment, 0, len(sales))
+			for _, sale := range sales {
+				payment := models.SalePayment{
+					SaleID:         sale.ID,
+					PaymentType:    sale.PaymentType,
+					Amount:         sale.FinalTotal,
+					AmountReceived: sale.FinalTotal,
+					CreatedAt:      sale.CreatedAt,
+				}
+				if sale.AmountReceived != nil {
+					payment.AmountReceived = *sale.AmountReceived
+				}
+				if sale.Change != nil {
+					payment.Change = *sale.Change
+				}
+				payments = append(payments, payment)
+			}
+			if len(payments) == 0 {
+				return nil
+			}
+			return tx.Create(&payments).Error
+		}).Error
+}
+
+// defaultOrganization retorna a primeira organização cadastrada e sua primeira loja,
+// criando-as quando ainda não existem
+func defaultOrganization(tx *gorm.DB) (models.Organization, models.Store, error) {
+	var organization models.Organization
+	err := tx.Order("id ASC").Attrs(models.Organization{Name: "Minha Empresa", Active: true}).
+		FirstOrCreate(&organization).Error
+	if err != nil {
+		return organization, models.Store{}, err
+	}
+
+	var store models.Store
+	err = tx.Where(models.Store{OrganizationID: organization.ID}).Order("id ASC").
+		Attrs(models.Store{Name: "Loja Principal", Active: true}).
+		FirstOrCreate(&store).Error
+	return organization, store, err
+}
+
+// defaultOrganizationDB retorna a conexão restrita à organização padrão, usada para
+// cadastrar os dados de exemplo
+func defaultOrganizationDB() *gorm.DB {
+	organization, _, err := defaultOrganization(DB)
+	if err != nil {
+		log.Printf("Erro ao criar organização padrão: %v", err)
+		return DB
+	}
+	return DB.WithContext(WithOrganization(context.Background(), organization.ID))
+}
+
+// organizationTables lista as tabelas com dados restritos à organização
+var organizationTables = []string{"users", "categories", "products", "sales"}
+
+// assignDefaultOrganization atribui os registros anteriores às organizações à organização
+// padrão e substitui o índice único global do código de barras pelo índice por organização
+func assignDefaultOrganization(tx *gorm.DB) error {
+	organization, store, err := defaultOrganization(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range organizationTables {
+		if err := tx.Exec("UPDATE ? SET organization_id = ? WHERE organization_id IS NULL OR organization_id = 0",
+			clause.Table{Name: table}, organization.ID).Error; err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"users", "sales"} {
+		if err := tx.Exec("UPDATE ? SET store_id = ? WHERE store_id IS NULL",
+			clause.Table{Name: table}, store.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	if tx.Migrator().HasIndex(&models.Product{}, "idx_products_barcode") {
+		return tx.Migrator().DropIndex(&models.Product{}, "idx_products_barcode")
+	}
+	return nil
+}
+
+// assignStoreStock atribui o estoque existente dos produtos à primeira loja de cada organização
+func assignStoreStock(tx *gorm.DB) error {
+	var stores []models.Store
+	if err := tx.Where("id IN (?)", tx.Model(&models.Store{}).Select("MIN(id)").Group("organization_id")).
+		Find(&stores).Error; err != nil {
+		return err
+	}
+
+	for _, store := range stores {
+		err := tx.Exec(`INSERT INTO store_products (store_id, product_id, stock, created_at, updated_at)
+			SELECT ?, id, stock, ?, ? FROM products
+			WHERE organization_id = ? AND stock <> 0
+			AND id NOT IN (SELECT product_id FROM store_products WHERE store_id = ?)`,
+			store.ID, time.Now(), time.Now(), store.OrganizationID, store.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignFiscalOrganization atribui as NFC-e à organização da venda e as inutilizações à
+// organização do usuário que as solicitou (ou à organização padrão)
+func assignFiscalOrganization(tx *gorm.DB) error {
+	err := tx.Exec(`UPDATE fiscal_documents SET organization_id =
+		(SELECT organization_id FROM sales WHERE sales.id = fiscal_documents.sale_id)
+		WHERE organization_id = 0`).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec(`UPDATE fiscal_invalidations SET organization_id =
+		COALESCE((SELECT organization_id FROM users WHERE users.id = fiscal_invalidations.user_id),
+			(SELECT MIN(id) FROM organizations))
+		WHERE organization_id = 0`).Error
+}
+
+// assignCustomerOrganization atribui os clientes à organização da primeira venda em que
+// aparecem (ou à organização padrão) e remove o índice único global do documento
+func assignCustomerOrganization(tx *gorm.DB) error {
+	err := tx.Exec(`UPDATE customers SET organization_id = COALESCE(
+		(SELECT organization_id FROM sales WHERE sales.customer_id = customers.id ORDER BY sales.id LIMIT 1),
+		(SELECT MIN(id) FROM organizations))
+		WHERE organization_id = 0`).Error
+	if err != nil {
+		return err
+	}
+
+	if tx.Migrator().HasIndex(&models.Customer{}, "idx_customers_document") {
+		return tx.Migrator().DropIndex(&models.Customer{}, "idx_customers_document")
+	}
+	return nil
+}
+
+// organizationSources indica, para cada tabela que passou a ser restrita à organização, de
+// onde vem a organização dos registros existentes. Sem origem (ou sem registro de origem)
+// vale a organização padrão
+var organizationSources = []struct {
+	Table  string
+	Source string
+}{
+	{"cash_sessions", "SELECT organization_id FROM users WHERE users.id = cash_sessions.user_id"},
+	{"promotions", `SELECT products.organization_id FROM promotion_items
+		JOIN products ON products.id = promotion_items.product_id
+		WHERE promotion_items.promotion_id = promotions.id LIMIT 1`},
+	{"promotions", "SELECT organization_id FROM products WHERE products.id = promotions.product_id"},
+	{"promotions", "SELECT organization_id FROM categories WHERE categories.id = promotions.category_id"},
+	{"suppliers", ""},
+	{"purchase_orders", "SELECT organization_id FROM users WHERE users.id = purchase_orders.user_id"},
+	{"purchase_invoices", "SELECT organization_id FROM users WHERE users.id = purchase_invoices.user_id"},
+	{"inventory_counts", "SELECT organization_id FROM users WHERE users.id = inventory_counts.user_id"},
+	{"scale_label_layouts", ""},
+	{"product_batches", "SELECT organization_id FROM products WHERE products.id = product_batches.product_id"},
+	{"price_changes", "SELECT organization_id FROM products WHERE products.id = price_changes.product_id"},
+	{"scheduled_prices", "SELECT organization_id FROM products WHERE products.id = scheduled_prices.product_id"},
+}
+
+// assignOrganizationScope atribui os registros existentes das tabelas que passaram a ser
+// restritas à organização e substitui o índice único global da chave da NF-e de entrada
+func assignOrganizationScope(tx *gorm.DB) error {
+	organization, _, err := defaultOrganization(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range organizationSources {
+		if source.Source != "" {
+			err = tx.Exec("UPDATE ? SET organization_id = COALESCE(("+source.Source+"), 0) WHERE organization_id = 0",
+				clause.Table{Name: source.Table}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, source := range organizationSources {
+		err = tx.Exec("UPDATE ? SET organization_id = ? WHERE organization_id = 0",
+			clause.Table{Name: source.Table}, organization.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if tx.Migrator().HasIndex(&models.PurchaseInvoice{}, "idx_purchase_invoices_access_key") {
+		return tx.Migrator().DropIndex(&models.PurchaseInvoice{}, "idx_purchase_invoices_access_key")
+	}
+	return nil
+}
+
+// assignOrganizationBarcodes atribui as variações e os códigos adicionais à organização do
+// produto e substitui os índices únicos globais dos códigos de barras e do documento do
+// fornecedor pelos índices por organização
+func assignOrganizationBarcodes(tx *gorm.DB) error {
+	for _, table := range []string{"product_variants", "product_barcodes"} {
+		err := tx.Exec("UPDATE ? SET organization_id = COALESCE((SELECT organization_id FROM products WHERE products.id = ?.product_id), 0) WHERE organization_id = 0",
+			clause.Table{Name: table}, clause.Table{Name: table}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	indexes := []struct {
+		Model interface{}
+		Name  string
+	}{
+		{&models.ProductVariant{}, "idx_product_variants_barcode"},
+		{&models.ProductBarcode{}, "idx_product_barcodes_barcode"},
+		{&models.Supplier{}, "idx_suppliers_document"},
+		{&models.Supplier{}, "idx_suppliers_organization_id"},
+	}
+	for _, index := range indexes {
+		if tx.Migrator().HasIndex(index.Model, index.Name) {
+			if err := tx.Migrator().DropIndex(index.Model, index.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// assignPrinterOrganization atribui as impressoras existentes à organização padrão
+func assignPrinterOrganization(tx *gorm.DB) error {
+	organization, _, err := defaultOrganization(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.PrinterProfile{}).Where("organization_id = ?", 0).
+		Update("organization_id", organization.ID).Error
+}
+
+// assignBatchStores atribui as movimentações de lotes à loja da movimentação de estoque e
+// cada lote à loja da sua primeira movimentação; lotes sem loja ficam na primeira loja da
+// organização, que recebeu o estoque anterior às lojas
+func assignBatchStores(tx *gorm.DB) error {
+	if err := tx.Exec(`UPDATE product_batch_movements SET store_id = (
+		SELECT stock_movements.store_id FROM stock_movements
+		WHERE stock_movements.id = product_batch_movements.stock_movement_id
+	) WHERE store_id IS NULL`).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`UPDATE product_batches SET store_id = (
+		SELECT product_batch_movements.store_id FROM product_batch_movements
+		WHERE product_batch_movements.batch_id = product_batches.id AND product_batch_movements.store_id IS NOT NULL
+		ORDER BY product_batch_movements.id LIMIT 1
+	) WHERE store_id IS NULL`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`UPDATE product_batches SET store_id = (
+		SELECT MIN(stores.id) FROM stores WHERE stores.organization_id = product_batches.organization_id
+	) WHERE store_id IS NULL`).Error
+}
+
+// assignFiscalSequenceOrganization atribui a numeração de cada série à organização que mais
+// emitiu nela (ou à organização padrão) e troca o índice único global do número da NFC-e
+// pelo índice por organização
+func assignFiscalSequenceOrganization(tx *gorm.DB) error {
+	if tx.Migrator().HasTable("fiscal_sequences_legacy") {
+		err := tx.Exec(`INSERT INTO fiscal_sequences (organization_id, series, next_number, updated_at)
+			SELECT COALESCE(
+				(SELECT organization_id FROM fiscal_documents
+					WHERE fiscal_documents.series = legacy.series AND organization_id <> 0
+					GROUP BY organization_id ORDER BY COUNT(*) DESC, organization_id LIMIT 1),
+				(SELECT MIN(id) FROM organizations)),
+				series, next_number, updated_at
+			FROM fiscal_sequences_legacy legacy`).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropTable("fiscal_sequences_legacy"); err != nil {
+			return err
+		}
+	}
+
+	if tx.Migrator().HasIndex(&models.FiscalDocument{}, "idx_fiscal_document_number") {
+		return tx.Migrator().DropIndex(&models.FiscalDocument{}, "idx_fiscal_document_number")
+	}
+	return nil
+}
+
+// assignInventoryCountStores atribui os balanços existentes à primeira loja da organização,
+// que recebeu o estoque anterior às lojas
+func assignInventoryCountStores(tx *gorm.DB) error {
+	return tx.Exec(`UPDATE inventory_counts SET store_id = (
+		SELECT MIN(stores.id) FROM stores WHERE stores.organization_id = inventory_counts.organization_id
+	) WHERE store_id IS NULL`).Error
+}
+
+// assignStoreVariantStock reconstrói o saldo das variações em cada loja a partir das
+// movimentações com loja; o restante do saldo, anterior às lojas, fica na primeira loja da
+// organização, como no estoque dos produtos
+func assignStoreVariantStock(tx *gorm.DB) error {
+	now := time.Now()
+	err := tx.Exec(`INSERT INTO store_variants (store_id, variant_id, product_id, stock, created_at, updated_at)
+		SELECT store_id, variant_id, product_id, SUM(quantity), ?, ? FROM stock_movements
+		WHERE variant_id IS NOT NULL AND store_id IS NOT NULL
+		GROUP BY store_id, variant_id, product_id`, now, now).Error
+	if err != nil {
+		return err
+	}
+
+	var stores []models.Store
+	if err := tx.Where("id IN (?)", tx.Model(&models.Store{}).Select("MIN(id)").Group("organization_id")).
+		Find(&stores).Error; err != nil {
+		return err
+	}
+	for _, store := range stores {
+		remainder := `product_variants.stock - COALESCE((SELECT SUM(stock) FROM store_variants
+			WHERE store_variants.variant_id = product_variants.id), 0)`
+		err := tx.Exec(`UPDATE store_variants SET stock = stock + (SELECT `+remainder+` FROM product_variants
+			WHERE product_variants.id = store_variants.variant_id)
+			WHERE store_id = ?`, store.ID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`INSERT INTO store_variants (store_id, variant_id, product_id, stock, created_at, updated_at)
+			SELECT ?, id, product_id, `+remainder+`, ?, ? FROM product_variants
+			WHERE organization_id = ? AND `+remainder+` <> 0
+			AND id NOT IN (SELECT variant_id FROM store_variants WHERE store_id = ?)`,
+			store.ID, now, now, store.OrganizationID, store.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceInventoryCountItemIndex remove o índice único por balanço e produto, substituído pelo
+// índice que inclui a variação, para que produtos com variações sejam contados por variação
+func replaceInventoryCountItemIndex(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&models.InventoryCountItem{}, "idx_inventory_count_product") {
+		return tx.Migrator().DropIndex(&models.InventoryCountItem{}, "idx_inventory_count_product")
+	}
+	return nil
+}
@@ -9,11 +9,13 @@ import (
 func SeedData() {
 	createDefaultCategories()
 	createDefaultProducts()
+	createDefaultScaleLabelLayouts()
 }
 
 func createDefaultCategories() {
+	db := defaultOrganizationDB()
 	var count int64
-	DB.Model(&models.Category{}).Count(&count)
+	db.Model(&models.Category{}).Count(&count)
 
 	if count == 0 {
 		categories := []models.Category{
@@ -40,7 +42,7 @@ func createDefaultCategories() {
 		}
 
 		for _, category := range categories {
-			if err := DB.Create(&category).Error; err != nil {
+			if err := db.Create(&category).Error; err != nil {
 				log.Printf("Erro ao criar categoria %s: %v", category.Name, err)
 			} else {
 				log.Printf("Categoria %s criada com sucesso", category.Name)
@@ -49,17 +51,46 @@ func createDefaultCategories() {
 	}
 }
 
+// createDefaultScaleLabelLayouts cadastra o formato de etiqueta mais comum das balanças
+// (2CCCC0TTTTTTD: código com 4 dígitos e preço total com 6)
+func createDefaultScaleLabelLayouts() {
+	db := defaultOrganizationDB()
+	var count int64
+	db.Model(&models.ScaleLabelLayout{}).Count(&count)
+
+	if count == 0 {
+		layout := models.ScaleLabelLayout{
+			Name:          "Padrão (preço total)",
+			Prefix:        "2",
+			CodeStart:     2,
+			CodeLength:    4,
+			ValueStart:    7,
+			ValueLength:   6,
+			ValueType:     models.ScaleValuePrice,
+			ValueDecimals: 2,
+			Active:        true,
+		}
+
+		if err := db.Create(&layout).Error; err != nil {
+			log.Printf("Erro ao criar formato de etiqueta %s: %v", layout.Name, err)
+		} else {
+			log.Printf("Formato de etiqueta %s criado com sucesso", layout.Name)
+		}
+	}
+}
+
 func createDefaultProducts() {
+	db := defaultOrganizationDB()
 	var count int64
-	DB.Model(&models.Product{}).Count(&count)
+	db.Model(&models.Product{}).Count(&count)
 
 	if count == 0 {
 		// Buscar categorias criadas
 		var bebidas, alimentacao, higiene, limpeza models.Category
-		DB.Where("name = ?", "Bebidas").First(&bebidas)
-		DB.Where("name = ?", "Alimentação").First(&alimentacao)
-		DB.Where("name = ?", "Higiene").First(&higiene)
-		DB.Where("name = ?", "Limpeza").First(&limpeza)
+		db.Where("name = ?", "Bebidas").First(&bebidas)
+		db.Where("name = ?", "Alimentação").First(&alimentacao)
+		db.Where("name = ?", "Higiene").First(&higiene)
+		db.Where("name = ?", "Limpeza").First(&limpeza)
 
 		products := []models.Product{
 			// Bebidas
@@ -67,8 +98,8 @@ func createDefaultProducts() {
 				Name:        "Coca-Cola 350ml",
 				Barcode:     "7894900011517",
 				Description: "Refrigerante Coca-Cola lata 350ml",
-				Price:       3.50,
-				CostPrice:   2.00,
+				Price:       models.NewMoney(3.50),
+				CostPrice:   models.NewMoney(2.00),
 				Stock:       100,
 				MinStock:    10,
 				Unit:        "un",
@@ -79,8 +110,8 @@ func createDefaultProducts() {
 				Name:        "Água Mineral 500ml",
 				Barcode:     "7891000100103",
 				Description: "Água mineral natural 500ml",
-				Price:       2.00,
-				CostPrice:   1.20,
+				Price:       models.NewMoney(2.00),
+				CostPrice:   models.NewMoney(1.20),
 				Stock:       150,
 				MinStock:    20,
 				Unit:        "un",
@@ -91,8 +122,8 @@ func createDefaultProducts() {
 				Name:        "Suco de Laranja 1L",
 				Barcode:     "7891000315507",
 				Description: "Suco de laranja natural 1 litro",
-				Price:       6.50,
-				CostPrice:   4.00,
+				Price:       models.NewMoney(6.50),
+				CostPrice:   models.NewMoney(4.00),
 				Stock:       80,
 				MinStock:    15,
 				Unit:        "un",
@@ -105,8 +136,8 @@ func createDefaultProducts() {
 				Name:        "Pão de Açúcar 500g",
 				Barcode:     "7891000053607",
 				Description: "Pão de açúcar tradicional 500g",
-				Price:       4.50,
-				CostPrice:   3.00,
+				Price:       models.NewMoney(4.50),
+				CostPrice:   models.NewMoney(3.00),
 				Stock:       50,
 				MinStock:    10,
 				Unit:        "un",
@@ -117,8 +148,8 @@ func createDefaultProducts() {
 				Name:        "Leite Integral 1L",
 				Barcode:     "7891000100202",
 				Description: "Leite integral UHT 1 litro",
-				Price:       4.80,
-				CostPrice:   3.50,
+				Price:       models.NewMoney(4.80),
+				CostPrice:   models.NewMoney(3.50),
 				Stock:       120,
 				MinStock:    25,
 				Unit:        "un",
@@ -129,8 +160,8 @@ func createDefaultProducts() {
 				Name:        "Arroz Branco 5kg",
 				Barcode:     "7891000244807",
 				Description: "Arroz branco tipo 1 - 5kg",
-				Price:       18.90,
-				CostPrice:   14.00,
+				Price:       models.NewMoney(18.90),
+				CostPrice:   models.NewMoney(14.00),
 				Stock:       30,
 				MinStock:    5,
 				Unit:        "un",
@@ -143,8 +174,8 @@ func createDefaultProducts() {
 				Name:        "Sabonete Dove 90g",
 				Barcode:     "7891150013711",
 				Description: "Sabonete em barra Dove 90g",
-				Price:       3.20,
-				CostPrice:   2.10,
+				Price:       models.NewMoney(3.20),
+				CostPrice:   models.NewMoney(2.10),
 				Stock:       200,
 				MinStock:    30,
 				Unit:        "un",
@@ -155,8 +186,8 @@ func createDefaultProducts() {
 				Name:        "Shampoo Seda 325ml",
 				Barcode:     "7891150047426",
 				Description: "Shampoo Seda Reconstrução 325ml",
-				Price:       12.90,
-				CostPrice:   8.50,
+				Price:       models.NewMoney(12.90),
+				CostPrice:   models.NewMoney(8.50),
 				Stock:       60,
 				MinStock:    10,
 				Unit:        "un",
@@ -169,8 +200,8 @@ func createDefaultProducts() {
 				Name:        "Detergente Ypê 500ml",
 				Barcode:     "7896098900116",
 				Description: "Detergente líquido Ypê neutro 500ml",
-				Price:       2.80,
-				CostPrice:   1.90,
+				Price:       models.NewMoney(2.80),
+				CostPrice:   models.NewMoney(1.90),
 				Stock:       90,
 				MinStock:    15,
 				Unit:        "un",
@@ -181,8 +212,8 @@ func createDefaultProducts() {
 				Name:        "Papel Higiênico 4 rolos",
 				Barcode:     "7891000315608",
 				Description: "Papel higiênico folha dupla 4 rolos",
-				Price:       8.50,
-				CostPrice:   6.00,
+				Price:       models.NewMoney(8.50),
+				CostPrice:   models.NewMoney(6.00),
 				Stock:       40,
 				MinStock:    8,
 				Unit:        "un",
@@ -192,13 +223,18 @@ func createDefaultProducts() {
 		}
 
 		for _, product := range products {
-			if err := DB.Create(&product).Error; err != nil {
+			if err := db.Create(&product).Error; err != nil {
 				log.Printf("Erro ao criar produto %s: %v", product.Name, err)
 			} else {
 				log.Printf("Produto %s criado com sucesso (Código: %s)", product.Name, product.Barcode)
 			}
 		}
 
+		// Atribuir o estoque de exemplo à loja principal
+		if err := assignStoreStock(DB); err != nil {
+			log.Printf("Erro ao atribuir estoque à loja: %v", err)
+		}
+
 		log.Println("Produtos de exemplo criados com sucesso!")
 		log.Println("Códigos de barras para teste:")
 		log.Println("- Coca-Cola: 7894900011517")
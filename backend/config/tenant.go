@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// organizationKey é a chave da organização no contexto das consultas
+type organizationKey struct{}
+
+// WithOrganization retorna um contexto que restringe as consultas feitas com ele
+// (DB.WithContext) aos registros da organização informada
+func WithOrganization(ctx context.Context, organizationID uint) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationID)
+}
+
+// OrganizationFromContext retorna a organização do contexto, se houver
+func OrganizationFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	organizationID, ok := ctx.Value(organizationKey{}).(uint)
+	return organizationID, ok && organizationID != 0
+}
+
+// registerTenantScope registra os callbacks que, para os modelos com a coluna
+// organization_id, filtram consultas, alterações e exclusões pela organização do contexto
+// e a preenchem nos registros criados. Consultas sem organização no contexto (ex.: login,
+// migrações e tarefas em segundo plano) não são filtradas
+func registerTenantScope(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeOrganization)
+	db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeOrganization)
+	db.Callback().Update().Before("gorm:update").Register("tenant:update", scopeOrganization)
+	db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeOrganization)
+	db.Callback().Create().Before("gorm:create").Register("tenant:create", assignOrganization)
+}
+
+// statementOrganization retorna a organização do contexto quando o modelo da instrução
+// possui a coluna organization_id
+func statementOrganization(db *gorm.DB) (uint, bool) {
+	if db.Statement.Schema == nil || db.Statement.Schema.LookUpField("organization_id") == nil {
+		return 0, false
+	}
+	return OrganizationFromContext(db.Statement.Context)
+}
+
+func scopeOrganization(db *gorm.DB) {
+	organizationID, ok := statementOrganization(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"}, Value: organizationID},
+	}})
+}
+
+func assignOrganization(db *gorm.DB) {
+	organizationID, ok := statementOrganization(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("organization_id")
+	ctx := db.Statement.Context
+
+	assign := func(value reflect.Value) {
+		if _, zero := field.ValueOf(ctx, value); zero {
+			if err := field.Set(ctx, value, organizationID); err != nil {
+				db.AddError(err)
+			}
+		}
+	}
+
+	switch value := reflect.Indirect(db.Statement.ReflectValue); value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			assign(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		assign(value)
+	}
+}
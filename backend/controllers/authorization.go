@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/config"
+	"pdv-backend/models"
+)
+
+// GetSupervisorAuthorizations retorna as ações aprovadas por supervisores e as tentativas
+// recusadas por credenciais inválidas
+func GetSupervisorAuthorizations(c *gin.Context) {
+	var authorizations []models.SupervisorAuthorization
+	query := tenantDB(c).Preload("RequestedBy").Preload("ApprovedBy")
+
+	// Filtros opcionais
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if requestedBy := c.Query("requested_by_id"); requestedBy != "" {
+		query = query.Where("requested_by_id = ?", requestedBy)
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if approvedBy := c.Query("approved_by_id"); approvedBy != "" {
+		query = query.Where("approved_by_id = ?", approvedBy)
+	}
+
+	if saleID := c.Query("sale_id"); saleID != "" {
+		query = query.Where("sale_id = ?", saleID)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("created_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("created_at <= ?", endOfDay)
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&authorizations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar autorizações"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.SupervisorAuthorizationResponse, len(authorizations))
+	for i := range authorizations {
+		responses[i] = authorizations[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// ChangePIN define ou remove o PIN do usuário autenticado, usado para autorizar ações de
+// outros operadores no terminal deles
+func ChangePIN(c *gin.Context) {
+	type ChangePINRequest struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		PIN             string `json:"pin" binding:"omitempty,numeric,min=4,max=8"` // vazio remove o PIN
+	}
+
+	var req ChangePINRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := c.MustGet("user").(models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro interno do servidor"})
+		return
+	}
+
+	if !user.CheckPassword(req.CurrentPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Senha atual incorreta"})
+		return
+	}
+
+	if err := user.SetPIN(req.PIN); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao definir PIN"})
+		return
+	}
+	// Um novo PIN encerra o bloqueio por tentativas inválidas
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{
+		"pin":                        user.PIN,
+		"authorization_failures":     0,
+		"authorization_locked_until": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao definir PIN"})
+		return
+	}
+
+	if req.PIN == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "PIN removido com sucesso"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "PIN definido com sucesso"})
+}
+
+// supervisorLocks serializa a verificação das credenciais de cada supervisor, para que
+// tentativas simultâneas não escapem do limite de falhas
+var supervisorLocks sync.Map
+
+// lockSupervisor bloqueia a verificação das credenciais do supervisor e retorna a função
+// que a libera
+func lockSupervisor(id uint) func() {
+	value, _ := supervisorLocks.LoadOrStore(id, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// authorizeAction verifica se o operador pode executar a ação protegida pela permissão de
+// attempt. Sem ela, a ação (descrita em description, ex.: "Cancelamento de venda") precisa
+// ser aprovada por um supervisor que a possua, com as credenciais informadas na própria
+// requisição. Retorna o supervisor que aprovou (nil quando o próprio operador tem a
+// permissão); se a ação for recusada, desfaz tx (a transação da ação, quando houver),
+// responde à requisição e retorna false.
+//
+// Credenciais recusadas ficam registradas com status denied e contam como falha do
+// supervisor: a partir de MaxAuthorizationFailures falhas seguidas ele fica bloqueado para
+// autorizar por AuthorizationLockout, até uma autorização aceita ou a troca do PIN
+func authorizeAction(c *gin.Context, tx *gorm.DB, attempt models.SupervisorAuthorization, description string, credentials *models.SupervisorCredentials) (*models.User, bool) {
+	if hasPermission(c, attempt.Permission) {
+		return nil, true
+	}
+
+	denied := func(message string) (*models.User, bool) {
+		if tx != nil {
+			tx.Rollback()
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                  message,
+			"permission":             attempt.Permission,
+			"authorization_required": true,
+		})
+		return nil, false
+	}
+
+	if credentials == nil {
+		return denied(description + " requer autorização de um supervisor")
+	}
+
+	// O supervisor é lido fora da transação da ação para ver as falhas já registradas
+	var supervisor models.User
+	if err := tenantDB(c).Where("email = ?", credentials.Email).First(&supervisor).Error; err != nil || !supervisor.Active {
+		return denied("Credenciais do supervisor inválidas")
+	}
+	if supervisor.ID == c.GetUint("user_id") {
+		return denied("A autorização deve ser dada por outro usuário")
+	}
+
+	unlock := lockSupervisor(supervisor.ID)
+	defer unlock()
+	if err := tenantDB(c).First(&supervisor, supervisor.ID).Error; err != nil {
+		return denied("Credenciais do supervisor inválidas")
+	}
+
+	now := time.Now()
+	if supervisor.AuthorizationLocked(now) {
+		return denied("Supervisor bloqueado para autorizações até " + supervisor.AuthorizationLockedUntil.Format("02/01/2006 15:04") + " por excesso de tentativas inválidas")
+	}
+
+	// O PIN é a forma usual; a senha atende supervisores ainda sem PIN cadastrado
+	valid := false
+	if credentials.PIN != "" {
+		valid = supervisor.CheckPIN(credentials.PIN)
+	} else if credentials.Password != "" {
+		valid = supervisor.CheckPassword(credentials.Password)
+	}
+	if !valid {
+		// A falha é registrada fora da transação da ação, que é desfeita antes
+		if tx != nil {
+			tx.Rollback()
+			tx = nil
+		}
+		attempt.RequestedByID = c.GetUint("user_id")
+		if err := recordAuthorizationFailure(tenantDB(c), &supervisor, attempt, now); err != nil {
+			log.Printf("Erro ao registrar autorização recusada do supervisor %d: %v", supervisor.ID, err)
+		}
+		return denied("Credenciais do supervisor inválidas")
+	}
+
+	if !supervisor.HasPermission(attempt.Permission) {
+		return denied("Supervisor sem a permissão " + attempt.Permission)
+	}
+
+	if supervisor.AuthorizationFailures > 0 || supervisor.AuthorizationLockedUntil != nil {
+		db := tx
+		if db == nil {
+			db = tenantDB(c)
+		}
+		if err := db.Model(&supervisor).Updates(map[string]interface{}{
+			"authorization_failures":     0,
+			"authorization_locked_until": nil,
+		}).Error; err != nil {
+			log.Printf("Erro ao zerar as falhas de autorização do supervisor %d: %v", supervisor.ID, err)
+		}
+	}
+	return &supervisor, true
+}
+
+// recordAuthorizationFailure registra a tentativa recusada e conta a falha do supervisor,
+// bloqueando-o a partir de MaxAuthorizationFailures falhas seguidas
+func recordAuthorizationFailure(db *gorm.DB, supervisor *models.User, attempt models.SupervisorAuthorization, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		failures := supervisor.AuthorizationFailures + 1
+		updates := map[string]interface{}{"authorization_failures": failures}
+		if failures >= models.MaxAuthorizationFailures {
+			updates["authorization_locked_until"] = now.Add(models.AuthorizationLockout)
+		}
+		if err := tx.Model(supervisor).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		attempt.ApprovedByID = supervisor.ID
+		attempt.Status = models.AuthorizationDenied
+		attempt.Details = "Credenciais inválidas"
+		return tx.Create(&attempt).Error
+	})
+}
+
+// recordAuthorization registra a aprovação do supervisor, se houver, na mesma transação da ação
+func recordAuthorization(tx *gorm.DB, supervisor *models.User, authorization models.SupervisorAuthorization) error {
+	if supervisor == nil {
+		return nil
+	}
+	authorization.ApprovedByID = supervisor.ID
+	authorization.Status = models.AuthorizationApproved
+	return tx.Create(&authorization).Error
+}
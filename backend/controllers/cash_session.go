@@ -0,0 +1,475 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+)
+
+// GetCashSessions retorna as sessões de caixa
+func GetCashSessions(c *gin.Context) {
+	var sessions []models.CashSession
+	query := tenantDB(c).Preload("User")
+
+	// Operadores de caixa só visualizam as próprias sessões
+	if hasPermission(c, models.PermissionCashManage) {
+		if userID := c.Query("user_id"); userID != "" {
+			query = query.Where("user_id = ?", userID)
+		}
+	} else {
+		query = query.Where("user_id = ?", c.GetUint("user_id"))
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Filtro por data de abertura
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("opened_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("opened_at <= ?", endOfDay)
+		}
+	}
+
+	if err := query.Order("opened_at DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar sessões de caixa"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetCashSession retorna uma sessão de caixa específica
+func GetCashSession(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetCurrentCashSession retorna a sessão de caixa aberta do usuário autenticado
+func GetCurrentCashSession(c *gin.Context) {
+	var session models.CashSession
+	if err := tenantDB(c).Preload("User").Preload("Movements").
+		Where("user_id = ? AND status = ?", c.GetUint("user_id"), models.CashSessionOpen).
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nenhum caixa aberto para este operador"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// OpenCashSession abre uma sessão de caixa para o usuário autenticado
+func OpenCashSession(c *gin.Context) {
+	var req models.OpenCashSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	// Verificar se o operador já possui um caixa aberto
+	var existing models.CashSession
+	if err := tenantDB(c).Where("user_id = ? AND status = ?", userID, models.CashSessionOpen).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um caixa aberto para este operador"})
+		return
+	}
+
+	session := models.CashSession{
+		UserID:        userID,
+		OpeningAmount: *req.OpeningAmount,
+		OpeningNotes:  req.Notes,
+		Status:        models.CashSessionOpen,
+		OpenedAt:      time.Now(),
+	}
+
+	// O índice único de caixas abertos recusa a abertura simultânea de outro caixa do operador
+	if err := tenantDB(c).Create(&session).Error; err != nil {
+		if tenantDB(c).Where("user_id = ? AND status = ?", userID, models.CashSessionOpen).First(&existing).Error == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um caixa aberto para este operador"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir caixa"})
+		return
+	}
+
+	tenantDB(c).Preload("User").First(&session, session.ID)
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// AddCashMovement registra uma sangria ou suprimento na sessão de caixa
+func AddCashMovement(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	var req models.CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !session.IsOpen() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sessão de caixa não está aberta"})
+		return
+	}
+
+	// Uma sangria não pode retirar mais dinheiro do que há na gaveta
+	if req.Type == models.CashMovementSangria {
+		report, err := buildCashSessionReport(tenantDB(c), session)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular saldo do caixa"})
+			return
+		}
+		if req.Amount > report.ExpectedCash {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Valor da sangria maior que o saldo em dinheiro do caixa"})
+			return
+		}
+	}
+
+	movement := models.CashMovement{
+		CashSessionID: session.ID,
+		Type:          req.Type,
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+		UserID:        c.GetUint("user_id"),
+	}
+
+	if err := tenantDB(c).Create(&movement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar movimentação de caixa"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+// CloseCashSession fecha a sessão de caixa com os valores conferidos e retorna a redução Z
+func CloseCashSession(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	var req models.CloseCashSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !session.IsOpen() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sessão de caixa já está fechada"})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear a sessão até o fim do fechamento: vendas em andamento terminam antes da
+	// conferência e as seguintes encontram o caixa fechado
+	var locked models.CashSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, session.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar sessão de caixa"})
+		return
+	}
+	if !locked.IsOpen() {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sessão de caixa já está fechada"})
+		return
+	}
+
+	now := time.Now()
+	session.Status = models.CashSessionClosed
+	session.ClosedAt = &now
+	session.ClosingNotes = req.Notes
+
+	if err := saveCashCounts(tx, &session, req.Counts); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar conferência do caixa"})
+		return
+	}
+
+	if err := tx.Omit("User", "Movements", "Counts", "Sales").Save(&session).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao fechar caixa"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar fechamento do caixa"})
+		return
+	}
+
+	renderCashSessionReport(c, session.ID)
+}
+
+// ReconcileCashSession confirma a conferência de uma sessão fechada, permitindo ajustar os valores contados
+func ReconcileCashSession(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	type ReconcileRequest struct {
+		Counts []models.CashCountRequest `json:"counts" binding:"omitempty,dive"`
+		Notes  string                    `json:"notes" binding:"max=500"`
+	}
+
+	var req ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if session.Status != models.CashSessionClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas sessões fechadas podem ser conferidas"})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if len(req.Counts) > 0 {
+		if err := saveCashCounts(tx, &session, req.Counts); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar conferência do caixa"})
+			return
+		}
+	}
+
+	now := time.Now()
+	reconciledBy := c.GetUint("user_id")
+	session.Status = models.CashSessionReconciled
+	session.ReconciledAt = &now
+	session.ReconciledByID = &reconciledBy
+	if req.Notes != "" {
+		session.ClosingNotes = req.Notes
+	}
+
+	if err := tx.Omit("User", "Movements", "Counts", "Sales").Save(&session).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao conferir caixa"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar conferência do caixa"})
+		return
+	}
+
+	renderCashSessionReport(c, session.ID)
+}
+
+// GetCashSessionReport retorna a redução Z de uma sessão de caixa
+func GetCashSessionReport(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	renderCashSessionReport(c, session.ID)
+}
+
+// findCashSession busca a sessão informada na URL, respeitando o acesso do operador
+func findCashSession(c *gin.Context) (models.CashSession, bool) {
+	var session models.CashSession
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return session, false
+	}
+
+	if err := tenantDB(c).Preload("User").Preload("Movements").Preload("Counts").First(&session, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sessão de caixa não encontrada"})
+		return session, false
+	}
+
+	if session.UserID != c.GetUint("user_id") && !hasPermission(c, models.PermissionCashManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito ao operador do caixa"})
+		return session, false
+	}
+
+	return session, true
+}
+
+// saveCashCounts grava os valores conferidos, calculando esperado e diferença por forma de pagamento
+func saveCashCounts(tx *gorm.DB, session *models.CashSession, counts []models.CashCountRequest) error {
+	report, err := buildCashSessionReport(tx, *session)
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]models.Money, len(report.Payments))
+	for _, payment := range report.Payments {
+		expected[payment.PaymentType] = payment.Expected
+	}
+
+	if err := tx.Where("cash_session_id = ?", session.ID).Delete(&models.CashSessionCount{}).Error; err != nil {
+		return err
+	}
+
+	counted := make(map[string]models.Money, len(counts))
+	for _, count := range counts {
+		counted[count.PaymentType] = *count.Counted
+	}
+
+	for _, paymentType := range models.PaymentTypes {
+		count := models.CashSessionCount{
+			CashSessionID: session.ID,
+			PaymentType:   paymentType,
+			Expected:      expected[paymentType],
+			Counted:       counted[paymentType],
+			Difference:    counted[paymentType] - expected[paymentType],
+		}
+		if err := tx.Create(&count).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderCashSessionReport calcula e responde a redução Z da sessão
+func renderCashSessionReport(c *gin.Context, sessionID uint) {
+	var session models.CashSession
+	if err := tenantDB(c).Preload("User").Preload("Movements").Preload("Counts").First(&session, sessionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sessão de caixa não encontrada"})
+		return
+	}
+
+	report, err := buildCashSessionReport(tenantDB(c), session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar relatório do caixa"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// buildCashSessionReport calcula a redução Z a partir das vendas vinculadas à sessão
+func buildCashSessionReport(db *gorm.DB, session models.CashSession) (models.CashSessionReport, error) {
+	report := models.CashSessionReport{Session: session}
+
+	type saleTotals struct {
+		Count      int64
+		Total      models.Money
+		Discount   models.Money
+		Tax        models.Money
+		FinalTotal models.Money
+	}
+
+	var completed saleTotals
+	if err := db.Model(&models.Sale{}).
+		Select("COUNT(*) as count, COALESCE(SUM(total), 0) as total, COALESCE(SUM(discount), 0) as discount, COALESCE(SUM(tax), 0) as tax, COALESCE(SUM(final_total), 0) as final_total").
+		Where("cash_session_id = ? AND status IN ?", session.ID, models.CompletedSaleStatuses).
+		Scan(&completed).Error; err != nil {
+		return report, err
+	}
+
+	var cancelled saleTotals
+	if err := db.Model(&models.Sale{}).
+		Select("COUNT(*) as count, COALESCE(SUM(final_total), 0) as final_total").
+		Where("cash_session_id = ? AND status = ?", session.ID, "cancelled").
+		Scan(&cancelled).Error; err != nil {
+		return report, err
+	}
+
+	report.TotalSales = completed.Count
+	report.GrossTotal = completed.Total
+	report.TotalDiscount = completed.Discount
+	report.TotalTax = completed.Tax
+	report.NetTotal = completed.FinalTotal
+	report.CancelledSales = cancelled.Count
+	report.CancelledTotal = cancelled.FinalTotal
+
+	// Sangrias e suprimentos
+	if err := db.Model(&models.CashMovement{}).
+		Where("cash_session_id = ? AND type = ?", session.ID, models.CashMovementSangria).
+		Select("COALESCE(SUM(amount), 0)").Scan(&report.TotalSangrias).Error; err != nil {
+		return report, err
+	}
+	if err := db.Model(&models.CashMovement{}).
+		Where("cash_session_id = ? AND type = ?", session.ID, models.CashMovementSuprimento).
+		Select("COALESCE(SUM(amount), 0)").Scan(&report.TotalSuprimentos).Error; err != nil {
+		return report, err
+	}
+
+	// Totais por forma de pagamento
+	type paymentTotals struct {
+		PaymentType string
+		Count       int64
+		Total       models.Money
+	}
+
+	var totals []paymentTotals
+	if err := db.Model(&models.SalePayment{}).
+		Select("sale_payments.payment_type, COUNT(DISTINCT sale_payments.sale_id) as count, COALESCE(SUM(sale_payments.amount), 0) as total").
+		Joins("JOIN sales ON sales.id = sale_payments.sale_id").
+		Where("sales.cash_session_id = ? AND sales.status IN ?", session.ID, models.CompletedSaleStatuses).
+		Group("sale_payments.payment_type").
+		Scan(&totals).Error; err != nil {
+		return report, err
+	}
+
+	byType := make(map[string]paymentTotals, len(totals))
+	for _, total := range totals {
+		byType[total.PaymentType] = total
+	}
+
+	report.ExpectedCash = session.OpeningAmount + byType["dinheiro"].Total + report.TotalSuprimentos - report.TotalSangrias
+
+	counted := make(map[string]models.CashSessionCount, len(session.Counts))
+	for _, count := range session.Counts {
+		counted[count.PaymentType] = count
+	}
+
+	for _, paymentType := range models.PaymentTypes {
+		summary := models.CashPaymentSummary{
+			PaymentType: paymentType,
+			Sales:       byType[paymentType].Count,
+			Expected:    byType[paymentType].Total,
+		}
+		if paymentType == "dinheiro" {
+			summary.Expected = report.ExpectedCash
+		}
+		if count, ok := counted[paymentType]; ok {
+			summary.Counted = count.Counted
+			summary.Difference = count.Counted - summary.Expected
+			report.TotalDifference += summary.Difference
+		}
+		report.Payments = append(report.Payments, summary)
+	}
+
+	return report, nil
+}
@@ -5,14 +5,13 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"pdv-backend/config"
 	"pdv-backend/models"
 )
 
 // GetCategories retorna todas as categorias
 func GetCategories(c *gin.Context) {
 	var categories []models.Category
-	query := config.DB
+	query := tenantDB(c)
 
 	// Filtro por status ativo
 	if active := c.Query("active"); active != "" {
@@ -47,7 +46,7 @@ func GetCategory(c *gin.Context) {
 	}
 
 	var category models.Category
-	if err := config.DB.First(&category, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&category, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Categoria não encontrada"})
 		return
 	}
@@ -65,7 +64,7 @@ func CreateCategory(c *gin.Context) {
 
 	// Verificar se já existe uma categoria com o mesmo nome
 	var existingCategory models.Category
-	if err := config.DB.Where("name = ?", req.Name).First(&existingCategory).Error; err == nil {
+	if err := tenantDB(c).Where("name = ?", req.Name).First(&existingCategory).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe uma categoria com este nome"})
 		return
 	}
@@ -80,7 +79,7 @@ func CreateCategory(c *gin.Context) {
 		category.Active = *req.Active
 	}
 
-	if err := config.DB.Create(&category).Error; err != nil {
+	if err := tenantDB(c).Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar categoria"})
 		return
 	}
@@ -104,14 +103,14 @@ func UpdateCategory(c *gin.Context) {
 
 	// Buscar categoria
 	var category models.Category
-	if err := config.DB.First(&category, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&category, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Categoria não encontrada"})
 		return
 	}
 
 	// Verificar se já existe outra categoria com o mesmo nome
 	var existingCategory models.Category
-	if err := config.DB.Where("name = ? AND id != ?", req.Name, category.ID).First(&existingCategory).Error; err == nil {
+	if err := tenantDB(c).Where("name = ? AND id != ?", req.Name, category.ID).First(&existingCategory).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe uma categoria com este nome"})
 		return
 	}
@@ -124,7 +123,7 @@ func UpdateCategory(c *gin.Context) {
 		category.Active = *req.Active
 	}
 
-	if err := config.DB.Save(&category).Error; err != nil {
+	if err := tenantDB(c).Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar categoria"})
 		return
 	}
@@ -141,20 +140,20 @@ func DeleteCategory(c *gin.Context) {
 	}
 
 	var category models.Category
-	if err := config.DB.First(&category, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&category, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Categoria não encontrada"})
 		return
 	}
 
 	// Verificar se a categoria tem produtos associados
 	var productCount int64
-	config.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&productCount)
+	tenantDB(c).Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&productCount)
 	if productCount > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir categoria com produtos associados"})
 		return
 	}
 
-	if err := config.DB.Delete(&category).Error; err != nil {
+	if err := tenantDB(c).Delete(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir categoria"})
 		return
 	}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// GetCustomers retorna todos os clientes
+func GetCustomers(c *gin.Context) {
+	var customers []models.Customer
+	query := tenantDB(c)
+
+	// Filtro por status ativo
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	// Filtro de busca por nome, documento, email ou telefone
+	if search := c.Query("search"); search != "" {
+		like := "%" + search + "%"
+		conditions := "name LIKE ? OR email LIKE ? OR phone LIKE ?"
+		args := []interface{}{like, like, like}
+		if digits := validators.OnlyDigits(search); digits != "" {
+			conditions += " OR document LIKE ?"
+			args = append(args, "%"+digits+"%")
+		}
+		query = query.Where(conditions, args...)
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("name ASC").Offset(offset).Limit(limit).Find(&customers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar clientes"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.CustomerResponse, len(customers))
+	for i, customer := range customers {
+		responses[i] = customer.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetCustomer retorna um cliente específico
+func GetCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var customer models.Customer
+	if err := tenantDB(c).First(&customer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// CreateCustomer cria um novo cliente
+func CreateCustomer(c *gin.Context) {
+	var req models.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var customer models.Customer
+	if err := fillCustomer(&customer, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verificar se o documento já está cadastrado
+	if customer.Document != nil {
+		var existingCustomer models.Customer
+		if err := tenantDB(c).Where("document = ?", *customer.Document).First(&existingCustomer).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um cliente com este CPF/CNPJ"})
+			return
+		}
+	}
+
+	if err := tenantDB(c).Create(&customer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar cliente"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, customer.ToResponse())
+}
+
+// UpdateCustomer atualiza um cliente
+func UpdateCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Buscar cliente
+	var customer models.Customer
+	if err := tenantDB(c).First(&customer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente não encontrado"})
+		return
+	}
+
+	if err := fillCustomer(&customer, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verificar se o documento já pertence a outro cliente
+	if customer.Document != nil {
+		var existingCustomer models.Customer
+		if err := tenantDB(c).Where("document = ? AND id != ?", *customer.Document, customer.ID).First(&existingCustomer).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um cliente com este CPF/CNPJ"})
+			return
+		}
+	}
+
+	if err := tenantDB(c).Save(&customer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar cliente"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer.ToResponse())
+}
+
+// DeleteCustomer exclui um cliente
+func DeleteCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var customer models.Customer
+	if err := tenantDB(c).First(&customer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente não encontrado"})
+		return
+	}
+
+	// Verificar se o cliente tem vendas associadas
+	var saleCount int64
+	tenantDB(c).Model(&models.Sale{}).Where("customer_id = ?", customer.ID).Count(&saleCount)
+	if saleCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir cliente com vendas associadas"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&customer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir cliente"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cliente excluído com sucesso"})
+}
+
+// GetCustomerSales retorna o histórico de compras do cliente com valor acumulado e última compra
+func GetCustomerSales(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var customer models.Customer
+	if err := tenantDB(c).First(&customer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente não encontrado"})
+		return
+	}
+
+	summary := models.CustomerSalesSummary{Customer: customer.ToResponse()}
+
+	// Indicadores consideram apenas vendas concluídas
+	completed := tenantDB(c).Model(&models.Sale{}).Where("customer_id = ? AND status IN ?", customer.ID, models.CompletedSaleStatuses)
+	completed.Session(&gorm.Session{}).Count(&summary.TotalPurchases)
+	completed.Session(&gorm.Session{}).Select("COALESCE(SUM(final_total), 0)").Scan(&summary.LifetimeValue)
+	summary.AverageTicket = summary.LifetimeValue.Div(summary.TotalPurchases)
+
+	if summary.TotalPurchases > 0 {
+		var first, last models.Sale
+		if err := completed.Session(&gorm.Session{}).Order("created_at ASC").First(&first).Error; err == nil {
+			summary.FirstPurchase = &first.CreatedAt
+		}
+		if err := completed.Session(&gorm.Session{}).Order("created_at DESC").First(&last).Error; err == nil {
+			summary.LastPurchase = &last.CreatedAt
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	var sales []models.Sale
+	if err := tenantDB(c).Preload("User").Preload("SaleItems.Product.Category").Preload("Payments").Preload("FiscalDocument").
+		Where("customer_id = ?", customer.ID).
+		Order("created_at DESC").Offset(offset).Limit(limit).
+		Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar vendas do cliente"})
+		return
+	}
+
+	summary.Sales = make([]models.SaleResponse, len(sales))
+	for i, sale := range sales {
+		summary.Sales[i] = sale.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// fillCustomer copia os dados da requisição para o cliente, validando CPF/CNPJ
+func fillCustomer(customer *models.Customer, req models.CustomerRequest) error {
+	customer.Name = req.Name
+	customer.Phone = req.Phone
+	customer.Email = req.Email
+	customer.ZipCode = validators.OnlyDigits(req.ZipCode)
+	customer.Street = req.Street
+	customer.Number = req.Number
+	customer.Complement = req.Complement
+	customer.District = req.District
+	customer.City = req.City
+	customer.State = strings.ToUpper(req.State)
+	customer.Notes = req.Notes
+
+	if req.Active != nil {
+		customer.Active = *req.Active
+	}
+
+	document, documentType, err := parseDocument(req.Document)
+	if err != nil {
+		return err
+	}
+	customer.Document = document
+	customer.DocumentType = documentType
+	return nil
+}
+
+// parseDocument valida um CPF ou CNPJ informado com ou sem máscara (apenas dígitos, ".",
+// "-" e "/"). Documento vazio retorna nil sem erro
+func parseDocument(raw string) (*string, string, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := func(r rune) bool { return (r < '0' || r > '9') && !strings.ContainsRune(".-/", r) }
+	if strings.IndexFunc(raw, invalid) >= 0 {
+		return nil, "", errors.New("Documento deve conter apenas números, pontos, hífen e barra")
+	}
+
+	document := validators.OnlyDigits(raw)
+	switch {
+	case document == "":
+		return nil, "", nil
+	case len(document) == 11:
+		if !validators.ValidateCPF(document) {
+			return nil, "", errors.New("CPF inválido")
+		}
+		return &document, "cpf", nil
+	case len(document) == 14:
+		if !validators.ValidateCNPJ(document) {
+			return nil, "", errors.New("CNPJ inválido")
+		}
+		return &document, "cnpj", nil
+	}
+	return nil, "", errors.New("Documento deve ser um CPF (11 dígitos) ou CNPJ (14 dígitos)")
+}
@@ -2,50 +2,64 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"pdv-backend/config"
+	"gorm.io/gorm"
 	"pdv-backend/models"
 )
 
 // DashboardStats representa as estatísticas do dashboard
 type DashboardStats struct {
-	TotalProducts    int64   `json:"total_products"`
-	ActiveProducts   int64   `json:"active_products"`
-	LowStockProducts int64   `json:"low_stock_products"`
-	TotalCategories  int64   `json:"total_categories"`
-	TotalUsers       int64   `json:"total_users"`
-	TodaySales       int64   `json:"today_sales"`
-	TodayRevenue     float64 `json:"today_revenue"`
-	MonthSales       int64   `json:"month_sales"`
-	MonthRevenue     float64 `json:"month_revenue"`
-	YearSales        int64   `json:"year_sales"`
-	YearRevenue      float64 `json:"year_revenue"`
+	TotalProducts    int64                     `json:"total_products"`
+	ActiveProducts   int64                     `json:"active_products"`
+	LowStockProducts int64                     `json:"low_stock_products"`
+	ExpiringBatches  int64                     `json:"expiring_batches"` // lotes com saldo vencidos ou a vencer em 30 dias
+	TotalCategories  int64                     `json:"total_categories"`
+	TotalUsers       int64                     `json:"total_users"`
+	TodaySales       int64                     `json:"today_sales"`
+	TodayRevenue     models.Money              `json:"today_revenue"`
+	MonthSales       int64                     `json:"month_sales"`
+	MonthRevenue     models.Money              `json:"month_revenue"`
+	YearSales        int64                     `json:"year_sales"`
+	YearRevenue      models.Money              `json:"year_revenue"`
+	TodayPayments    []models.PaymentBreakdown `json:"today_payments"`
+	MonthPayments    []models.PaymentBreakdown `json:"month_payments"`
 }
 
 // TopProduct representa um produto mais vendido
 type TopProduct struct {
-	ProductID    uint    `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	TotalSold    int64   `json:"total_sold"`
-	TotalRevenue float64 `json:"total_revenue"`
+	ProductID    uint         `json:"product_id"`
+	ProductName  string       `json:"product_name"`
+	TotalSold    float64      `json:"total_sold"`
+	TotalRevenue models.Money `json:"total_revenue"`
 }
 
-// GetDashboardStats retorna estatísticas gerais do sistema
+// GetDashboardStats retorna estatísticas gerais do sistema, consolidadas da organização ou
+// da loja informada em store_id
 func GetDashboardStats(c *gin.Context) {
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
 	var stats DashboardStats
 
 	// Estatísticas de produtos
-	config.DB.Model(&models.Product{}).Count(&stats.TotalProducts)
-	config.DB.Model(&models.Product{}).Where("active = ?", true).Count(&stats.ActiveProducts)
-	config.DB.Model(&models.Product{}).Where("stock <= min_stock AND active = ?", true).Count(&stats.LowStockProducts)
+	tenantDB(c).Model(&models.Product{}).Count(&stats.TotalProducts)
+	tenantDB(c).Model(&models.Product{}).Where("active = ?", true).Count(&stats.ActiveProducts)
+	lowStockProducts(tenantDB(c), storeID).Count(&stats.LowStockProducts)
+	storeBatches(tenantDB(c).Model(&models.ProductBatch{}), storeID).
+		Where("quantity > 0 AND expiry_date <= ?", expiryLimit(defaultExpiringDays)).
+		Where("product_id IN (?)", tenantDB(c).Model(&models.Product{}).Select("id")).
+		Count(&stats.ExpiringBatches)
 
 	// Estatísticas de categorias
-	config.DB.Model(&models.Category{}).Where("active = ?", true).Count(&stats.TotalCategories)
+	tenantDB(c).Model(&models.Category{}).Where("active = ?", true).Count(&stats.TotalCategories)
 
 	// Estatísticas de usuários
-	config.DB.Model(&models.User{}).Where("active = ?", true).Count(&stats.TotalUsers)
+	tenantDB(c).Model(&models.User{}).Where("active = ?", true).Count(&stats.TotalUsers)
 
 	// Data de hoje
 	now := time.Now()
@@ -53,29 +67,48 @@ func GetDashboardStats(c *gin.Context) {
 	endOfDay := startOfDay.Add(24*time.Hour - time.Nanosecond)
 
 	// Vendas de hoje
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfDay, endOfDay).Count(&stats.TodaySales)
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfDay, endOfDay).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.TodayRevenue)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfDay, endOfDay).Count(&stats.TodaySales)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfDay, endOfDay).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.TodayRevenue)
 
 	// Vendas do mês
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfMonth, endOfMonth).Count(&stats.MonthSales)
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfMonth, endOfMonth).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.MonthRevenue)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfMonth, endOfMonth).Count(&stats.MonthSales)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfMonth, endOfMonth).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.MonthRevenue)
 
 	// Vendas do ano
 	startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
 	endOfYear := startOfYear.AddDate(1, 0, 0).Add(-time.Nanosecond)
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfYear, endOfYear).Count(&stats.YearSales)
-	config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfYear, endOfYear).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.YearRevenue)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfYear, endOfYear).Count(&stats.YearSales)
+	storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfYear, endOfYear).Select("COALESCE(SUM(final_total), 0)").Scan(&stats.YearRevenue)
+
+	// Faturamento por forma de pagamento
+	var err error
+	stats.TodayPayments, err = paymentBreakdown(storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfDay, endOfDay))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar faturamento por forma de pagamento"})
+		return
+	}
+	stats.MonthPayments, err = paymentBreakdown(storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfMonth, endOfMonth))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar faturamento por forma de pagamento"})
+		return
+	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
-// GetLowStockProducts retorna produtos com estoque baixo
+// GetLowStockProducts retorna produtos com estoque baixo, consolidado da organização ou na
+// loja informada em store_id
 func GetLowStockProducts(c *gin.Context) {
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
 	var products []models.Product
 
-	if err := config.DB.Preload("Category").Where("stock <= min_stock AND active = ?", true).Order("stock ASC").Find(&products).Error; err != nil {
+	if err := lowStockProducts(tenantDB(c), storeID).Preload("Category").Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos com estoque baixo"})
 		return
 	}
@@ -86,11 +119,87 @@ func GetLowStockProducts(c *gin.Context) {
 		responses[i] = product.ToResponse()
 	}
 
+	if err := attachStoreProducts(tenantDB(c), storeID, products, responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
 	c.JSON(http.StatusOK, responses)
 }
 
-// GetTopProducts retorna os produtos mais vendidos
+// lowStockProducts retorna a consulta dos produtos ativos com saldo no estoque mínimo ou
+// abaixo dele: o saldo consolidado ou, com loja, o saldo e o estoque mínimo da loja
+func lowStockProducts(db *gorm.DB, storeID *uint) *gorm.DB {
+	query := db.Model(&models.Product{}).Where("products.active = ?", true)
+	if storeID == nil {
+		return query.Where("products.stock <= products.min_stock").Order("products.stock ASC")
+	}
+	return query.
+		Joins("LEFT JOIN store_products ON store_products.product_id = products.id AND store_products.store_id = ?", *storeID).
+		Where("COALESCE(store_products.stock, 0) <= COALESCE(store_products.min_stock, products.min_stock)").
+		Order("COALESCE(store_products.stock, 0) ASC")
+}
+
+// storeSales retorna a consulta das vendas da organização ou, com loja, apenas da loja
+func storeSales(c *gin.Context, storeID *uint) *gorm.DB {
+	query := tenantDB(c).Model(&models.Sale{})
+	if storeID != nil {
+		query = query.Where("store_id = ?", *storeID)
+	}
+	return query
+}
+
+// defaultExpiringDays é o prazo padrão, em dias, dos lotes a vencer
+const defaultExpiringDays = 30
+
+// expiryLimit retorna a data limite dos lotes que vencem nos próximos dias
+func expiryLimit(days int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, time.Local)
+}
+
+// GetExpiringBatches retorna os lotes com saldo vencidos ou que vencem nos próximos dias
+// (parâmetro days, padrão 30), do vencimento mais próximo ao mais distante, da organização
+// ou da loja informada em store_id
+func GetExpiringBatches(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultExpiringDays)))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Número de dias inválido"})
+		return
+	}
+
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
+	var batches []models.ProductBatch
+	if err := storeBatches(tenantDB(c).Preload("Product").Preload("Variant").Preload("Store"), storeID).
+		Joins("JOIN products ON products.id = product_batches.product_id AND products.active = ? AND products.organization_id = ?", true, c.GetUint("organization_id")).
+		Where("product_batches.quantity > 0 AND product_batches.expiry_date <= ?", expiryLimit(days)).
+		Order("product_batches.expiry_date ASC, product_batches.id ASC").
+		Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lotes a vencer"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.ProductBatchResponse, len(batches))
+	for i := range batches {
+		responses[i] = batches[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetTopProducts retorna os produtos mais vendidos, da organização ou da loja informada em
+// store_id
 func GetTopProducts(c *gin.Context) {
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
 	limit := c.DefaultQuery("limit", "10")
 	period := c.DefaultQuery("period", "month") // day, week, month, year
 
@@ -125,13 +234,13 @@ func GetTopProducts(c *gin.Context) {
 		FROM sale_items si
 		JOIN sales s ON si.sale_id = s.id
 		JOIN products p ON si.product_id = p.id
-		WHERE s.status = 'completed' AND s.created_at >= ?
+		WHERE s.id IN (?) AND s.status IN ? AND s.created_at >= ?
 		GROUP BY si.product_id, p.name
 		ORDER BY total_sold DESC
 		LIMIT ?
 	`
 
-	if err := config.DB.Raw(query, startDate, limit).Scan(&topProducts).Error; err != nil {
+	if err := tenantDB(c).Raw(query, storeSales(c, storeID).Select("id"), models.CompletedSaleStatuses, startDate, limit).Scan(&topProducts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos mais vendidos"})
 		return
 	}
@@ -139,14 +248,20 @@ func GetTopProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, topProducts)
 }
 
-// GetSalesChart retorna dados para gráfico de vendas
+// GetSalesChart retorna dados para gráfico de vendas, da organização ou da loja informada
+// em store_id
 func GetSalesChart(c *gin.Context) {
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
 	period := c.DefaultQuery("period", "week") // week, month, year
 
 	type ChartData struct {
-		Date   string  `json:"date"`
-		Sales  int64   `json:"sales"`
-		Revenue float64 `json:"revenue"`
+		Date    string       `json:"date"`
+		Sales   int64        `json:"sales"`
+		Revenue models.Money `json:"revenue"`
 	}
 
 	var chartData []ChartData
@@ -161,9 +276,9 @@ func GetSalesChart(c *gin.Context) {
 			endOfDay := startOfDay.Add(24*time.Hour - time.Nanosecond)
 
 			var sales int64
-			var revenue float64
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfDay, endOfDay).Count(&sales)
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfDay, endOfDay).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
+			var revenue models.Money
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfDay, endOfDay).Count(&sales)
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfDay, endOfDay).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
 
 			chartData = append(chartData, ChartData{
 				Date:    date.Format("02/01"),
@@ -180,9 +295,9 @@ func GetSalesChart(c *gin.Context) {
 			endOfWeek := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
 
 			var sales int64
-			var revenue float64
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfWeek, endOfWeek).Count(&sales)
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfWeek, endOfWeek).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
+			var revenue models.Money
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfWeek, endOfWeek).Count(&sales)
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfWeek, endOfWeek).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
 
 			chartData = append(chartData, ChartData{
 				Date:    startDate.Format("02/01") + "-" + endDate.Format("02/01"),
@@ -198,9 +313,9 @@ func GetSalesChart(c *gin.Context) {
 			endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
 
 			var sales int64
-			var revenue float64
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfMonth, endOfMonth).Count(&sales)
-			config.DB.Model(&models.Sale{}).Where("status = ? AND created_at BETWEEN ? AND ?", "completed", startOfMonth, endOfMonth).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
+			var revenue models.Money
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfMonth, endOfMonth).Count(&sales)
+			storeSales(c, storeID).Where("status IN ? AND created_at BETWEEN ? AND ?", models.CompletedSaleStatuses, startOfMonth, endOfMonth).Select("COALESCE(SUM(final_total), 0)").Scan(&revenue)
 
 			chartData = append(chartData, ChartData{
 				Date:    date.Format("01/2006"),
@@ -211,4 +326,4 @@ func GetSalesChart(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, chartData)
-}
\ No newline at end of file
+}
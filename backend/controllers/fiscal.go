@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/fiscal"
+	"pdv-backend/models"
+)
+
+// GetFiscalDocuments retorna as NFC-e emitidas
+func GetFiscalDocuments(c *gin.Context) {
+	var documents []models.FiscalDocument
+	query := tenantDB(c)
+
+	// Filtros opcionais
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if series := c.Query("series"); series != "" {
+		query = query.Where("series = ?", series)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("issued_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			query = query.Where("issued_at < ?", parsedDate.AddDate(0, 0, 1))
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("issued_at DESC").Offset(offset).Limit(limit).Find(&documents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar documentos fiscais"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.FiscalDocumentResponse, len(documents))
+	for i, document := range documents {
+		responses[i] = document.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetSaleFiscalDocument retorna a NFC-e de uma venda
+func GetSaleFiscalDocument(c *gin.Context) {
+	document, ok := findSaleFiscalDocument(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, document.ToResponse())
+}
+
+// GetSaleFiscalXML retorna o XML da NFC-e (autorizado, com protocolo, ou assinado em contingência).
+// Com type=cancel retorna o XML do evento de cancelamento
+func GetSaleFiscalXML(c *gin.Context) {
+	document, ok := findSaleFiscalDocument(c)
+	if !ok {
+		return
+	}
+
+	content, suffix := document.XML, "nfce"
+	if c.Query("type") == "cancel" {
+		if document.CancelXML == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "NFC-e sem evento de cancelamento"})
+			return
+		}
+		content, suffix = document.CancelXML, "cancelamento"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.xml", document.AccessKey, suffix))
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", []byte(content))
+}
+
+// EmitSaleFiscalDocument emite (ou reemite, se rejeitada) a NFC-e de uma venda
+func EmitSaleFiscalDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if fiscal.Default == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fiscal.ErrDisabled.Error()})
+		return
+	}
+
+	var sale models.Sale
+	if err := tenantDB(c).First(&sale, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venda não encontrada"})
+		return
+	}
+
+	document, err := fiscal.Default.EmitForSale(sale.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, document.ToResponse())
+}
+
+// TransmitContingencyDocuments transmite as NFC-e emitidas em contingência
+func TransmitContingencyDocuments(c *gin.Context) {
+	if fiscal.Default == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fiscal.ErrDisabled.Error()})
+		return
+	}
+
+	if err := fiscal.Default.CheckEmitter(c.GetUint("organization_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorized, err := fiscal.Default.TransmitContingency()
+
+	var pending int64
+	tenantDB(c).Model(&models.FiscalDocument{}).Where("status = ?", models.FiscalStatusContingency).Count(&pending)
+
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":      "SEFAZ indisponível: " + err.Error(),
+			"authorized": authorized,
+			"pending":    pending,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorized": authorized,
+		"pending":    pending,
+	})
+}
+
+// findSaleFiscalDocument busca a NFC-e da venda informada na rota
+func findSaleFiscalDocument(c *gin.Context) (*models.FiscalDocument, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	var document models.FiscalDocument
+	if err := tenantDB(c).Where("sale_id = ?", uint(id)).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venda sem NFC-e emitida"})
+		return nil, false
+	}
+
+	return &document, true
+}
+
+// GetFiscalInvalidations retorna as inutilizações de numeração
+func GetFiscalInvalidations(c *gin.Context) {
+	var invalidations []models.FiscalInvalidation
+	query := tenantDB(c)
+
+	if series := c.Query("series"); series != "" {
+		query = query.Where("series = ?", series)
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&invalidations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar inutilizações"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invalidations)
+}
+
+// CreateFiscalInvalidation inutiliza uma faixa de numeração não utilizada
+func CreateFiscalInvalidation(c *gin.Context) {
+	var req models.FiscalInvalidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if fiscal.Default == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fiscal.ErrDisabled.Error()})
+		return
+	}
+
+	series := fiscal.Default.Config().Series
+	if req.Series != nil {
+		series = *req.Series
+	}
+
+	invalidation, err := fiscal.Default.Invalidate(series, req.StartNumber, req.EndNumber, req.Reason, c.GetUint("organization_id"), c.GetUint("user_id"))
+	var rejection *fiscal.RejectionError
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, invalidation)
+	case errors.As(err, &rejection):
+		c.JSON(http.StatusConflict, gin.H{"error": "Inutilização rejeitada pela SEFAZ: " + rejection.Error(), "invalidation": invalidation})
+	case errors.Is(err, fiscal.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// hasFiscalDocumentToCancel indica se a venda tem NFC-e que exige evento de cancelamento
+func hasFiscalDocumentToCancel(c *gin.Context, saleID uint) bool {
+	var count int64
+	tenantDB(c).Model(&models.FiscalDocument{}).
+		Where("sale_id = ? AND status IN ?", saleID, []string{models.FiscalStatusAuthorized, models.FiscalStatusContingency}).
+		Count(&count)
+	return count > 0
+}
+
+// cancelSaleFiscalDocument envia o cancelamento da NFC-e da venda, se houver. Quando a SEFAZ
+// rejeita ou está indisponível, a venda passa a cancel_pending. Retorna false se a resposta
+// de erro já foi enviada
+func cancelSaleFiscalDocument(c *gin.Context, sale *models.Sale, reason string) bool {
+	var document models.FiscalDocument
+	if err := tenantDB(c).Where("sale_id = ?", sale.ID).First(&document).Error; err != nil {
+		return true
+	}
+	if document.Status != models.FiscalStatusAuthorized && document.Status != models.FiscalStatusContingency {
+		return true
+	}
+
+	if fiscal.Default == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Emissão fiscal desabilitada: não é possível cancelar a NFC-e da venda"})
+		return false
+	}
+
+	_, err := fiscal.Default.CancelForSale(sale.ID, reason)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, fiscal.ErrInvalidJustification), errors.Is(err, fiscal.ErrCancelWindowExpired), errors.Is(err, fiscal.ErrNotEmitter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := tenantDB(c).Model(sale).Update("status", "cancel_pending").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar status da venda"})
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error":  "Cancelamento da NFC-e não homologado: " + err.Error(),
+		"status": sale.Status,
+	})
+	return false
+}
@@ -0,0 +1,717 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+	"pdv-backend/spreadsheet"
+)
+
+// GetInventoryCounts retorna os balanços com o resumo de cada um
+func GetInventoryCounts(c *gin.Context) {
+	var counts []models.InventoryCount
+	query := tenantDB(c).Preload("Store").Preload("User").Preload("Items")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if storeID := c.Query("store_id"); storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar balanços"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.InventoryCountResponse, len(counts))
+	for i, count := range counts {
+		responses[i] = count.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetInventoryCount retorna o balanço com os itens e o relatório de divergências.
+// Filtros: filter=variance (contados com diferença), counted, uncounted; search por nome ou código
+func GetInventoryCount(c *gin.Context) {
+	count, ok := loadInventoryCount(c)
+	if !ok {
+		return
+	}
+
+	response := count.ToResponse()
+	response.Items = []models.InventoryCountItemResponse{}
+	for _, item := range filterInventoryItems(count.Items, c.Query("filter"), c.Query("search")) {
+		response.Items = append(response.Items, item.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportInventoryCountReport exporta o relatório de divergências do balanço em CSV ou XLSX,
+// aceitando os mesmos filtros do detalhe
+func ExportInventoryCountReport(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": spreadsheet.ErrUnsupportedFormat.Error()})
+		return
+	}
+
+	count, ok := loadInventoryCount(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="balanco-%d.%s"`, count.ID, format))
+	c.Status(http.StatusOK)
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format)
+	if err != nil {
+		log.Printf("Erro ao exportar balanço: %v", err)
+		return
+	}
+
+	err = writer.WriteRow("barcode", "name", "variant", "unit", "expected_quantity", "counted_quantity",
+		"variance", "unit_cost", "variance_cost")
+	for _, item := range filterInventoryItems(count.Items, c.Query("filter"), c.Query("search")) {
+		if err != nil {
+			break
+		}
+		response := item.ToResponse()
+		var counted interface{}
+		if response.CountedQuantity != nil {
+			counted = *response.CountedQuantity
+		}
+		err = writer.WriteRow(response.Barcode, response.ProductName, response.VariantName, response.Unit,
+			response.ExpectedQuantity, counted, response.Variance, response.UnitCost, response.VarianceCost)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("Erro ao exportar balanço: %v", err)
+	}
+}
+
+// CreateInventoryCount abre o balanço de uma loja, congelando o saldo da loja e o custo dos
+// produtos ativos do escopo (todos ou uma categoria). Produtos com variações entram com um
+// item por variação ativa
+func CreateInventoryCount(c *gin.Context) {
+	var req models.InventoryCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.CategoryID != nil {
+		var category models.Category
+		if err := tenantDB(c).First(&category, *req.CategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Categoria não encontrada"})
+			return
+		}
+	}
+
+	// O balanço conta o estoque de uma loja: a informada ou a do usuário
+	storeID := userStore(c)
+	if req.StoreID != nil {
+		var store models.Store
+		if err := tenantDB(c).First(&store, *req.StoreID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Loja não encontrada"})
+			return
+		}
+		storeID = &store.ID
+	}
+	if storeID == nil {
+		var stores int64
+		tenantDB(c).Model(&models.Store{}).Count(&stores)
+		if stores > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Informe a loja do balanço"})
+			return
+		}
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Balanços abertos não podem ter produtos em comum
+	overlap := tx.Model(&models.InventoryCount{}).Where("status = ?", models.InventoryCountOpen)
+	if storeID != nil {
+		overlap = overlap.Where("store_id IS NULL OR store_id = ?", *storeID)
+	}
+	if req.CategoryID != nil {
+		overlap = overlap.Where("category_id IS NULL OR category_id = ?", *req.CategoryID)
+	}
+	var openCount int64
+	if err := overlap.Count(&openCount).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar balanços abertos"})
+		return
+	}
+	if openCount > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um balanço aberto para estes produtos"})
+		return
+	}
+
+	count := models.InventoryCount{
+		Description: req.Description,
+		StoreID:     storeID,
+		Status:      models.InventoryCountOpen,
+		CategoryID:  req.CategoryID,
+		Notes:       req.Notes,
+		UserID:      c.GetUint("user_id"),
+	}
+	if count.Description == "" {
+		count.Description = "Balanço de " + time.Now().Format("02/01/2006")
+	}
+	if err := tx.Create(&count).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir balanço"})
+		return
+	}
+
+	// Congelar saldo e custo dos produtos do escopo
+	products := tx.Model(&models.Product{}).Preload("Variants", "active = ?", true).Where("active = ?", true)
+	if req.CategoryID != nil {
+		products = products.Where("category_id = ?", *req.CategoryID)
+	}
+	var snapshot []models.Product
+	if err := products.Find(&snapshot).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos"})
+		return
+	}
+	if len(snapshot) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum produto ativo no escopo do balanço"})
+		return
+	}
+
+	stocks, err := inventoryStoreStocks(tx, count.StoreID, snapshot)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
+	var items []models.InventoryCountItem
+	for _, product := range snapshot {
+		if !product.HasVariants {
+			items = append(items, models.InventoryCountItem{
+				InventoryCountID: count.ID,
+				ProductID:        product.ID,
+				ExpectedQuantity: stocks[stockKey{ProductID: product.ID}],
+				UnitCost:         product.CostPrice,
+			})
+			continue
+		}
+		for _, variant := range product.Variants {
+			variantID := variant.ID
+			items = append(items, models.InventoryCountItem{
+				InventoryCountID: count.ID,
+				ProductID:        product.ID,
+				VariantID:        &variantID,
+				ExpectedQuantity: stocks[stockKey{ProductID: product.ID, VariantID: variant.ID}],
+				UnitCost:         product.CostPrice,
+			})
+		}
+	}
+	if len(items) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum produto ativo no escopo do balanço"})
+		return
+	}
+	if err := tx.CreateInBatches(&items, 500).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao congelar saldos"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir balanço"})
+		return
+	}
+
+	count.Items = items
+	tenantDB(c).Preload("Store").Preload("User").First(&count, count.ID)
+	c.JSON(http.StatusCreated, count.ToResponse())
+}
+
+// AddInventoryCountEntries registra quantidades contadas. Vários dispositivos podem lançar ao
+// mesmo tempo: no modo "add" (padrão) a quantidade é somada à já contada, no modo "set" a
+// substitui. Produtos com variações são contados pelo código da variação ou pelo variant_id.
+// Produtos do escopo que não estavam no congelamento entram com o saldo atual
+func AddInventoryCountEntries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.InventoryCountEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var count models.InventoryCount
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&count, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Balanço não encontrado"})
+		return
+	}
+	if count.Status != models.InventoryCountOpen {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Balanço não está aberto para contagem"})
+		return
+	}
+
+	now := time.Now()
+	userID := c.GetUint("user_id")
+	variants := make(map[uint]*models.ProductVariant)
+	var touched []uint
+	for _, entry := range req.Entries {
+		// Pelo código de barras a quantidade é multiplicada pela do código (ex.: caixa com 12)
+		var product models.Product
+		multiplier := 1.0
+		variantID := entry.VariantID
+		if entry.ProductID != 0 {
+			err = tx.First(&product, entry.ProductID).Error
+		} else {
+			var match barcodeMatch
+			match, err = lookupBarcode(tx, count.StoreID, entry.Barcode)
+			product, multiplier = match.Product, match.Multiplier
+			if match.Variant != nil {
+				variantID = &match.Variant.ID
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + entryProductLabel(entry)})
+			return
+		}
+
+		variant, errMessage := saleItemVariant(tx, &product, variantID, variants, false)
+		if errMessage != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+			return
+		}
+
+		quantity := models.RoundQuantity(entry.Quantity * multiplier)
+		if err := product.ValidateQuantity(quantity); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item, err := inventoryCountItem(tx, count, product, variant)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		mode := entry.Mode
+		if mode == "" {
+			mode = models.InventoryEntryAdd
+		}
+		counted := quantity
+		if mode == models.InventoryEntryAdd && item.CountedQuantity != nil {
+			counted = models.RoundQuantity(*item.CountedQuantity + quantity)
+		}
+
+		if err := tx.Model(&item).Updates(map[string]interface{}{
+			"counted_quantity": counted,
+			"counted_at":       now,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar contagem"})
+			return
+		}
+
+		if err := tx.Create(&models.InventoryCountEntry{
+			InventoryCountItemID: item.ID,
+			Mode:                 mode,
+			Quantity:             quantity,
+			Device:               req.Device,
+			UserID:               userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar contagem"})
+			return
+		}
+		touched = append(touched, item.ID)
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar contagem"})
+		return
+	}
+
+	var items []models.InventoryCountItem
+	tenantDB(c).Preload("Product").Preload("Variant").Where("id IN ?", touched).Find(&items)
+	responses := make([]models.InventoryCountItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToResponse()
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// ApproveInventoryCount aprova o balanço, lançando como ajuste a diferença entre contado e
+// esperado de cada produto (ou variação). A diferença é aplicada sobre o saldo atual, preservando as
+// vendas e entradas ocorridas desde a abertura
+func ApproveInventoryCount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ApproveInventoryCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var count models.InventoryCount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&count, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Balanço não encontrado"})
+		return
+	}
+	if count.Status != models.InventoryCountOpen {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas balanços abertos podem ser aprovados"})
+		return
+	}
+
+	notes := fmt.Sprintf("Balanço #%d", count.ID)
+	if req.Notes != "" {
+		notes += " - " + req.Notes
+	}
+
+	counted := 0
+	for i := range count.Items {
+		item := &count.Items[i]
+		if item.CountedQuantity == nil {
+			if !req.ZeroUncounted {
+				continue
+			}
+			zero := 0.0
+			item.CountedQuantity = &zero
+			if err := tx.Model(item).Update("counted_quantity", zero).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar item do balanço"})
+				return
+			}
+		}
+		counted++
+
+		variance := item.Variance()
+		if variance == 0 {
+			continue
+		}
+
+		product, err := lockProduct(tx, item.ProductID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
+			return
+		}
+		change := stockChange{
+			Type:          models.StockMovementAdjustment,
+			Quantity:      variance,
+			ReferenceType: "inventory_count",
+			ReferenceID:   &count.ID,
+			UserID:        c.GetUint("user_id"),
+			Notes:         notes,
+			StoreID:       count.StoreID,
+		}
+		if item.VariantID != nil {
+			variant, err := lockVariant(tx, product.ID, *item.VariantID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Variação excluída após a abertura do balanço: " + product.Name})
+				return
+			}
+			change.Variant = &variant
+		} else if product.HasVariants {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto passou a ter variações após a abertura do balanço: " + product.Name})
+			return
+		}
+		if err := applyStockChange(tx, &product, change); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+			return
+		}
+
+		item.AdjustedQuantity = variance
+		if err := tx.Model(item).Update("adjusted_quantity", variance).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar item do balanço"})
+			return
+		}
+	}
+
+	if counted == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum produto contado no balanço"})
+		return
+	}
+
+	now := time.Now()
+	userID := c.GetUint("user_id")
+	if err := tx.Model(&count).Updates(map[string]interface{}{
+		"status":      models.InventoryCountApproved,
+		"approved_by": userID,
+		"approved_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aprovar balanço"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aprovar balanço"})
+		return
+	}
+
+	tenantDB(c).Preload("Store").Preload("User").Preload("Items").First(&count, count.ID)
+	c.JSON(http.StatusOK, count.ToResponse())
+}
+
+// CancelInventoryCount cancela um balanço aberto, sem alterar o estoque
+func CancelInventoryCount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var count models.InventoryCount
+	if err := tenantDB(c).First(&count, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Balanço não encontrado"})
+		return
+	}
+	if count.Status != models.InventoryCountOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas balanços abertos podem ser cancelados"})
+		return
+	}
+
+	if err := tenantDB(c).Model(&count).Updates(map[string]interface{}{
+		"status":       models.InventoryCountCancelled,
+		"cancelled_at": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar balanço"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Balanço cancelado com sucesso"})
+}
+
+// loadInventoryCount busca o balanço da rota com os itens, produtos e variações, ordenados
+// por nome
+func loadInventoryCount(c *gin.Context) (models.InventoryCount, bool) {
+	var count models.InventoryCount
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return count, false
+	}
+
+	if err := tenantDB(c).Preload("Store").Preload("User").Preload("Items.Product").Preload("Items.Variant").First(&count, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Balanço não encontrado"})
+		return count, false
+	}
+
+	sort.Slice(count.Items, func(i, j int) bool {
+		a, b := count.Items[i], count.Items[j]
+		if a.Product.Name != b.Product.Name || a.Variant == nil || b.Variant == nil {
+			return a.Product.Name < b.Product.Name
+		}
+		return a.Variant.Name < b.Variant.Name
+	})
+	return count, true
+}
+
+// filterInventoryItems aplica os filtros do relatório de balanço
+func filterInventoryItems(items []models.InventoryCountItem, filter, search string) []models.InventoryCountItem {
+	search = strings.ToLower(search)
+	var result []models.InventoryCountItem
+	for _, item := range items {
+		switch filter {
+		case "variance":
+			if item.CountedQuantity == nil || item.Variance() == 0 {
+				continue
+			}
+		case "counted":
+			if item.CountedQuantity == nil {
+				continue
+			}
+		case "uncounted":
+			if item.CountedQuantity != nil {
+				continue
+			}
+		}
+		if search != "" && !strings.Contains(strings.ToLower(item.Product.Name), search) &&
+			!strings.Contains(item.Product.Barcode, search) && !inventoryVariantMatches(item.Variant, search) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// inventoryCountItem busca (bloqueando) o item do produto (ou da variação) no balanço.
+// Produtos e variações do escopo que não estavam no congelamento (cadastrados ou reativados
+// depois) entram com o saldo atual da loja
+func inventoryCountItem(tx *gorm.DB, count models.InventoryCount, product models.Product, variant *models.ProductVariant) (models.InventoryCountItem, error) {
+	var item models.InventoryCountItem
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("inventory_count_id = ? AND product_id = ?", count.ID, product.ID)
+	if variant != nil {
+		query = query.Where("variant_id = ?", variant.ID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+	err := query.First(&item).Error
+	if err == nil {
+		return item, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, err
+	}
+
+	if count.CategoryID != nil && product.CategoryID != *count.CategoryID {
+		return item, fmt.Errorf("Produto fora do escopo do balanço: %s", product.Name)
+	}
+
+	key := stockKey{ProductID: product.ID}
+	if variant != nil {
+		key.VariantID = variant.ID
+		product.Variants = []models.ProductVariant{*variant}
+	}
+	stocks, err := inventoryStoreStocks(tx, count.StoreID, []models.Product{product})
+	if err != nil {
+		return item, err
+	}
+
+	item = models.InventoryCountItem{
+		InventoryCountID: count.ID,
+		ProductID:        product.ID,
+		ExpectedQuantity: stocks[key],
+		UnitCost:         product.CostPrice,
+	}
+	if variant != nil {
+		item.VariantID = &variant.ID
+	}
+	if err := tx.Create(&item).Error; err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// inventoryStoreStocks retorna o saldo dos produtos e das variações carregadas (em Variants)
+// na loja do balanço (zero sem registro na loja). Sem loja vale o estoque consolidado
+func inventoryStoreStocks(tx *gorm.DB, storeID *uint, products []models.Product) (map[stockKey]float64, error) {
+	stocks := make(map[stockKey]float64, len(products))
+	var productIDs, variantIDs []uint
+	for _, product := range products {
+		if !product.HasVariants {
+			stocks[stockKey{ProductID: product.ID}] = product.Stock
+			productIDs = append(productIDs, product.ID)
+			continue
+		}
+		for _, variant := range product.Variants {
+			stocks[stockKey{ProductID: product.ID, VariantID: variant.ID}] = variant.Stock
+			variantIDs = append(variantIDs, variant.ID)
+		}
+	}
+	if storeID == nil {
+		return stocks, nil
+	}
+
+	for key := range stocks {
+		stocks[key] = 0
+	}
+	if len(productIDs) > 0 {
+		var storeProducts []models.StoreProduct
+		if err := tx.Where("store_id = ? AND product_id IN ?", *storeID, productIDs).Find(&storeProducts).Error; err != nil {
+			return nil, err
+		}
+		for _, storeProduct := range storeProducts {
+			stocks[stockKey{ProductID: storeProduct.ProductID}] = storeProduct.Stock
+		}
+	}
+	if len(variantIDs) > 0 {
+		var storeVariants []models.StoreVariant
+		if err := tx.Where("store_id = ? AND variant_id IN ?", *storeID, variantIDs).Find(&storeVariants).Error; err != nil {
+			return nil, err
+		}
+		for _, storeVariant := range storeVariants {
+			stocks[stockKey{ProductID: storeVariant.ProductID, VariantID: storeVariant.VariantID}] = storeVariant.Stock
+		}
+	}
+	return stocks, nil
+}
+
+// inventoryVariantMatches verifica se a busca do relatório corresponde ao nome ou ao código
+// da variação do item
+func inventoryVariantMatches(variant *models.ProductVariant, search string) bool {
+	if variant == nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(variant.Name), search) || strings.Contains(variant.BarcodeValue(), search)
+}
+
+func entryProductLabel(entry models.InventoryCountEntryRequest) string {
+	if entry.ProductID != 0 {
+		return strconv.Itoa(int(entry.ProductID))
+	}
+	return entry.Barcode
+}
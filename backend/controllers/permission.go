@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/models"
+)
+
+// GetPermissions retorna o catálogo de permissões e o modelo de permissões de cada perfil
+func GetPermissions(c *gin.Context) {
+	roles := make(map[string][]string)
+	for _, role := range []string{"admin", "manager", "cashier"} {
+		roles[role] = models.RoleTemplate(role)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": models.PermissionCatalog,
+		"roles":       roles,
+	})
+}
+
+// hasPermission verifica se o usuário autenticado possui a permissão
+func hasPermission(c *gin.Context, permission string) bool {
+	user, ok := c.MustGet("user").(models.User)
+	return ok && user.HasPermission(permission)
+}
+
+// requirePermission responde com acesso negado se o usuário autenticado não possuir a
+// permissão, para verificações que dependem dos dados da requisição
+func requirePermission(c *gin.Context, permission string) bool {
+	if hasPermission(c, permission) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "Permissão necessária: " + permission,
+		"permission": permission,
+	})
+	return false
+}
+
+// priceChanged verifica se um preço opcional (nulo usa o padrão) foi alterado
+func priceChanged(current, requested *models.Money) bool {
+	if current == nil || requested == nil {
+		return current != requested
+	}
+	return *current != *requested
+}
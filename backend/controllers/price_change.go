@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/models"
+)
+
+// GetProductPriceHistory retorna o histórico de alterações de preço de venda e de custo de
+// um produto, da mais recente para a mais antiga
+func GetProductPriceHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var changes []models.PriceChange
+	query := tenantDB(c).Preload("User").Where("product_id = ?", product.ID)
+
+	// Filtros opcionais
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("created_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("created_at <= ?", endOfDay)
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico de preços"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// GetScheduledPrices retorna as alterações de preço agendadas de um produto
+func GetScheduledPrices(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var schedules []models.ScheduledPrice
+	query := tenantDB(c).Preload("User").Where("product_id = ?", product.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("effective_at ASC, id ASC").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar preços agendados"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateScheduledPrice agenda uma alteração de preço de venda e/ou de custo do produto, a
+// ser aplicada automaticamente a partir da data de vigência
+func CreateScheduledPrice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ScheduledPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Price == nil && req.CostPrice == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Informe o novo preço de venda ou de custo"})
+		return
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A vigência deve ser uma data futura"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	schedule := models.ScheduledPrice{
+		ProductID:   product.ID,
+		Price:       req.Price,
+		CostPrice:   req.CostPrice,
+		EffectiveAt: req.EffectiveAt,
+		Status:      models.ScheduledPricePending,
+		Notes:       req.Notes,
+		UserID:      c.GetUint("user_id"),
+	}
+
+	if err := tenantDB(c).Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao agendar alteração de preço"})
+		return
+	}
+
+	tenantDB(c).Preload("User").First(&schedule, schedule.ID)
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// CancelScheduledPrice cancela uma alteração de preço agendada ainda não aplicada
+func CancelScheduledPrice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do agendamento inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var schedule models.ScheduledPrice
+	if err := tenantDB(c).Where("product_id = ?", product.ID).First(&schedule, uint(scheduleID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agendamento não encontrado"})
+		return
+	}
+
+	if schedule.Status != models.ScheduledPricePending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas agendamentos pendentes podem ser cancelados"})
+		return
+	}
+
+	// A condição no status evita cancelar um agendamento aplicado enquanto isso
+	result := tenantDB(c).Model(&schedule).Where("status = ?", models.ScheduledPricePending).
+		Update("status", models.ScheduledPriceCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar agendamento"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas agendamentos pendentes podem ser cancelados"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agendamento cancelado com sucesso"})
+}
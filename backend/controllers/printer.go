@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/fiscal"
+	"pdv-backend/models"
+	"pdv-backend/printer"
+	"pdv-backend/validators"
+)
+
+// printTimeout limita a conexão e o envio do cupom para impressoras em rede
+const printTimeout = 10 * time.Second
+
+// GetPrinterProfiles retorna as impressoras configuradas
+func GetPrinterProfiles(c *gin.Context) {
+	var profiles []models.PrinterProfile
+	query := tenantDB(c)
+
+	// Filtro por status ativo
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	if err := query.Order("is_default DESC, name ASC").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar impressoras"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.PrinterProfileResponse, len(profiles))
+	for i, profile := range profiles {
+		responses[i] = profile.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetPrinterProfile retorna uma impressora específica
+func GetPrinterProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var profile models.PrinterProfile
+	if err := tenantDB(c).First(&profile, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impressora não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile.ToResponse())
+}
+
+// CreatePrinterProfile cadastra uma nova impressora
+func CreatePrinterProfile(c *gin.Context) {
+	var req models.PrinterProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Padrões de uma nova impressora
+	profile := models.PrinterProfile{
+		PrintQRCode: true,
+		CutPaper:    true,
+		FeedLines:   4,
+		Active:      true,
+	}
+	fillPrinterProfile(&profile, req)
+
+	if err := printer.ValidateTarget(profile.ConnectionType, profile.Address); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := savePrinterProfile(tenantDB(c), &profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cadastrar impressora"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile.ToResponse())
+}
+
+// UpdatePrinterProfile atualiza uma impressora
+func UpdatePrinterProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.PrinterProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.PrinterProfile
+	if err := tenantDB(c).First(&profile, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impressora não encontrada"})
+		return
+	}
+
+	fillPrinterProfile(&profile, req)
+
+	if err := printer.ValidateTarget(profile.ConnectionType, profile.Address); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := savePrinterProfile(tenantDB(c), &profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar impressora"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile.ToResponse())
+}
+
+// DeletePrinterProfile exclui uma impressora
+func DeletePrinterProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var profile models.PrinterProfile
+	if err := tenantDB(c).First(&profile, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impressora não encontrada"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir impressora"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impressora excluída com sucesso"})
+}
+
+// PrintSale imprime o cupom da venda na impressora informada ou na impressora padrão
+func PrintSale(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	// O corpo é opcional: sem ele, imprime uma via na impressora padrão
+	var req models.PrintSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+
+	var sale models.Sale
+	if err := tenantDB(c).Preload("User").Preload("Customer").Preload("SaleItems.Product.Category").Preload("Payments").Preload("FiscalDocument").First(&sale, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venda não encontrada"})
+		return
+	}
+
+	profile, ok := findActivePrinter(c, req.PrinterProfileID)
+	if !ok {
+		return
+	}
+
+	receipt := printer.RenderSale(sale.ToResponse(), printer.Layout{
+		Columns:   profile.Columns(),
+		Header:    receiptHeader(profile),
+		Footer:    splitLines(profile.Footer),
+		QRCode:    profile.PrintQRCode,
+		Cut:       profile.CutPaper,
+		FeedLines: profile.FeedLines,
+	})
+
+	for i := 0; i < req.Copies; i++ {
+		if err := printer.Send(profile.ConnectionType, profile.Address, receipt, printTimeout); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao imprimir: " + err.Error(), "printed": i})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cupom enviado para impressão",
+		"printer": profile.Name,
+		"copies":  req.Copies,
+		"bytes":   len(receipt),
+	})
+}
+
+// fillPrinterProfile copia os dados da requisição para a impressora
+func fillPrinterProfile(profile *models.PrinterProfile, req models.PrinterProfileRequest) {
+	profile.Name = req.Name
+	profile.PaperWidth = req.PaperWidth
+	profile.ConnectionType = req.ConnectionType
+	profile.Address = strings.TrimSpace(req.Address)
+	profile.Header = req.Header
+	profile.Footer = req.Footer
+	profile.IsDefault = req.IsDefault
+
+	if req.PrintQRCode != nil {
+		profile.PrintQRCode = *req.PrintQRCode
+	}
+	if req.CutPaper != nil {
+		profile.CutPaper = *req.CutPaper
+	}
+	if req.FeedLines != nil {
+		profile.FeedLines = *req.FeedLines
+	}
+	if req.Active != nil {
+		profile.Active = *req.Active
+	}
+}
+
+// savePrinterProfile grava a impressora garantindo que exista apenas uma impressora padrão
+func savePrinterProfile(db *gorm.DB, profile *models.PrinterProfile) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if profile.IsDefault {
+			if err := tx.Model(&models.PrinterProfile{}).
+				Where("is_default = ? AND id != ?", true, profile.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(profile).Error
+	})
+}
+
+// receiptHeader retorna o cabeçalho do cupom: o configurado na impressora ou, se vazio,
+// os dados do emitente da NFC-e
+func receiptHeader(profile models.PrinterProfile) []string {
+	if lines := splitLines(profile.Header); len(lines) > 0 {
+		return lines
+	}
+	if fiscal.Default == nil {
+		return []string{"SISTEMA PDV", "Ponto de Venda"}
+	}
+
+	cfg := fiscal.Default.Config()
+	header := []string{cfg.Name}
+	if cfg.TradeName != "" && cfg.TradeName != cfg.Name {
+		header = append(header, cfg.TradeName)
+	}
+	header = append(header,
+		fmt.Sprintf("CNPJ %s  IE %s", validators.FormatDocument(cfg.CNPJ), cfg.IE),
+		fmt.Sprintf("%s, %s - %s", cfg.Street, cfg.Number, cfg.District),
+		fmt.Sprintf("%s - %s", cfg.City, cfg.UF),
+	)
+	return header
+}
+
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// OpenCashDrawer abre a gaveta do caixa fora de uma venda, pelo conector da impressora.
+// Operadores sem a permissão precisam da autorização de um supervisor
+func OpenCashDrawer(c *gin.Context) {
+	session, ok := findCashSession(c)
+	if !ok {
+		return
+	}
+
+	var req models.OpenCashDrawerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !session.IsOpen() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sessão de caixa não está aberta"})
+		return
+	}
+
+	attempt := models.SupervisorAuthorization{
+		Action:        models.AuthorizationDrawerOpen,
+		Permission:    models.PermissionCashDrawerOpen,
+		CashSessionID: &session.ID,
+	}
+	approver, ok := authorizeAction(c, nil, attempt, "Abertura da gaveta", req.Authorization)
+	if !ok {
+		return
+	}
+
+	profile, ok := findActivePrinter(c, req.PrinterProfileID)
+	if !ok {
+		return
+	}
+
+	pulse := printer.NewBuilder(profile.Columns()).OpenDrawer().Bytes()
+	if err := printer.Send(profile.ConnectionType, profile.Address, pulse, printTimeout); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao abrir a gaveta: " + err.Error()})
+		return
+	}
+
+	authorization := models.SupervisorAuthorization{
+		Action:        models.AuthorizationDrawerOpen,
+		Permission:    models.PermissionCashDrawerOpen,
+		RequestedByID: c.GetUint("user_id"),
+		CashSessionID: &session.ID,
+		Details:       req.Reason,
+	}
+	if err := recordAuthorization(tenantDB(c), approver, authorization); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar autorização"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Gaveta aberta",
+		"printer": profile.Name,
+	})
+}
+
+// findActivePrinter busca a impressora ativa informada ou, se omitida, a impressora padrão
+func findActivePrinter(c *gin.Context, id *uint) (models.PrinterProfile, bool) {
+	var profile models.PrinterProfile
+	query := tenantDB(c).Where("active = ?", true)
+	if id != nil {
+		query = query.Where("id = ?", *id)
+	} else {
+		query = query.Where("is_default = ?", true)
+	}
+	if err := query.First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) && id == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhuma impressora padrão configurada"})
+			return profile, false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impressora não encontrada ou inativa"})
+		return profile, false
+	}
+	return profile, true
+}
@@ -5,31 +5,15 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"pdv-backend/config"
+	"gorm.io/gorm"
 	"pdv-backend/models"
+	"pdv-backend/pricing"
 )
 
 // GetProducts retorna todos os produtos
 func GetProducts(c *gin.Context) {
 	var products []models.Product
-	query := config.DB.Preload("Category")
-
-	// Filtros opcionais
-	if categoryID := c.Query("category_id"); categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
-	}
-
-	if active := c.Query("active"); active != "" {
-		query = query.Where("active = ?", active)
-	}
-
-	if search := c.Query("search"); search != "" {
-		query = query.Where("name LIKE ? OR barcode LIKE ?", "%"+search+"%", "%"+search+"%")
-	}
-
-	if lowStock := c.Query("low_stock"); lowStock == "true" {
-		query = query.Where("stock <= min_stock")
-	}
+	query := filterProducts(tenantDB(c).Preload("Category"), c)
 
 	if err := query.Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos"})
@@ -42,6 +26,12 @@ func GetProducts(c *gin.Context) {
 		responses[i] = product.ToResponse()
 	}
 
+	// Saldo e preço na loja do usuário
+	if err := attachStoreProducts(tenantDB(c), userStore(c), products, responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
 	c.JSON(http.StatusOK, responses)
 }
 
@@ -54,15 +44,26 @@ func GetProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := config.DB.Preload("Category").First(&product, uint(id)).Error; err != nil {
+	if err := tenantDB(c).Preload("Category").Preload("Barcodes").Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).First(&product, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
 		return
 	}
 
-	c.JSON(http.StatusOK, product.ToResponse())
+	responses := []models.ProductResponse{product.ToResponse()}
+	if err := attachStoreProducts(tenantDB(c), userStore(c), []models.Product{product}, responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses[0])
 }
 
-// GetProductByBarcode retorna um produto pelo código de barras
+// GetProductByBarcode retorna um produto pelo código de barras principal, da variação,
+// adicional ou pela etiqueta da balança. Quando o código pertence a uma variação, ela é
+// retornada em "variant"; o campo "multiplier" indica a quantidade representada pelo código
+// (ex.: 12 na caixa ou o peso da etiqueta, detalhada em "scale_label")
 func GetProductByBarcode(c *gin.Context) {
 	barcode := c.Param("barcode")
 	if barcode == "" {
@@ -70,13 +71,26 @@ func GetProductByBarcode(c *gin.Context) {
 		return
 	}
 
-	var product models.Product
-	if err := config.DB.Preload("Category").Where("barcode = ? AND active = ?", barcode, true).First(&product).Error; err != nil {
+	match, err := lookupBarcode(tenantDB(c), userStore(c), barcode)
+	if err != nil || !match.Product.Active || (match.Variant != nil && !match.Variant.Active) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
 		return
 	}
 
-	c.JSON(http.StatusOK, product.ToResponse())
+	responses := []models.ProductResponse{match.Product.ToResponse()}
+	if err := attachStoreProducts(tenantDB(c), userStore(c), []models.Product{match.Product}, responses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
+	response := responses[0]
+	if match.Variant != nil {
+		variantResponse := match.Variant.ToResponse(&match.Product)
+		response.Variant = &variantResponse
+	}
+	response.Multiplier = match.Multiplier
+	response.ScaleLabel = match.ScaleLabel
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateProduct cria um novo produto
@@ -89,18 +103,27 @@ func CreateProduct(c *gin.Context) {
 
 	// Verificar se a categoria existe
 	var category models.Category
-	if err := config.DB.First(&category, *req.CategoryID).Error; err != nil {
+	if err := tenantDB(c).First(&category, *req.CategoryID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Categoria não encontrada"})
 		return
 	}
 
-	// Verificar se o código de barras já existe (se fornecido)
-	if req.Barcode != "" {
-		var existingProduct models.Product
-		if err := config.DB.Where("barcode = ?", req.Barcode).First(&existingProduct).Error; err == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Código de barras já existe"})
-			return
-		}
+	if err := validateBarcode(req.Barcode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verificar se o código de barras já existe em produto ou variação (se fornecido)
+	if req.Barcode != "" && barcodeInUse(tenantDB(c), req.Barcode, 0, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código de barras já existe"})
+		return
+	}
+
+	// Verificar se o código de balança já existe (se fornecido)
+	plu := models.NormalizePLU(req.PLU)
+	if plu != "" && pluInUse(tenantDB(c), plu, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código de balança (PLU) já existe"})
+		return
 	}
 
 	// Criar produto
@@ -111,28 +134,79 @@ func CreateProduct(c *gin.Context) {
 		Price:       *req.Price,
 		CategoryID:  *req.CategoryID,
 		Unit:        req.Unit,
+		PLU:         plu,
+	}
+
+	if product.Unit == "" {
+		product.Unit = "un"
 	}
 
 	if req.CostPrice != nil {
 		product.CostPrice = *req.CostPrice
 	}
-	if req.Stock != nil {
-		product.Stock = *req.Stock
-	}
 	if req.MinStock != nil {
 		product.MinStock = *req.MinStock
 	}
 	if req.Active != nil {
 		product.Active = *req.Active
 	}
+	applyProductFiscalFields(&product, req)
+	applyProductScaleFields(&product, req)
+
+	// Estoque de produtos vendidos por unidade deve ser inteiro
+	if req.Stock != nil {
+		if err := product.ValidateQuantity(*req.Stock); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// O estoque inicial entra na loja do usuário
+	var storeID *uint
+	if req.Stock != nil && *req.Stock != 0 {
+		var ok bool
+		if storeID, ok = stockStore(c); !ok {
+			return
+		}
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(&product).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar produto"})
+		return
+	}
+
+	// Registrar o estoque inicial no histórico de movimentações
+	if req.Stock != nil && *req.Stock != 0 {
+		if err := applyStockChange(tx, &product, stockChange{
+			Type:     models.StockMovementAdjustment,
+			Quantity: *req.Stock,
+			UserID:   c.GetUint("user_id"),
+			Notes:    "Estoque inicial",
+			StoreID:  storeID,
+		}); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar estoque inicial"})
+			return
+		}
+	}
 
-	if err := config.DB.Create(&product).Error; err != nil {
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar produto"})
 		return
 	}
 
 	// Carregar categoria para resposta
-	config.DB.Preload("Category").First(&product, product.ID)
+	tenantDB(c).Preload("Category").First(&product, product.ID)
 
 	c.JSON(http.StatusCreated, product.ToResponse())
 }
@@ -153,55 +227,154 @@ func UpdateProduct(c *gin.Context) {
 
 	// Buscar produto
 	var product models.Product
-	if err := config.DB.First(&product, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
 		return
 	}
 
 	// Verificar se a categoria existe
 	var category models.Category
-	if err := config.DB.First(&category, *req.CategoryID).Error; err != nil {
+	if err := tenantDB(c).First(&category, *req.CategoryID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Categoria não encontrada"})
 		return
 	}
 
-	// Verificar se o código de barras já existe em outro produto
-	if req.Barcode != "" && req.Barcode != product.Barcode {
-		var existingProduct models.Product
-		if err := config.DB.Where("barcode = ? AND id != ?", req.Barcode, product.ID).First(&existingProduct).Error; err == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Código de barras já existe"})
+	// Códigos já cadastrados são mantidos mesmo sem dígito verificador válido
+	if req.Barcode != product.Barcode {
+		if err := validateBarcode(req.Barcode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Verificar se o código de barras já existe em outro produto ou variação
+	if req.Barcode != "" && req.Barcode != product.Barcode && barcodeInUse(tenantDB(c), req.Barcode, product.ID, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código de barras já existe"})
+		return
+	}
+
+	// Verificar se o código de balança já existe em outro produto
+	plu := models.NormalizePLU(req.PLU)
+	if plu != "" && pluInUse(tenantDB(c), plu, product.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código de balança (PLU) já existe"})
+		return
+	}
+
+	// Alterar preços exige permissão própria
+	if *req.Price != product.Price || (req.CostPrice != nil && *req.CostPrice != product.CostPrice) {
+		if !requirePermission(c, models.PermissionProductPriceEdit) {
 			return
 		}
 	}
 
 	// Atualizar campos
+	oldPrice, oldCostPrice := product.Price, product.CostPrice
 	product.Name = req.Name
+	product.PLU = plu
 	product.Barcode = req.Barcode
 	product.Description = req.Description
 	product.Price = *req.Price
 	product.CategoryID = *req.CategoryID
 	product.Unit = req.Unit
+	if product.Unit == "" {
+		product.Unit = "un"
+	}
 
 	if req.CostPrice != nil {
 		product.CostPrice = *req.CostPrice
 	}
-	if req.Stock != nil {
-		product.Stock = *req.Stock
-	}
 	if req.MinStock != nil {
 		product.MinStock = *req.MinStock
 	}
 	if req.Active != nil {
 		product.Active = *req.Active
 	}
+	applyProductFiscalFields(&product, req)
+	applyProductScaleFields(&product, req)
+
+	// Estoque de produtos vendidos por unidade deve ser inteiro
+	if req.Stock != nil {
+		if err := product.ValidateQuantity(*req.Stock); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// O estoque de produtos com variações é a soma do estoque das variações
+	if product.HasVariants && req.Stock != nil && *req.Stock != product.Stock {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque de produto com variações deve ser alterado nas variações"})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
 
-	if err := config.DB.Save(&product).Error; err != nil {
+	if err := tx.Omit("stock").Save(&product).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar produto"})
+		return
+	}
+
+	// Registrar a alteração de preço de venda ou de custo no histórico
+	if err := pricing.Record(tx, &product, oldPrice, oldCostPrice, pricing.Change{
+		Source: models.PriceChangeManual,
+		UserID: c.GetUint("user_id"),
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico de preços"})
+		return
+	}
+
+	// Alteração de estoque pela edição do produto vira um ajuste no histórico. O estoque
+	// informado é o da loja do usuário (ou o consolidado, sem loja)
+	if req.Stock != nil && !product.HasVariants {
+		locked, err := lockProduct(tx, product.ID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
+			return
+		}
+		product.Stock = locked.Stock
+
+		current, err := storeStock(tx, &product, nil, userStore(c))
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+			return
+		}
+		if *req.Stock != current {
+			if userStore(c) == nil && hasStores(tx) {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": errStoreRequired})
+				return
+			}
+			if err := applyStockChange(tx, &product, stockChange{
+				Type:     models.StockMovementAdjustment,
+				Quantity: models.RoundQuantity(*req.Stock - current),
+				UserID:   c.GetUint("user_id"),
+				Notes:    "Ajuste na edição do produto",
+				StoreID:  userStore(c),
+			}); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+				return
+			}
+		}
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar produto"})
 		return
 	}
 
 	// Carregar categoria para resposta
-	config.DB.Preload("Category").First(&product, product.ID)
+	tenantDB(c).Preload("Category").First(&product, product.ID)
 
 	c.JSON(http.StatusOK, product.ToResponse())
 }
@@ -215,20 +388,21 @@ func DeleteProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := config.DB.First(&product, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
 		return
 	}
 
 	// Verificar se o produto tem vendas associadas
 	var saleItemCount int64
-	config.DB.Model(&models.SaleItem{}).Where("product_id = ?", product.ID).Count(&saleItemCount)
+	tenantDB(c).Model(&models.SaleItem{}).Where("product_id = ?", product.ID).Count(&saleItemCount)
 	if saleItemCount > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir produto com vendas associadas"})
 		return
 	}
 
-	if err := config.DB.Delete(&product).Error; err != nil {
+	// Variações, códigos de barras adicionais, lotes e saldos das lojas são excluídos junto com o produto
+	if err := tenantDB(c).Select("Variants", "Barcodes", "Batches", "Stores", "StoreVariants").Delete(&product).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir produto"})
 		return
 	}
@@ -245,8 +419,12 @@ func UpdateStock(c *gin.Context) {
 	}
 
 	type UpdateStockRequest struct {
-		Quantity int    `json:"quantity" binding:"required"`
-		Type     string `json:"type" binding:"required,oneof=add subtract set"`
+		Quantity  float64 `json:"quantity" binding:"required,gte=0"`
+		Type      string  `json:"type" binding:"required,oneof=add subtract set"`
+		Notes     string  `json:"notes" binding:"max=500"`
+		VariantID *uint   `json:"variant_id"` // obrigatório para produtos com variações
+		BatchID   *uint   `json:"batch_id"`   // altera o saldo do lote (ex.: baixa de lote vencido)
+		StoreID   *uint   `json:"store_id"`   // loja movimentada; padrão: a loja do usuário
 	}
 
 	var req UpdateStockRequest
@@ -255,32 +433,173 @@ func UpdateStock(c *gin.Context) {
 		return
 	}
 
-	var product models.Product
-	if err := config.DB.First(&product, uint(id)).Error; err != nil {
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	product, err := lockProduct(tx, uint(id))
+	if err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
 		return
 	}
 
-	// Atualizar estoque baseado no tipo
+	if err := product.ValidateQuantity(req.Quantity); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sem lote informado, o saldo considerado é o da loja (da variação, se houver)
+	change := stockChange{UserID: c.GetUint("user_id"), Notes: req.Notes, StoreID: userStore(c)}
+	if req.StoreID != nil {
+		var store models.Store
+		if err := tx.First(&store, *req.StoreID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+			return
+		}
+		change.StoreID = &store.ID
+	}
+
+	// Em produtos com variações o saldo alterado é o da variação
+	if product.HasVariants {
+		if req.VariantID == nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrVariantRequired.Error()})
+			return
+		}
+		variant, err := lockVariant(tx, product.ID, *req.VariantID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Variação não encontrada"})
+			return
+		}
+		change.Variant = &variant
+	}
+
+	// Com lote informado o saldo considerado é o do lote
+	if req.BatchID != nil {
+		batch, err := lockBatch(tx, *req.BatchID)
+		if err != nil || batch.ProductID != product.ID {
+			tx.Rollback()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lote não encontrado"})
+			return
+		}
+		if change.Variant != nil && (batch.VariantID == nil || *batch.VariantID != change.Variant.ID) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Lote não pertence à variação informada"})
+			return
+		}
+		// Sem loja informada, o lote é movimentado na própria loja
+		if req.StoreID == nil && batch.StoreID != nil {
+			change.StoreID = batch.StoreID
+		}
+		if !batch.InStore(change.StoreID) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrBatchStore.Error()})
+			return
+		}
+		change.Batch = &batch
+	}
+
+	if change.StoreID == nil && hasStores(tx) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": errStoreRequired})
+		return
+	}
+
+	var current float64
+	if change.Batch != nil {
+		current = change.Batch.Quantity
+	} else if current, err = storeStock(tx, &product, change.Variant, change.StoreID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
+	// Calcular a variação do estoque baseada no tipo
 	switch req.Type {
 	case "add":
-		product.Stock += req.Quantity
+		change.Type = models.StockMovementManualAdd
+		change.Quantity = req.Quantity
 	case "subtract":
-		product.Stock -= req.Quantity
-		if product.Stock < 0 {
-			product.Stock = 0
+		change.Type = models.StockMovementManualSubtract
+		change.Quantity = -req.Quantity
+		if current+change.Quantity < 0 {
+			change.Quantity = -current
 		}
 	case "set":
-		product.Stock = req.Quantity
+		change.Type = models.StockMovementManualSet
+		change.Quantity = req.Quantity - current
 	}
 
-	if err := config.DB.Save(&product).Error; err != nil {
+	if err := applyStockChange(tx, &product, change); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
 		return
 	}
 
-	// Carregar categoria para resposta
-	config.DB.Preload("Category").First(&product, product.ID)
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+		return
+	}
+
+	// Carregar categoria e variações para resposta
+	tenantDB(c).Preload("Category").Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).First(&product, product.ID)
 
 	c.JSON(http.StatusOK, product.ToResponse())
-}
\ No newline at end of file
+}
+
+// filterProducts aplica os filtros opcionais da listagem de produtos (também usados na exportação)
+func filterProducts(query *gorm.DB, c *gin.Context) *gorm.DB {
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name LIKE ? OR barcode LIKE ? OR id IN (?) OR id IN (?)", "%"+search+"%", "%"+search+"%",
+			tenantDB(c).Model(&models.ProductVariant{}).Select("product_id").Where("barcode LIKE ?", "%"+search+"%"),
+			tenantDB(c).Model(&models.ProductBarcode{}).Select("product_id").Where("barcode LIKE ?", "%"+search+"%"))
+	}
+
+	if lowStock := c.Query("low_stock"); lowStock == "true" {
+		query = query.Where("stock <= min_stock")
+	}
+	return query
+}
+
+// applyProductFiscalFields copia os dados fiscais da requisição para o produto
+func applyProductFiscalFields(product *models.Product, req models.ProductRequest) {
+	product.NCM = req.NCM
+	product.CEST = req.CEST
+	product.CFOP = req.CFOP
+	product.CST = req.CST
+	if req.Origin != nil {
+		product.Origin = *req.Origin
+	}
+	if req.ICMSRate != nil {
+		product.ICMSRate = *req.ICMSRate
+	}
+}
+
+// applyProductScaleFields copia os dados da etiqueta da balança da requisição para o produto
+func applyProductScaleFields(product *models.Product, req models.ProductRequest) {
+	if req.ShelfLifeDays != nil {
+		product.ShelfLifeDays = *req.ShelfLifeDays
+	}
+	if req.Tare != nil {
+		product.Tare = *req.Tare
+	}
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// barcodeMatch é o resultado da leitura de um código de barras: o produto, a variação (se o
+// código pertencer a uma) e a quantidade que o código representa
+type barcodeMatch struct {
+	Product    models.Product
+	Variant    *models.ProductVariant
+	Multiplier float64
+	ScaleLabel *models.ScaleLabel // etiqueta de balança, com o peso ou preço embutido
+}
+
+// GetProductBarcodes retorna os códigos de barras adicionais de um produto
+func GetProductBarcodes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var barcodes []models.ProductBarcode
+	if err := tenantDB(c).Where("product_id = ?", product.ID).Order("id ASC").Find(&barcodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar códigos de barras"})
+		return
+	}
+
+	responses := make([]models.ProductBarcodeResponse, len(barcodes))
+	for i := range barcodes {
+		responses[i] = barcodes[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateProductBarcode cadastra um código de barras adicional para o produto
+func CreateProductBarcode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ProductBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	barcode := models.ProductBarcode{
+		ProductID:   product.ID,
+		Barcode:     strings.TrimSpace(req.Barcode),
+		Multiplier:  1,
+		Description: req.Description,
+	}
+	if req.Multiplier != nil {
+		barcode.Multiplier = models.RoundQuantity(*req.Multiplier)
+	}
+
+	if err := validateBarcode(barcode.Barcode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if barcodeInUse(tenantDB(c), barcode.Barcode, 0, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Código de barras já existe"})
+		return
+	}
+
+	// A quantidade do código segue as regras da unidade do produto
+	if err := product.ValidateQuantity(barcode.Multiplier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Em produtos com variações o código identifica também a variação
+	switch {
+	case product.HasVariants && req.VariantID == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrVariantRequired.Error()})
+		return
+	case !product.HasVariants && req.VariantID != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Produto sem variações: " + product.Name})
+		return
+	case req.VariantID != nil:
+		var variant models.ProductVariant
+		if err := tenantDB(c).Where("product_id = ?", product.ID).First(&variant, *req.VariantID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Variação não encontrada"})
+			return
+		}
+		barcode.VariantID = &variant.ID
+	}
+
+	if err := tenantDB(c).Create(&barcode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cadastrar código de barras"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, barcode.ToResponse())
+}
+
+// DeleteProductBarcode exclui um código de barras adicional do produto
+func DeleteProductBarcode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	barcodeID, err := strconv.ParseUint(c.Param("barcode_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do código de barras inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var barcode models.ProductBarcode
+	if err := tenantDB(c).Where("product_id = ?", product.ID).First(&barcode, uint(barcodeID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Código de barras não encontrado"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&barcode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir código de barras"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Código de barras excluído com sucesso"})
+}
+
+// validateBarcode verifica o dígito verificador de códigos com formato de GTIN (EAN-8, UPC-A,
+// EAN-13 e DUN-14). Códigos internos, com outro tamanho ou com letras, são aceitos
+func validateBarcode(code string) error {
+	gtinType := validators.GTINType(code)
+	if gtinType != "" && !validators.ValidateGTIN(code) {
+		return errors.New("Código de barras inválido: dígito verificador do " + gtinType + " não confere")
+	}
+	return nil
+}
+
+// barcodeInUse verifica se o código de barras já pertence a outro produto ou variação, ou se
+// está cadastrado como código adicional, desconsiderando o produto e a variação informados
+// (zero para nenhum)
+func barcodeInUse(db *gorm.DB, barcode string, productID, variantID uint) bool {
+	var count int64
+	db.Model(&models.Product{}).Where("barcode = ? AND id != ?", barcode, productID).Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.ProductVariant{}).Where("barcode = ? AND id != ?", barcode, variantID).Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.ProductBarcode{}).Where("barcode = ?", barcode).Count(&count)
+	return count > 0
+}
+
+// lookupBarcode busca o produto pelo código de barras principal, pelo código da variação,
+// pelos códigos adicionais ou, por último, como etiqueta de balança. Não filtra produtos ou
+// variações inativos. A quantidade das etiquetas de preço usa o preço da loja informada
+func lookupBarcode(db *gorm.DB, storeID *uint, code string) (barcodeMatch, error) {
+	match := barcodeMatch{Multiplier: 1}
+	if code == "" {
+		return match, gorm.ErrRecordNotFound
+	}
+
+	err := db.Preload("Category").Where("barcode = ?", code).First(&match.Product).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return match, err
+	}
+
+	var variant models.ProductVariant
+	err = db.Where("barcode = ?", code).First(&variant).Error
+	if err == nil {
+		match.Variant = &variant
+		return match, db.Preload("Category").First(&match.Product, variant.ProductID).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return match, err
+	}
+
+	var barcode models.ProductBarcode
+	err = db.Preload("Variant").Where("barcode = ?", code).First(&barcode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		label, product, err := parseScaleLabel(db, code)
+		if err != nil {
+			return match, err
+		}
+		priced := product
+		if err := applyStorePrice(db, storeID, &priced); err != nil {
+			return match, err
+		}
+		match.Product = product
+		match.ScaleLabel = &label
+		match.Multiplier = label.Quantity(&priced)
+		return match, nil
+	}
+	if err != nil {
+		return match, err
+	}
+	match.Variant = barcode.Variant
+	match.Multiplier = barcode.Multiplier
+	return match, db.Preload("Category").First(&match.Product, barcode.ProductID).Error
+}
+
+// resolveSaleItemBarcodes completa os itens informados apenas pelo código de barras com o
+// produto, a variação e a quantidade representada pelo código (ex.: caixa com 12 ou o peso
+// da etiqueta da balança). Nas etiquetas de preço o valor impresso vira o total do item
+func resolveSaleItemBarcodes(db *gorm.DB, storeID *uint, items []models.SaleItemRequest) error {
+	for i := range items {
+		if items[i].ProductID != 0 {
+			continue
+		}
+		if items[i].Barcode == "" {
+			return errors.New("Informe o produto ou o código de barras do item")
+		}
+		match, err := lookupBarcode(db, storeID, items[i].Barcode)
+		if err != nil {
+			return errors.New("Produto não encontrado: " + items[i].Barcode)
+		}
+		items[i].ProductID = match.Product.ID
+		if match.Variant != nil {
+			items[i].VariantID = &match.Variant.ID
+		}
+		if label := match.ScaleLabel; label != nil && label.ValueType != models.ScaleValueWeight {
+			labelTotal := label.Price.Mul(items[i].Quantity)
+			items[i].LabelTotal = &labelTotal
+		}
+		items[i].Quantity = models.RoundQuantity(items[i].Quantity * match.Multiplier)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+)
+
+// GetProductBatches retorna os lotes de um produto, do vencimento mais próximo ao mais
+// distante, de todas as lojas ou da loja informada em store_id
+func GetProductBatches(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	storeID, ok := storeParam(c)
+	if !ok {
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var batches []models.ProductBatch
+	query := storeBatches(tenantDB(c).Preload("Variant").Preload("Store"), storeID).Where("product_id = ?", product.ID)
+
+	// Filtros opcionais
+	if variantID := c.Query("variant_id"); variantID != "" {
+		query = query.Where("variant_id = ?", variantID)
+	}
+	if c.Query("available") == "true" {
+		query = query.Where("quantity > 0")
+	}
+
+	if err := query.Order("expiry_date ASC, id ASC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lotes"})
+		return
+	}
+
+	responses := make([]models.ProductBatchResponse, len(batches))
+	for i := range batches {
+		batches[i].Product = product
+		responses[i] = batches[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateProductBatch cadastra um lote do produto. A quantidade informada é registrada como
+// entrada de estoque ou, com from_stock, atribuída ao estoque já existente sem lote
+func CreateProductBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ProductBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	product, err := lockProduct(tx, uint(id))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	// Em produtos com variações o lote pertence a uma variação
+	var variant *models.ProductVariant
+	switch {
+	case product.HasVariants && req.VariantID == nil:
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrVariantRequired.Error()})
+		return
+	case !product.HasVariants && req.VariantID != nil:
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Produto sem variações: " + product.Name})
+		return
+	case req.VariantID != nil:
+		locked, err := lockVariant(tx, product.ID, *req.VariantID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Variação não encontrada"})
+			return
+		}
+		variant = &locked
+	}
+
+	// O lote entra no saldo da loja informada ou da loja do usuário
+	storeID := userStore(c)
+	if req.StoreID != nil {
+		var store models.Store
+		if err := tx.First(&store, *req.StoreID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+			return
+		}
+		storeID = &store.ID
+	}
+	if req.Quantity != nil && storeID == nil && hasStores(tx) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": errStoreRequired})
+		return
+	}
+
+	batch := models.ProductBatch{ProductID: product.ID, VariantID: req.VariantID, StoreID: storeID}
+	if errMessage := fillProductBatch(tx, &batch, req); errMessage != "" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+		return
+	}
+
+	if req.Quantity != nil {
+		if err := product.ValidateQuantity(*req.Quantity); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// O estoque sem lote é o saldo que ainda não foi atribuído a nenhum lote
+	if req.Quantity != nil && req.FromStock {
+		current := product.Stock
+		batched := tx.Model(&models.ProductBatch{}).Where("product_id = ?", product.ID)
+		if variant != nil {
+			current = variant.Stock
+			batched = batched.Where("variant_id = ?", variant.ID)
+		}
+		var total float64
+		if err := batched.Select("COALESCE(SUM(quantity), 0)").Scan(&total).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estoque sem lote"})
+			return
+		}
+		if unbatched := models.RoundQuantity(current - total); unbatched < *req.Quantity {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque sem lote insuficiente: disponível " + strconv.FormatFloat(unbatched, 'f', -1, 64)})
+			return
+		}
+
+		// Na loja, o lote também não passa do estoque sem lote da própria loja
+		if storeID != nil {
+			inStore, err := storeStock(tx, &product, variant, storeID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+				return
+			}
+			storeBatched := tx.Model(&models.ProductBatch{}).Where("product_id = ? AND store_id = ?", product.ID, *storeID)
+			if variant != nil {
+				storeBatched = storeBatched.Where("variant_id = ?", variant.ID)
+			}
+			var storeTotal float64
+			if err := storeBatched.Select("COALESCE(SUM(quantity), 0)").Scan(&storeTotal).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estoque sem lote"})
+				return
+			}
+			if unbatched := models.RoundQuantity(inStore - storeTotal); unbatched < *req.Quantity {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque sem lote insuficiente na loja: disponível " + strconv.FormatFloat(unbatched, 'f', -1, 64)})
+				return
+			}
+		}
+		batch.Quantity = models.RoundQuantity(*req.Quantity)
+	}
+
+	if err := tx.Create(&batch).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cadastrar lote"})
+		return
+	}
+
+	// Registrar a entrada do lote no histórico de movimentações
+	if req.Quantity != nil && !req.FromStock {
+		if err := applyStockChange(tx, &product, stockChange{
+			Type:     models.StockMovementManualAdd,
+			Quantity: *req.Quantity,
+			UserID:   c.GetUint("user_id"),
+			Notes:    "Entrada do lote " + batch.Code,
+			Variant:  variant,
+			Batch:    &batch,
+			StoreID:  storeID,
+		}); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar entrada do lote"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cadastrar lote"})
+		return
+	}
+
+	batch.Product = product
+	batch.Variant = variant
+	tenantDB(c).Preload("Store").First(&batch, batch.ID)
+	c.JSON(http.StatusCreated, batch.ToResponse())
+}
+
+// UpdateProductBatch atualiza o número e a validade do lote. O saldo e a loja são alterados
+// apenas por movimentações (PUT /products/:id/stock com batch_id e transferências)
+func UpdateProductBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	batchID, err := strconv.ParseUint(c.Param("batch_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do lote inválido"})
+		return
+	}
+
+	var req models.ProductBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var batch models.ProductBatch
+	if err := tenantDB(c).Preload("Variant").Preload("Store").Where("product_id = ?", product.ID).First(&batch, uint(batchID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote não encontrado"})
+		return
+	}
+	batch.Product = product
+
+	if errMessage := fillProductBatch(tenantDB(c), &batch, req); errMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+		return
+	}
+
+	if err := tenantDB(c).Model(&batch).Updates(map[string]interface{}{
+		"code":        batch.Code,
+		"expiry_date": batch.ExpiryDate,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar lote"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch.ToResponse())
+}
+
+// fillProductBatch copia o número e a validade da requisição para o lote, verificando se o
+// número já existe no produto (ou na variação) na loja do lote. Retorna a mensagem de erro
+// de validação, se houver
+func fillProductBatch(db *gorm.DB, batch *models.ProductBatch, req models.ProductBatchRequest) string {
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return "Informe o número do lote"
+	}
+
+	expiryDate, err := time.ParseInLocation("2006-01-02", req.ExpiryDate, time.Local)
+	if err != nil {
+		return "Validade inválida (use AAAA-MM-DD)"
+	}
+
+	var duplicated int64
+	query := db.Model(&models.ProductBatch{}).Where("product_id = ? AND code = ? AND id != ?", batch.ProductID, code, batch.ID)
+	if batch.VariantID != nil {
+		query = query.Where("variant_id = ?", *batch.VariantID)
+	}
+	if batch.StoreID != nil {
+		query = query.Where("store_id = ?", *batch.StoreID)
+	} else {
+		query = query.Where("store_id IS NULL")
+	}
+	query.Count(&duplicated)
+	if duplicated > 0 {
+		return "Lote " + code + " já cadastrado para este produto"
+	}
+
+	batch.Code = code
+	batch.ExpiryDate = expiryDate
+	return ""
+}
+
+// storeBatches restringe a consulta de lotes à loja informada, incluindo os lotes sem loja
+func storeBatches(query *gorm.DB, storeID *uint) *gorm.DB {
+	if storeID == nil {
+		return query
+	}
+	return query.Where("product_batches.store_id = ? OR product_batches.store_id IS NULL", *storeID)
+}
@@ -0,0 +1,737 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+	"pdv-backend/pricing"
+	"pdv-backend/spreadsheet"
+)
+
+// maxProductImportSize limita o tamanho da planilha aceita na importação
+const maxProductImportSize = 20 << 20
+
+// productImportColumns lista os campos da planilha de produtos, na ordem da exportação,
+// com os nomes de coluna reconhecidos automaticamente na importação
+var productImportColumns = []struct {
+	Field      string
+	Aliases    []string
+	ImportOnly bool
+}{
+	{Field: "barcode", Aliases: []string{"codigo_de_barras", "codigo_barras", "ean", "gtin"}},
+	{Field: "name", Aliases: []string{"nome", "produto"}},
+	{Field: "description", Aliases: []string{"descricao"}},
+	{Field: "category", Aliases: []string{"categoria"}},
+	{Field: "category_id", Aliases: []string{"id_categoria"}, ImportOnly: true},
+	{Field: "price", Aliases: []string{"preco", "preco_venda", "preco_de_venda"}},
+	{Field: "cost_price", Aliases: []string{"custo", "preco_custo", "preco_de_custo"}},
+	{Field: "stock", Aliases: []string{"estoque"}},
+	{Field: "min_stock", Aliases: []string{"estoque_minimo"}},
+	{Field: "unit", Aliases: []string{"unidade"}},
+	{Field: "plu", Aliases: []string{"codigo_balanca", "codigo_da_balanca"}},
+	{Field: "shelf_life_days", Aliases: []string{"validade", "dias_validade", "validade_dias"}},
+	{Field: "tare", Aliases: []string{"tara"}},
+	{Field: "active", Aliases: []string{"ativo"}},
+	{Field: "ncm"},
+	{Field: "cest"},
+	{Field: "cfop"},
+	{Field: "cst", Aliases: []string{"csosn"}},
+	{Field: "origin", Aliases: []string{"origem"}},
+	{Field: "icms_rate", Aliases: []string{"aliquota_icms", "icms"}},
+}
+
+// productImportPlan é uma linha válida da planilha, pronta para ser gravada
+type productImportPlan struct {
+	Product models.Product
+	Exists  bool
+	Stock   *float64 // saldo desejado, se informado
+
+	// Preços anteriores de produtos existentes, para o histórico de preços
+	OldPrice     models.Money
+	OldCostPrice models.Money
+}
+
+// ImportProducts importa produtos de uma planilha CSV ou XLSX (campo "file" em multipart).
+// Produtos são identificados pelo código de barras: existentes são atualizados apenas nas
+// colunas preenchidas e os demais são cadastrados. Com dry_run=true apenas valida e retorna
+// o relatório por linha; havendo qualquer erro, nada é gravado
+func ImportProducts(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo da planilha é obrigatório (campo file)"})
+		return
+	}
+	if header.Size > maxProductImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Planilha muito grande"})
+		return
+	}
+
+	format := c.DefaultPostForm("format", c.Query("format"))
+	if format == "" {
+		if format, err = spreadsheet.DetectFormat(header.Filename); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao ler planilha"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao ler planilha"})
+		return
+	}
+
+	rows, err := spreadsheet.Read(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Mapeamento opcional de colunas: {"campo": "Coluna na planilha"}
+	var mapping map[string]string
+	if raw := c.DefaultPostForm("mapping", c.Query("mapping")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mapeamento de colunas inválido"})
+			return
+		}
+	}
+
+	report, plans, err := planProductImport(tenantDB(c), rows, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report.DryRun = c.DefaultPostForm("dry_run", c.Query("dry_run")) == "true"
+
+	if len(report.Errors) > 0 {
+		status := http.StatusBadRequest
+		if report.DryRun {
+			status = http.StatusOK
+		}
+		c.JSON(status, report)
+		return
+	}
+	if report.DryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	userID, storeID := c.GetUint("user_id"), userStore(c)
+	for i := range plans {
+		plan := &plans[i]
+		if err := saveImportedProduct(tx, plan, userID, storeID); err != nil {
+			tx.Rollback()
+			if errors.Is(err, errImportStoreRequired) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Linha %d: %s", report.Rows[i].Row, errStoreRequired)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Erro ao gravar produto da linha %d", report.Rows[i].Row)})
+			return
+		}
+		report.Rows[i].ProductID = plan.Product.ID
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar importação"})
+		return
+	}
+
+	report.Applied = true
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportProducts exporta os produtos (com os mesmos filtros da listagem) em CSV ou XLSX,
+// no layout aceito pela importação
+func ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": spreadsheet.ErrUnsupportedFormat.Error()})
+		return
+	}
+
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="produtos.%s"`, format))
+	c.Status(http.StatusOK)
+
+	writer, err := spreadsheet.NewWriter(c.Writer, format)
+	if err != nil {
+		log.Printf("Erro ao exportar produtos: %v", err)
+		return
+	}
+
+	var header []interface{}
+	for _, column := range productImportColumns {
+		if !column.ImportOnly {
+			header = append(header, column.Field)
+		}
+	}
+	if err := writer.WriteRow(header...); err != nil {
+		log.Printf("Erro ao exportar produtos: %v", err)
+		return
+	}
+
+	// Gravar em lotes para não carregar todo o catálogo em memória
+	var batch []models.Product
+	err = filterProducts(tenantDB(c).Preload("Category"), c).Order("id ASC").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, product := range batch {
+			if err := writer.WriteRow(
+				product.Barcode, product.Name, product.Description, product.Category.Name,
+				product.Price, product.CostPrice, product.Stock, product.MinStock, product.Unit,
+				product.PLU, product.ShelfLifeDays, product.Tare, product.Active, product.NCM, product.CEST,
+				product.CFOP, product.CST, product.Origin, product.ICMSRate,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("Erro ao exportar produtos: %v", err)
+	}
+}
+
+// planProductImport valida as linhas da planilha e monta os produtos a gravar
+func planProductImport(db *gorm.DB, rows [][]string, mapping map[string]string) (models.ProductImportResponse, []productImportPlan, error) {
+	report := models.ProductImportResponse{
+		Rows:   []models.ProductImportRow{},
+		Errors: []models.ProductImportError{},
+	}
+	if len(rows) == 0 {
+		return report, nil, errors.New("Planilha vazia")
+	}
+
+	columns, names, err := productImportColumnIndex(rows[0], mapping)
+	if err != nil {
+		return report, nil, err
+	}
+	report.Columns = names
+
+	// Categorias por nome e por ID
+	var categories []models.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return report, nil, errors.New("Erro ao buscar categorias")
+	}
+	categoriesByName := make(map[string]uint, len(categories))
+	categoriesByID := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		categoriesByName[strings.ToLower(strings.TrimSpace(category.Name))] = category.ID
+		categoriesByID[category.ID] = true
+	}
+
+	// Produtos já cadastrados com os códigos de barras da planilha
+	var barcodes []string
+	for _, row := range rows[1:] {
+		if barcode := importCell(row, columns, "barcode"); barcode != "" {
+			barcodes = append(barcodes, barcode)
+		}
+	}
+	existing := make(map[string]models.Product, len(barcodes))
+	for start := 0; start < len(barcodes); start += 500 {
+		end := start + 500
+		if end > len(barcodes) {
+			end = len(barcodes)
+		}
+		var products []models.Product
+		if err := db.Where("barcode IN ?", barcodes[start:end]).Find(&products).Error; err != nil {
+			return report, nil, errors.New("Erro ao buscar produtos")
+		}
+		for _, product := range products {
+			existing[product.Barcode] = product
+		}
+	}
+
+	var plans []productImportPlan
+	seen := make(map[string]int)
+	seenPLU := make(map[string]int)
+	for i, row := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(row) {
+			continue
+		}
+		report.TotalRows++
+
+		errorCount := len(report.Errors)
+		addError := func(field, message string) {
+			report.Errors = append(report.Errors, models.ProductImportError{Row: rowNumber, Field: field, Error: message})
+		}
+
+		barcode := importCell(row, columns, "barcode")
+		if barcode == "" {
+			addError("barcode", "Código de barras é obrigatório")
+			continue
+		}
+		if previous, duplicated := seen[barcode]; duplicated {
+			addError("barcode", fmt.Sprintf("Código de barras duplicado na planilha (linha %d)", previous))
+			continue
+		}
+		seen[barcode] = rowNumber
+
+		product, exists := existing[barcode]
+		if !exists {
+			if err := validateBarcode(barcode); err != nil {
+				addError("barcode", err.Error())
+				continue
+			}
+		}
+		if !exists && barcodeInUse(db, barcode, 0, 0) {
+			addError("barcode", "Código de barras pertence a uma variação ou é código adicional de outro produto")
+			continue
+		}
+		req := productImportRequest(product, exists)
+		req.Barcode = barcode
+
+		var stock *float64
+		for _, column := range productImportColumns {
+			field := column.Field
+			value := importCell(row, columns, field)
+			if value == "" {
+				continue
+			}
+			if err := setProductImportField(&req, field, value, categoriesByName, categoriesByID); err != nil {
+				addError(field, err.Error())
+				continue
+			}
+			if field == "stock" {
+				stock = req.Stock
+			}
+		}
+
+		if len(report.Errors) == errorCount {
+			if err := binding.Validator.ValidateStruct(&req); err != nil {
+				for _, fieldError := range productImportValidationErrors(err) {
+					addError(fieldError.Field, fieldError.Error)
+				}
+			}
+		}
+		if len(report.Errors) > errorCount {
+			continue
+		}
+
+		if !exists {
+			product = models.Product{Active: true}
+		}
+		oldPrice, oldCostPrice := product.Price, product.CostPrice
+		product.Name = req.Name
+		product.Barcode = req.Barcode
+		product.Description = req.Description
+		product.Price = *req.Price
+		product.CategoryID = *req.CategoryID
+		product.Unit = req.Unit
+		if product.Unit == "" {
+			product.Unit = "un"
+		}
+		product.PLU = models.NormalizePLU(req.PLU)
+		if product.PLU != "" {
+			if previous, duplicated := seenPLU[product.PLU]; duplicated {
+				addError("plu", fmt.Sprintf("Código de balança duplicado na planilha (linha %d)", previous))
+				continue
+			}
+			seenPLU[product.PLU] = rowNumber
+			if pluInUse(db, product.PLU, product.ID) {
+				addError("plu", "Código de balança (PLU) já existe")
+				continue
+			}
+		}
+		if req.CostPrice != nil {
+			product.CostPrice = *req.CostPrice
+		}
+		if req.MinStock != nil {
+			product.MinStock = *req.MinStock
+		}
+		if req.Active != nil {
+			product.Active = *req.Active
+		}
+		applyProductFiscalFields(&product, req)
+		applyProductScaleFields(&product, req)
+
+		// Estoque de produtos com variações é a soma do estoque das variações
+		if stock != nil && product.HasVariants && *stock != product.Stock {
+			addError("stock", "Estoque de produto com variações deve ser alterado nas variações")
+			continue
+		}
+
+		// Estoque de produtos vendidos por unidade deve ser inteiro
+		if stock != nil {
+			if err := product.ValidateQuantity(*stock); err != nil {
+				addError("stock", err.Error())
+				continue
+			}
+		}
+
+		action := models.ProductImportCreate
+		if exists {
+			action = models.ProductImportUpdate
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		report.Rows = append(report.Rows, models.ProductImportRow{
+			Row:       rowNumber,
+			Action:    action,
+			ProductID: product.ID,
+			Barcode:   product.Barcode,
+			Name:      product.Name,
+		})
+		plans = append(plans, productImportPlan{
+			Product:      product,
+			Exists:       exists,
+			Stock:        stock,
+			OldPrice:     oldPrice,
+			OldCostPrice: oldCostPrice,
+		})
+	}
+
+	if len(report.Errors) > 0 {
+		report.Error = "A planilha contém erros; nenhum produto foi importado"
+	}
+	return report, plans, nil
+}
+
+// errImportStoreRequired indica alteração de estoque pela planilha sem loja em organização com lojas
+var errImportStoreRequired = errors.New(errStoreRequired)
+
+// saveImportedProduct grava o produto da planilha, registrando a alteração de estoque como ajuste
+// na loja informada
+func saveImportedProduct(tx *gorm.DB, plan *productImportPlan, userID uint, storeID *uint) error {
+	product := &plan.Product
+	notes := "Ajuste por importação de produtos"
+	if plan.Exists {
+		if err := tx.Omit("stock").Save(product).Error; err != nil {
+			return err
+		}
+		if err := pricing.Record(tx, product, plan.OldPrice, plan.OldCostPrice, pricing.Change{
+			Source: models.PriceChangeImport,
+			UserID: userID,
+		}); err != nil {
+			return err
+		}
+	} else {
+		active := product.Active
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		// O default do banco ignora o valor falso na criação
+		if !active {
+			if err := tx.Model(product).Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		notes = "Estoque inicial (importação de produtos)"
+	}
+
+	if plan.Stock == nil || product.HasVariants {
+		return nil
+	}
+	if plan.Exists {
+		locked, err := lockProduct(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		product.Stock = locked.Stock
+	}
+
+	// O estoque da planilha é o da loja informada (ou o consolidado, sem loja)
+	current, err := storeStock(tx, product, nil, storeID)
+	if err != nil {
+		return err
+	}
+	if *plan.Stock == current {
+		return nil
+	}
+	if storeID == nil && hasStores(tx) {
+		return errImportStoreRequired
+	}
+	return applyStockChange(tx, product, stockChange{
+		Type:     models.StockMovementAdjustment,
+		Quantity: models.RoundQuantity(*plan.Stock - current),
+		UserID:   userID,
+		Notes:    notes,
+		StoreID:  storeID,
+	})
+}
+
+// productImportColumnIndex localiza a coluna de cada campo pelo mapeamento informado ou pelo
+// nome do cabeçalho. Retorna também o nome da coluna usada para cada campo
+func productImportColumnIndex(header []string, mapping map[string]string) (map[string]int, map[string]string, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		if key := normalizeColumnName(name); key != "" {
+			if _, found := positions[key]; !found {
+				positions[key] = i
+			}
+		}
+	}
+
+	columns := make(map[string]int)
+	names := make(map[string]string)
+	known := make(map[string]bool, len(productImportColumns))
+	for _, column := range productImportColumns {
+		known[column.Field] = true
+		candidates := append([]string{column.Field}, column.Aliases...)
+		if name, mapped := mapping[column.Field]; mapped {
+			candidates = []string{name}
+		}
+		for _, candidate := range candidates {
+			if index, found := positions[normalizeColumnName(candidate)]; found {
+				columns[column.Field] = index
+				names[column.Field] = strings.TrimSpace(header[index])
+				break
+			}
+		}
+		if _, mapped := mapping[column.Field]; mapped && names[column.Field] == "" {
+			return nil, nil, fmt.Errorf("Coluna %q não encontrada na planilha", mapping[column.Field])
+		}
+	}
+
+	for field := range mapping {
+		if !known[field] {
+			return nil, nil, fmt.Errorf("Campo desconhecido no mapeamento: %s", field)
+		}
+	}
+	if _, found := columns["barcode"]; !found {
+		return nil, nil, errors.New("Coluna de código de barras não encontrada (barcode)")
+	}
+	return columns, names, nil
+}
+
+// productImportRequest parte dos dados atuais do produto, para que colunas vazias mantenham
+// o valor cadastrado
+func productImportRequest(product models.Product, exists bool) models.ProductRequest {
+	if !exists {
+		return models.ProductRequest{Unit: "un"}
+	}
+
+	return models.ProductRequest{
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         &product.Price,
+		CostPrice:     &product.CostPrice,
+		MinStock:      &product.MinStock,
+		Unit:          product.Unit,
+		Active:        &product.Active,
+		CategoryID:    &product.CategoryID,
+		NCM:           product.NCM,
+		CEST:          product.CEST,
+		CFOP:          product.CFOP,
+		CST:           product.CST,
+		Origin:        &product.Origin,
+		ICMSRate:      &product.ICMSRate,
+		PLU:           product.PLU,
+		ShelfLifeDays: &product.ShelfLifeDays,
+		Tare:          &product.Tare,
+	}
+}
+
+// setProductImportField converte o valor da célula e o atribui ao campo da requisição
+func setProductImportField(req *models.ProductRequest, field, value string, categoriesByName map[string]uint, categoriesByID map[uint]bool) error {
+	switch field {
+	case "name":
+		req.Name = value
+	case "description":
+		req.Description = value
+	case "unit":
+		req.Unit = strings.ToLower(value)
+	case "ncm":
+		req.NCM = value
+	case "cest":
+		req.CEST = value
+	case "cfop":
+		req.CFOP = value
+	case "cst":
+		req.CST = value
+	case "plu":
+		req.PLU = value
+	case "shelf_life_days":
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Validade inválida: %s", value)
+		}
+		req.ShelfLifeDays = &days
+	case "category":
+		id, found := categoriesByName[strings.ToLower(value)]
+		if !found {
+			return fmt.Errorf("Categoria não encontrada: %s", value)
+		}
+		req.CategoryID = &id
+	case "category_id":
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || !categoriesByID[uint(parsed)] {
+			return fmt.Errorf("Categoria não encontrada: %s", value)
+		}
+		id := uint(parsed)
+		req.CategoryID = &id
+	case "price", "cost_price":
+		number, err := parseImportDecimal(value)
+		if err != nil {
+			return fmt.Errorf("Valor inválido: %s", value)
+		}
+		money := models.NewMoney(number)
+		if field == "price" {
+			req.Price = &money
+		} else {
+			req.CostPrice = &money
+		}
+	case "stock", "min_stock", "icms_rate", "tare":
+		number, err := parseImportDecimal(value)
+		if err != nil {
+			return fmt.Errorf("Número inválido: %s", value)
+		}
+		switch field {
+		case "stock":
+			number = models.RoundQuantity(number)
+			req.Stock = &number
+		case "min_stock":
+			req.MinStock = &number
+		case "tare":
+			req.Tare = &number
+		default:
+			req.ICMSRate = &number
+		}
+	case "origin":
+		origin, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Origem inválida: %s", value)
+		}
+		req.Origin = &origin
+	case "active":
+		active, err := parseImportBool(value)
+		if err != nil {
+			return err
+		}
+		req.Active = &active
+	case "barcode":
+		// chave da importação, já tratada
+	}
+	return nil
+}
+
+// productImportValidationErrors traduz os erros de validação da requisição para o relatório
+func productImportValidationErrors(err error) []models.ProductImportError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []models.ProductImportError{{Error: err.Error()}}
+	}
+
+	requestType := reflect.TypeOf(models.ProductRequest{})
+	var result []models.ProductImportError
+	for _, fieldError := range validationErrors {
+		field := fieldError.Field()
+		if structField, found := requestType.FieldByName(fieldError.StructField()); found {
+			field = strings.Split(structField.Tag.Get("json"), ",")[0]
+		}
+		if field == "category_id" {
+			field = "category"
+		}
+
+		var message string
+		switch fieldError.Tag() {
+		case "required":
+			message = "Campo obrigatório"
+		case "gt":
+			message = "Deve ser maior que " + fieldError.Param()
+		case "gte":
+			message = "Deve ser maior ou igual a " + fieldError.Param()
+		case "lte":
+			message = "Deve ser menor ou igual a " + fieldError.Param()
+		case "len":
+			message = "Deve ter " + fieldError.Param() + " caracteres"
+		case "min":
+			message = "Deve ter no mínimo " + fieldError.Param() + " caracteres"
+		case "max":
+			message = "Deve ter no máximo " + fieldError.Param() + " caracteres"
+		case "numeric":
+			message = "Deve conter apenas números"
+		default:
+			message = "Valor inválido"
+		}
+		result = append(result, models.ProductImportError{Field: field, Error: message})
+	}
+	return result
+}
+
+// parseImportDecimal aceita números com ponto ou vírgula decimal ("12.50", "12,50", "1.234,56")
+func parseImportDecimal(value string) (float64, error) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "R$"))
+	if strings.Contains(value, ",") {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseImportBool aceita sim/não, s/n, true/false e 1/0
+func parseImportBool(value string) (bool, error) {
+	switch normalizeColumnName(value) {
+	case "sim", "s", "true", "verdadeiro", "1", "ativo":
+		return true, nil
+	case "nao", "n", "false", "falso", "0", "inativo":
+		return false, nil
+	}
+	return false, fmt.Errorf("Valor inválido para ativo: %s", value)
+}
+
+// normalizeColumnName padroniza nomes de coluna: minúsculas, sem acentos e com "_" no lugar
+// de espaços ("Preço de Venda" → "preco_de_venda")
+func normalizeColumnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = columnAccents.Replace(name)
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '.' || r == '_' || r == '/'
+	}), "_")
+}
+
+var columnAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e",
+	"í", "i",
+	"ó", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ü", "u",
+	"ç", "c",
+)
+
+func importCell(row []string, columns map[string]int, field string) string {
+	index, found := columns[field]
+	if !found || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
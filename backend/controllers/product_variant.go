@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+)
+
+// GetProductVariants retorna as variações de um produto
+func GetProductVariants(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var variants []models.ProductVariant
+	query := tenantDB(c).Where("product_id = ?", product.ID).Order("name ASC")
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+	if err := query.Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar variações"})
+		return
+	}
+
+	responses := make([]models.ProductVariantResponse, len(variants))
+	for i := range variants {
+		responses[i] = variants[i].ToResponse(&product)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateProductVariant cadastra uma variação do produto. A partir da primeira variação o
+// estoque do produto passa a ser a soma do estoque das variações
+func CreateProductVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// O estoque inicial entra na loja do usuário
+	var storeID *uint
+	if req.Stock != nil && *req.Stock != 0 {
+		var ok bool
+		if storeID, ok = stockStore(c); !ok {
+			return
+		}
+	}
+
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	product, err := lockProduct(tx, uint(id))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	// O saldo existente não pertence a nenhuma variação: deve ser zerado antes
+	if !product.HasVariants && product.Stock != 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Zere o estoque do produto antes de cadastrar a primeira variação"})
+		return
+	}
+
+	variant := models.ProductVariant{ProductID: product.ID, Active: true}
+	if errMessage := fillProductVariant(tx, &product, &variant, req); errMessage != "" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+		return
+	}
+
+	if req.Stock != nil {
+		if err := product.ValidateQuantity(*req.Stock); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Create(&variant).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar variação"})
+		return
+	}
+
+	if !product.HasVariants {
+		if err := tx.Model(&product).Update("has_variants", true).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar variação"})
+			return
+		}
+	}
+
+	// Registrar o estoque inicial da variação no histórico de movimentações
+	if req.Stock != nil && *req.Stock != 0 {
+		if err := applyStockChange(tx, &product, stockChange{
+			Type:     models.StockMovementAdjustment,
+			Quantity: *req.Stock,
+			UserID:   c.GetUint("user_id"),
+			Notes:    "Estoque inicial da variação " + variant.Name,
+			Variant:  &variant,
+			StoreID:  storeID,
+		}); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar estoque inicial"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar variação"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant.ToResponse(&product))
+}
+
+// UpdateProductVariant atualiza os dados de uma variação. O estoque é alterado apenas por
+// movimentações (PUT /products/:id/stock com variant_id)
+func UpdateProductVariant(c *gin.Context) {
+	product, variant, ok := findProductVariant(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Alterar o preço exige permissão própria
+	if priceChanged(variant.Price, req.Price) && !requirePermission(c, models.PermissionProductPriceEdit) {
+		return
+	}
+
+	if errMessage := fillProductVariant(tenantDB(c), &product, &variant, req); errMessage != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+		return
+	}
+
+	if err := tenantDB(c).Omit("stock").Save(&variant).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar variação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant.ToResponse(&product))
+}
+
+// DeleteProductVariant exclui uma variação sem estoque nem histórico; as demais devem ser
+// desativadas
+func DeleteProductVariant(c *gin.Context) {
+	product, variant, ok := findProductVariant(c)
+	if !ok {
+		return
+	}
+
+	if variant.Stock != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir variação com estoque"})
+		return
+	}
+
+	var saleItemCount int64
+	tenantDB(c).Model(&models.SaleItem{}).Where("variant_id = ?", variant.ID).Count(&saleItemCount)
+	if saleItemCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir variação com vendas associadas, desative-a"})
+		return
+	}
+
+	var movementCount int64
+	tenantDB(c).Model(&models.StockMovement{}).Where("variant_id = ?", variant.ID).Count(&movementCount)
+	if movementCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir variação com movimentações de estoque, desative-a"})
+		return
+	}
+
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.ProductBarcode{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir variação"})
+		return
+	}
+	if err := tx.Delete(&variant).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir variação"})
+		return
+	}
+
+	// Sem variações o produto volta a controlar o próprio estoque
+	var remaining int64
+	tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&remaining)
+	if remaining == 0 {
+		if err := tx.Model(&product).Update("has_variants", false).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir variação"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir variação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variação excluída com sucesso"})
+}
+
+// findProductVariant carrega o produto e a variação informados na rota, respondendo com o
+// erro adequado quando não encontrados
+func findProductVariant(c *gin.Context) (models.Product, models.ProductVariant, bool) {
+	var product models.Product
+	var variant models.ProductVariant
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return product, variant, false
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da variação inválido"})
+		return product, variant, false
+	}
+
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return product, variant, false
+	}
+	if err := tenantDB(c).Where("product_id = ?", product.ID).First(&variant, uint(variantID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variação não encontrada"})
+		return product, variant, false
+	}
+	return product, variant, true
+}
+
+// fillProductVariant copia os dados da requisição para a variação, validando nome e código
+// de barras. Retorna a mensagem de erro de validação, se houver
+func fillProductVariant(db *gorm.DB, product *models.Product, variant *models.ProductVariant, req models.ProductVariantRequest) string {
+	attributes := models.VariantAttributes{}
+	for key, value := range req.Attributes {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "Nome de atributo vazio"
+		}
+		attributes[key] = strings.TrimSpace(value)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = attributes.Label()
+	}
+	if name == "" {
+		return "Informe o nome ou os atributos da variação"
+	}
+
+	var duplicated int64
+	db.Model(&models.ProductVariant{}).
+		Where("product_id = ? AND name = ? AND id != ?", product.ID, name, variant.ID).
+		Count(&duplicated)
+	if duplicated > 0 {
+		return "Já existe uma variação " + name + " para este produto"
+	}
+
+	barcode := strings.TrimSpace(req.Barcode)
+	if err := validateBarcode(barcode); err != nil {
+		return err.Error()
+	}
+	if barcode != "" && barcodeInUse(db, barcode, 0, variant.ID) {
+		return "Código de barras já existe"
+	}
+
+	variant.Name = name
+	variant.Attributes = attributes
+	variant.Barcode = nil
+	if barcode != "" {
+		variant.Barcode = &barcode
+	}
+	variant.Price = req.Price
+	if req.MinStock != nil {
+		variant.MinStock = *req.MinStock
+	}
+	if req.Active != nil {
+		variant.Active = *req.Active
+	}
+	return ""
+}
@@ -0,0 +1,461 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+	"pdv-backend/promotions"
+)
+
+// GetPromotions retorna as promoções cadastradas
+func GetPromotions(c *gin.Context) {
+	var promotionList []models.Promotion
+	query := tenantDB(c).Preload("Items.Product")
+
+	// Filtros opcionais
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	if promotionType := c.Query("type"); promotionType != "" {
+		query = query.Where("type = ?", promotionType)
+	}
+
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ? OR id IN (?)", productID,
+			tenantDB(c).Model(&models.PromotionItem{}).Select("promotion_id").Where("product_id = ?", productID))
+	}
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+
+	if err := query.Order("priority DESC, name ASC").Find(&promotionList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar promoções"})
+		return
+	}
+
+	// Somente as promoções válidas agora (vigência, dia da semana e horário)
+	if c.Query("current") == "true" {
+		now := time.Now()
+		current := promotionList[:0]
+		for _, promotion := range promotionList {
+			if promotion.ActiveAt(now) {
+				current = append(current, promotion)
+			}
+		}
+		promotionList = current
+	}
+
+	// Converter para response
+	responses := make([]models.PromotionResponse, len(promotionList))
+	for i, promotion := range promotionList {
+		responses[i] = promotion.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetPromotion retorna uma promoção específica
+func GetPromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := tenantDB(c).Preload("Items.Product").First(&promotion, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoção não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion.ToResponse())
+}
+
+// CreatePromotion cria uma nova promoção
+func CreatePromotion(c *gin.Context) {
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotion := models.Promotion{Active: true}
+	if err := fillPromotion(tenantDB(c), &promotion, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&promotion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar promoção"})
+		return
+	}
+
+	tenantDB(c).Preload("Items.Product").First(&promotion, promotion.ID)
+	c.JSON(http.StatusCreated, promotion.ToResponse())
+}
+
+// UpdatePromotion atualiza uma promoção, substituindo os produtos do combo
+func UpdatePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := tenantDB(c).First(&promotion, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoção não encontrada"})
+		return
+	}
+
+	if err := fillPromotion(tenantDB(c), &promotion, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("promotion_id = ?", promotion.ID).Delete(&models.PromotionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&promotion).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar promoção"})
+		return
+	}
+
+	tenantDB(c).Preload("Items.Product").First(&promotion, promotion.ID)
+	c.JSON(http.StatusOK, promotion.ToResponse())
+}
+
+// DeletePromotion exclui uma promoção
+func DeletePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := tenantDB(c).First(&promotion, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoção não encontrada"})
+		return
+	}
+
+	// Promoções já aplicadas em vendas devem ser desativadas, não excluídas
+	var saleItemCount int64
+	tenantDB(c).Model(&models.SaleItem{}).Where("promotion_id = ?", promotion.ID).Count(&saleItemCount)
+	if saleItemCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir promoção aplicada em vendas. Desative-a"})
+		return
+	}
+
+	err = tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("promotion_id = ?", promotion.ID).Delete(&models.PromotionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&promotion).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir promoção"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promoção excluída com sucesso"})
+}
+
+// PreviewSale precifica o carrinho com as promoções vigentes, sem registrar a venda
+func PreviewSale(c *gin.Context) {
+	var req models.SalePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := resolveSaleItemBarcodes(tenantDB(c), userStore(c), req.Items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var saleItems []models.SaleItem
+	products := make(map[uint]*models.Product)
+	variants := make(map[uint]*models.ProductVariant)
+	for _, itemReq := range req.Items {
+		product, loaded := products[itemReq.ProductID]
+		if !loaded {
+			var found models.Product
+			if err := tenantDB(c).Preload("Category").First(&found, itemReq.ProductID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + strconv.Itoa(int(itemReq.ProductID))})
+				return
+			}
+			product = &found
+			products[itemReq.ProductID] = product
+
+			// Na loja do operador vale o preço da loja
+			if err := applyStorePrice(tenantDB(c), userStore(c), product); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar preço da loja"})
+				return
+			}
+		}
+
+		if !product.Active {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto inativo: " + product.Name})
+			return
+		}
+
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		variant, errMessage := saleItemVariant(tenantDB(c), product, itemReq.VariantID, variants, false)
+		if errMessage != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+			return
+		}
+
+		quantity := models.RoundQuantity(itemReq.Quantity)
+		saleItem := models.SaleItem{
+			ProductID: product.ID,
+			Quantity:  quantity,
+			UnitPrice: product.Price,
+			Total:     product.Price.Mul(quantity),
+			Product:   *product,
+		}
+		if variant != nil {
+			saleItem.VariantID = &variant.ID
+			saleItem.VariantName = variant.Name
+			saleItem.VariantBarcode = variant.BarcodeValue()
+			saleItem.UnitPrice = variant.EffectivePrice(product)
+			saleItem.Total = saleItem.UnitPrice.Mul(quantity)
+		}
+		if itemReq.LabelTotal != nil {
+			saleItem.Total = *itemReq.LabelTotal
+		}
+		saleItems = append(saleItems, saleItem)
+	}
+
+	if err := applyPromotions(tenantDB(c), saleItems, products, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aplicar promoções"})
+		return
+	}
+
+	var preview models.SalePreviewResponse
+	preview.Items = make([]models.SaleItemResponse, len(saleItems))
+	for i, item := range saleItems {
+		preview.Items[i] = item.ToResponse()
+		preview.Subtotal += item.Total + item.Discount
+		preview.PromotionDiscount += item.Discount
+		preview.Total += item.Total
+	}
+
+	// Mesmas regras de desconto e acréscimo do registro da venda
+	sale := models.Sale{Total: preview.Total}
+	if req.DiscountPercentage != nil {
+		sale.Discount = sale.Total.Percent(*req.DiscountPercentage)
+	} else if req.Discount != nil {
+		sale.Discount = *req.Discount
+	}
+	if req.Tax != nil {
+		sale.Tax = *req.Tax
+	}
+	sale.CalculateTotal()
+
+	preview.Discount = sale.Discount
+	preview.Tax = sale.Tax
+	preview.FinalTotal = sale.FinalTotal
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// applyPromotions aplica aos itens as promoções vigentes no momento informado, preenchendo
+// desconto, promoção e total líquido de cada item. O total já calculado do item (ex.: o preço
+// impresso na etiqueta da balança) é o valor bruto sobre o qual incide o desconto
+func applyPromotions(db *gorm.DB, saleItems []models.SaleItem, products map[uint]*models.Product, at time.Time) error {
+	var candidates []models.Promotion
+	if err := db.Preload("Items").Where("active = ?", true).Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	active := candidates[:0]
+	for _, promotion := range candidates {
+		if promotion.ActiveAt(at) {
+			active = append(active, promotion)
+		}
+	}
+
+	items := make([]promotions.Item, len(saleItems))
+	for i, saleItem := range saleItems {
+		items[i] = promotions.Item{
+			ProductID:  saleItem.ProductID,
+			CategoryID: products[saleItem.ProductID].CategoryID,
+			Quantity:   saleItem.Quantity,
+			UnitPrice:  saleItem.UnitPrice,
+			Total:      saleItem.Total,
+		}
+	}
+
+	for i, result := range promotions.Apply(active, items) {
+		gross := items[i].Gross()
+		saleItems[i].Discount = 0
+		saleItems[i].PromotionID = nil
+		saleItems[i].PromotionName = ""
+		saleItems[i].Total = gross
+
+		if result.Promotion == nil || result.Discount <= 0 {
+			continue
+		}
+		if result.Discount > gross {
+			result.Discount = gross
+		}
+		promotionID := result.Promotion.ID
+		saleItems[i].Discount = result.Discount
+		saleItems[i].PromotionID = &promotionID
+		saleItems[i].PromotionName = result.Promotion.Name
+		saleItems[i].Total = gross - result.Discount
+	}
+	return nil
+}
+
+// fillPromotion copia os dados da requisição para a promoção, validando as regras de cada tipo
+func fillPromotion(db *gorm.DB, promotion *models.Promotion, req models.PromotionRequest) error {
+	promotion.Name = req.Name
+	promotion.Description = req.Description
+	promotion.Type = req.Type
+	promotion.Priority = req.Priority
+	promotion.ProductID = nil
+	promotion.CategoryID = nil
+	promotion.Percentage = 0
+	promotion.BuyQuantity = 0
+	promotion.PayQuantity = 0
+	promotion.ComboPrice = 0
+	promotion.Items = nil
+
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+
+	switch req.Type {
+	case models.PromotionPercentage, models.PromotionBuyXPayY:
+		if (req.ProductID == nil) == (req.CategoryID == nil) {
+			return errors.New("Informe o produto ou a categoria da promoção")
+		}
+		if req.ProductID != nil {
+			if err := db.First(&models.Product{}, *req.ProductID).Error; err != nil {
+				return errors.New("Produto não encontrado")
+			}
+		} else if err := db.First(&models.Category{}, *req.CategoryID).Error; err != nil {
+			return errors.New("Categoria não encontrada")
+		}
+		promotion.ProductID = req.ProductID
+		promotion.CategoryID = req.CategoryID
+
+		if req.Type == models.PromotionPercentage {
+			if req.Percentage <= 0 {
+				return errors.New("Informe o percentual de desconto")
+			}
+			promotion.Percentage = req.Percentage
+		} else {
+			if req.BuyQuantity < 2 || req.PayQuantity < 1 || req.PayQuantity >= req.BuyQuantity {
+				return errors.New("Leve X pague Y exige buy_quantity maior que pay_quantity (ex.: leve 3 pague 2)")
+			}
+			promotion.BuyQuantity = req.BuyQuantity
+			promotion.PayQuantity = req.PayQuantity
+		}
+
+	case models.PromotionComboPrice:
+		if req.ComboPrice <= 0 {
+			return errors.New("Informe o preço do combo")
+		}
+		var quantity float64
+		seen := make(map[uint]bool)
+		for _, item := range req.Items {
+			if seen[item.ProductID] {
+				return errors.New("Produto repetido no combo")
+			}
+			seen[item.ProductID] = true
+
+			var product models.Product
+			if err := db.First(&product, item.ProductID).Error; err != nil {
+				return errors.New("Produto não encontrado: " + strconv.Itoa(int(item.ProductID)))
+			}
+			if err := product.ValidateQuantity(item.Quantity); err != nil {
+				return err
+			}
+			quantity += item.Quantity
+			promotion.Items = append(promotion.Items, models.PromotionItem{
+				ProductID: item.ProductID,
+				Quantity:  models.RoundQuantity(item.Quantity),
+			})
+		}
+		if len(promotion.Items) == 0 || (len(promotion.Items) == 1 && quantity < 2) {
+			return errors.New("O combo deve ter ao menos dois itens")
+		}
+		promotion.ComboPrice = req.ComboPrice
+	}
+
+	// Vigência
+	promotion.StartsAt = nil
+	promotion.EndsAt = nil
+	if req.StartsAt != "" {
+		startsAt, err := time.ParseInLocation("2006-01-02", req.StartsAt, time.Local)
+		if err != nil {
+			return errors.New("Data de início inválida (use AAAA-MM-DD)")
+		}
+		promotion.StartsAt = &startsAt
+	}
+	if req.EndsAt != "" {
+		endsAt, err := time.ParseInLocation("2006-01-02", req.EndsAt, time.Local)
+		if err != nil {
+			return errors.New("Data de término inválida (use AAAA-MM-DD)")
+		}
+		promotion.EndsAt = &endsAt
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && promotion.EndsAt.Before(*promotion.StartsAt) {
+		return errors.New("Data de término anterior à data de início")
+	}
+
+	// Janela diária (happy hour)
+	if (req.StartTime == "") != (req.EndTime == "") {
+		return errors.New("Informe o horário de início e de término da promoção")
+	}
+	promotion.StartTime = ""
+	promotion.EndTime = ""
+	if req.StartTime != "" {
+		startTime, err := time.Parse("15:04", req.StartTime)
+		if err != nil {
+			return errors.New("Horário inválido (use HH:MM): " + req.StartTime)
+		}
+		endTime, err := time.Parse("15:04", req.EndTime)
+		if err != nil {
+			return errors.New("Horário inválido (use HH:MM): " + req.EndTime)
+		}
+		if startTime.Equal(endTime) {
+			return errors.New("Horário de início e de término não podem ser iguais")
+		}
+		promotion.StartTime = startTime.Format("15:04")
+		promotion.EndTime = endTime.Format("15:04")
+	}
+
+	weekdays := make([]string, len(req.Weekdays))
+	for i, day := range req.Weekdays {
+		weekdays[i] = strconv.Itoa(day)
+	}
+	promotion.Weekdays = strings.Join(weekdays, ",")
+
+	return nil
+}
@@ -0,0 +1,729 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/fiscal"
+	"pdv-backend/models"
+)
+
+// maxInvoiceXMLSize limita o tamanho do XML de NF-e aceito na importação
+const maxInvoiceXMLSize = 5 << 20
+
+// invoiceLine é um item da NF-e de entrada associado (ou não) a um produto cadastrado
+type invoiceLine struct {
+	Item    fiscal.IncomingItem
+	Match   string
+	Product *models.Product
+	Variant *models.ProductVariant // variação recebida, em produtos com variações
+	Factor  float64                // unidades do produto por unidade comercial da NF-e
+}
+
+// StockQuantity retorna a quantidade do item convertida para a unidade do produto
+func (l invoiceLine) StockQuantity() float64 {
+	return models.RoundQuantity(l.Item.Quantity * l.Factor)
+}
+
+// UnitCost retorna o custo por unidade do produto, rateando frete, IPI, ST e descontos
+func (l invoiceLine) UnitCost() models.Money {
+	quantity := l.StockQuantity()
+	if quantity <= 0 {
+		return 0
+	}
+	return models.Money(math.Round(float64(l.Item.Cost()) / quantity))
+}
+
+// ImportPurchaseInvoice recebe o XML da NF-e do fornecedor (arquivo "file" em multipart ou o
+// XML no corpo da requisição) e retorna a prévia da entrada: itens associados a produtos
+// cadastrados pelo código do fornecedor ou código de barras, e propostas de cadastro para
+// os demais. Nada é lançado no estoque até a confirmação
+func ImportPurchaseInvoice(c *gin.Context) {
+	data, err := readInvoiceXML(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nfe, err := fiscal.ParseNFe(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoice := models.PurchaseInvoice{Status: models.PurchaseInvoicePending}
+	status := http.StatusCreated
+	if err := tenantDB(c).Where("access_key = ?", nfe.AccessKey).First(&invoice).Error; err == nil {
+		if invoice.Status == models.PurchaseInvoiceImported {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":             "NF-e já importada",
+				"purchase_order_id": invoice.PurchaseOrderID,
+			})
+			return
+		}
+		status = http.StatusOK
+	}
+
+	invoice.AccessKey = nfe.AccessKey
+	invoice.Number = nfe.Number
+	invoice.Series = nfe.Series
+	invoice.IssuedAt = nfe.IssuedAt
+	invoice.SupplierDocument = nfe.Issuer.Document
+	invoice.SupplierName = nfe.Issuer.Name
+	invoice.Total = nfe.Total
+	invoice.XML = string(data)
+	invoice.UserID = c.GetUint("user_id")
+	invoice.SupplierID = nil
+	if supplier, found := findInvoiceSupplier(tenantDB(c), nfe.Issuer.Document); found {
+		invoice.SupplierID = &supplier.ID
+	}
+
+	if err := tenantDB(c).Save(&invoice).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar NF-e"})
+		return
+	}
+
+	response, err := purchaseInvoiceResponse(tenantDB(c), invoice, nfe)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao associar itens da NF-e"})
+		return
+	}
+	c.JSON(status, response)
+}
+
+// GetPurchaseInvoice retorna a prévia de uma NF-e importada
+func GetPurchaseInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var invoice models.PurchaseInvoice
+	if err := tenantDB(c).First(&invoice, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "NF-e não encontrada"})
+		return
+	}
+
+	nfe, err := fiscal.ParseNFe([]byte(invoice.XML))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := purchaseInvoiceResponse(tenantDB(c), invoice, nfe)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao associar itens da NF-e"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmPurchaseInvoice dá entrada na NF-e: cadastra o fornecedor e os produtos novos,
+// grava a associação código do fornecedor → produto, gera um pedido de compra recebido e
+// lança as quantidades no estoque atualizando o custo médio dos produtos
+func ConfirmPurchaseInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	// Corpo opcional: sem ele, usa apenas a associação automática
+	var req models.ConfirmPurchaseInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decisions := make(map[int]models.ConfirmPurchaseInvoiceItemRequest, len(req.Items))
+	for _, item := range req.Items {
+		decisions[item.Line] = item
+	}
+
+	// A mercadoria entra no estoque da loja do usuário
+	storeID, ok := stockStore(c)
+	if !ok {
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var invoice models.PurchaseInvoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "NF-e não encontrada"})
+		return
+	}
+
+	if invoice.Status == models.PurchaseInvoiceImported {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "NF-e já importada",
+			"purchase_order_id": invoice.PurchaseOrderID,
+		})
+		return
+	}
+
+	nfe, err := fiscal.ParseNFe([]byte(invoice.XML))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for line := range decisions {
+		if !invoiceHasLine(nfe, line) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d não existe na NF-e", line)})
+			return
+		}
+	}
+
+	// Fornecedor: cadastrado a partir do emitente se ainda não existir
+	supplier, err := invoiceSupplier(tx, nfe.Issuer)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines, err := matchInvoiceItems(tx, &supplier.ID, nfe.Items)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao associar itens da NF-e"})
+		return
+	}
+
+	// Aplicar as decisões do usuário sobre cada item
+	var received []invoiceLine
+	var pending []string
+	for _, line := range lines {
+		decision, decided := decisions[line.Item.Line]
+		if decided && decision.Skip {
+			continue
+		}
+
+		switch {
+		case decided && decision.ProductID != nil:
+			var product models.Product
+			if err := tx.First(&product, *decision.ProductID).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + strconv.Itoa(int(*decision.ProductID))})
+				return
+			}
+			if line.Product == nil || line.Product.ID != product.ID {
+				line.Factor = 1
+				line.Variant = nil
+			}
+			line.Product = &product
+		case decided && decision.Create:
+			line.Factor = 1
+			product, err := createInvoiceProduct(tx, line, decision, req.CategoryID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: %s", line.Item.Line, err.Error())})
+				return
+			}
+			line.Product = product
+		case line.Product == nil:
+			pending = append(pending, strconv.Itoa(line.Item.Line))
+			continue
+		}
+
+		if decided && decision.UnitFactor != nil {
+			line.Factor = *decision.UnitFactor
+		}
+
+		// Produtos com variações recebem a mercadoria na variação informada ou associada
+		variantID := decision.VariantID
+		if variantID == nil && line.Variant != nil {
+			variantID = &line.Variant.ID
+		}
+		variant, err := purchaseItemVariant(tx, line.Product, variantID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: %s", line.Item.Line, err.Error())})
+			return
+		}
+		line.Variant = variant
+		received = append(received, line)
+	}
+
+	if len(pending) > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Itens sem produto associado (informe product_id, create ou skip): " + strings.Join(pending, ", ")})
+		return
+	}
+	if len(received) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum item para dar entrada"})
+		return
+	}
+
+	// Gerar o pedido de compra já recebido, que documenta a entrada
+	now := time.Now()
+	notes := fmt.Sprintf("NF-e nº %d série %d", nfe.Number, nfe.Series)
+	if req.Notes != "" {
+		notes += " - " + req.Notes
+	}
+	order := models.PurchaseOrder{
+		SupplierID: supplier.ID,
+		Status:     models.PurchaseOrderReceived,
+		Notes:      notes,
+		SentAt:     &now,
+		ReceivedAt: &now,
+		UserID:     c.GetUint("user_id"),
+	}
+	for _, line := range received {
+		if err := line.Product.ValidateQuantity(line.StockQuantity()); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: %s (ajuste o fator de conversão)", line.Item.Line, err.Error())})
+			return
+		}
+		item := models.PurchaseOrderItem{
+			ProductID:        line.Product.ID,
+			Quantity:         line.StockQuantity(),
+			ReceivedQuantity: line.StockQuantity(),
+			UnitCost:         line.UnitCost(),
+			Total:            line.Item.Cost(),
+		}
+		if line.Variant != nil {
+			item.VariantID = &line.Variant.ID
+			item.VariantName = line.Variant.Name
+		}
+		order.Items = append(order.Items, item)
+		order.Total += line.Item.Cost()
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar pedido de compra"})
+		return
+	}
+
+	movementNotes := fmt.Sprintf("%s - %s", notes, supplier.Name)
+	for _, line := range received {
+		// Lembrar a associação para as próximas notas do fornecedor
+		if line.Item.Code != "" {
+			mapping := models.SupplierProduct{
+				SupplierID:   supplier.ID,
+				SupplierCode: line.Item.Code,
+				ProductID:    line.Product.ID,
+				UnitFactor:   line.Factor,
+			}
+			if line.Variant != nil {
+				mapping.VariantID = &line.Variant.ID
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "supplier_id"}, {Name: "supplier_code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"product_id", "variant_id", "unit_factor", "updated_at"}),
+			}).Create(&mapping).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar associação do produto"})
+				return
+			}
+		}
+
+		product, err := lockProduct(tx, line.Product.ID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
+			return
+		}
+
+		change := stockChange{
+			ReferenceType: "purchase_order",
+			ReferenceID:   &order.ID,
+			UserID:        c.GetUint("user_id"),
+			Notes:         movementNotes,
+			StoreID:       storeID,
+		}
+		if line.Variant != nil {
+			variant, err := lockVariant(tx, product.ID, line.Variant.ID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar variação"})
+				return
+			}
+			change.Variant = &variant
+		}
+
+		if err := receiveStock(tx, &product, line.StockQuantity(), line.UnitCost(), change); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+			return
+		}
+	}
+
+	if err := tx.Model(&invoice).Updates(map[string]interface{}{
+		"status":            models.PurchaseInvoiceImported,
+		"supplier_id":       supplier.ID,
+		"purchase_order_id": order.ID,
+		"imported_at":       now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar NF-e"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar entrada da NF-e"})
+		return
+	}
+
+	tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, order.ID)
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// readInvoiceXML lê o XML enviado como arquivo multipart ("file") ou no corpo da requisição
+func readInvoiceXML(c *gin.Context) ([]byte, error) {
+	var reader io.Reader = http.MaxBytesReader(c.Writer, c.Request.Body, maxInvoiceXMLSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("Arquivo XML da NF-e é obrigatório (campo file)")
+		}
+		if header.Size > maxInvoiceXMLSize {
+			return nil, errors.New("Arquivo XML muito grande")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, errors.New("Erro ao ler arquivo XML")
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.New("Erro ao ler XML da NF-e")
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, errors.New("XML da NF-e é obrigatório")
+	}
+	return data, nil
+}
+
+// purchaseInvoiceResponse monta a prévia da NF-e com a associação atual dos itens
+func purchaseInvoiceResponse(db *gorm.DB, invoice models.PurchaseInvoice, nfe *fiscal.IncomingNFe) (models.PurchaseInvoiceResponse, error) {
+	response := invoice.ToResponse()
+
+	lines, err := matchInvoiceItems(db, invoice.SupplierID, nfe.Items)
+	if err != nil {
+		return response, err
+	}
+
+	for _, line := range lines {
+		item := models.PurchaseInvoiceItemResponse{
+			Line:          line.Item.Line,
+			SupplierCode:  line.Item.Code,
+			Barcode:       line.Item.EAN,
+			Description:   line.Item.Description,
+			NCM:           line.Item.NCM,
+			CFOP:          line.Item.CFOP,
+			Unit:          line.Item.Unit,
+			Quantity:      line.Item.Quantity,
+			Total:         line.Item.Cost(),
+			Match:         line.Match,
+			UnitFactor:    line.Factor,
+			StockQuantity: line.StockQuantity(),
+			UnitCost:      line.UnitCost(),
+		}
+		if line.Product != nil {
+			product := line.Product.ToResponse()
+			item.ProductID = &line.Product.ID
+			item.Product = &product
+			if line.Variant != nil {
+				item.VariantID = &line.Variant.ID
+				item.VariantName = line.Variant.Name
+			}
+		} else {
+			item.Proposed = proposedInvoiceProduct(line)
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	if invoice.Status == models.PurchaseInvoicePending {
+		response.Warnings = invoiceWarnings(nfe)
+	}
+	return response, nil
+}
+
+// invoiceWarnings aponta inconsistências da NF-e que não impedem a entrada
+func invoiceWarnings(nfe *fiscal.IncomingNFe) []string {
+	warnings := []string{}
+	if nfe.Model != 55 {
+		warnings = append(warnings, fmt.Sprintf("Documento modelo %d (esperado 55 - NF-e)", nfe.Model))
+	}
+	if nfe.Protocol == "" {
+		warnings = append(warnings, "XML sem protocolo de autorização da SEFAZ")
+	}
+	if fiscal.Default != nil {
+		if cnpj := fiscal.Default.Config().CNPJ; nfe.Recipient.Document != cnpj {
+			warnings = append(warnings, "Destinatário da NF-e ("+nfe.Recipient.Document+") difere do CNPJ da empresa")
+		}
+	}
+	var cost models.Money
+	for _, item := range nfe.Items {
+		cost += item.Cost()
+	}
+	if cost != nfe.Total {
+		warnings = append(warnings, fmt.Sprintf("Soma dos itens (%s) difere do total da NF-e (%s)", cost, nfe.Total))
+	}
+	return warnings
+}
+
+// matchInvoiceItems associa os itens da NF-e aos produtos cadastrados: primeiro pelo código
+// do fornecedor já associado em entradas anteriores, depois pelo código de barras (GTIN) do
+// produto ou da variação
+func matchInvoiceItems(db *gorm.DB, supplierID *uint, items []fiscal.IncomingItem) ([]invoiceLine, error) {
+	mappings := make(map[string]models.SupplierProduct)
+	if supplierID != nil {
+		var supplierProducts []models.SupplierProduct
+		if err := db.Preload("Product.Category").Preload("Variant").Where("supplier_id = ?", *supplierID).Find(&supplierProducts).Error; err != nil {
+			return nil, err
+		}
+		for _, mapping := range supplierProducts {
+			mappings[mapping.SupplierCode] = mapping
+		}
+	}
+
+	var barcodes []string
+	for _, item := range items {
+		for _, barcode := range []string{item.EAN, item.EANTrib} {
+			if barcode != "" {
+				barcodes = append(barcodes, barcode)
+			}
+		}
+	}
+	products := make(map[string]models.Product)
+	variants := make(map[string]models.ProductVariant)
+	if len(barcodes) > 0 {
+		var found []models.Product
+		if err := db.Preload("Category").Where("barcode IN ?", barcodes).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, product := range found {
+			products[product.Barcode] = product
+		}
+
+		var foundVariants []models.ProductVariant
+		if err := db.Preload("Product.Category").Where("barcode IN ?", barcodes).Find(&foundVariants).Error; err != nil {
+			return nil, err
+		}
+		for _, variant := range foundVariants {
+			variants[variant.BarcodeValue()] = variant
+		}
+	}
+
+	// matchBarcode associa a linha ao produto ou à variação do código de barras
+	matchBarcode := func(line *invoiceLine, barcode string) bool {
+		if barcode == "" {
+			return false
+		}
+		if product, found := products[barcode]; found {
+			line.Product = &product
+			return true
+		}
+		if variant, found := variants[barcode]; found {
+			product := variant.Product
+			line.Product = &product
+			line.Variant = &variant
+			return true
+		}
+		return false
+	}
+
+	lines := make([]invoiceLine, len(items))
+	for i, item := range items {
+		line := invoiceLine{Item: item, Match: models.InvoiceMatchNone, Factor: 1}
+
+		if mapping, found := mappings[item.Code]; found && item.Code != "" && mapping.Product.ID != 0 {
+			product := mapping.Product
+			line.Match = models.InvoiceMatchSupplierCode
+			line.Product = &product
+			line.Variant = mapping.Variant
+			line.Factor = mapping.UnitFactor
+		} else if matchBarcode(&line, item.EAN) {
+			line.Match = models.InvoiceMatchBarcode
+		} else if matchBarcode(&line, item.EANTrib) {
+			// GTIN tributável é o da unidade: converter a quantidade comercial (ex.: caixas)
+			line.Match = models.InvoiceMatchBarcode
+			if item.QuantityTrib > 0 {
+				line.Factor = item.QuantityTrib / item.Quantity
+			}
+		}
+		lines[i] = line
+	}
+	return lines, nil
+}
+
+// proposedInvoiceProduct monta a proposta de cadastro para um item sem produto associado
+func proposedInvoiceProduct(line invoiceLine) *models.ProductRequest {
+	cost := line.UnitCost()
+	proposed := &models.ProductRequest{
+		Name:      truncateText(line.Item.Description, 200),
+		Barcode:   line.Item.EAN,
+		Unit:      invoiceProductUnit(line.Item.Unit),
+		CostPrice: &cost,
+		NCM:       line.Item.NCM,
+		CEST:      line.Item.CEST,
+	}
+	if proposed.Barcode == "" {
+		proposed.Barcode = line.Item.EANTrib
+	}
+	return proposed
+}
+
+// createInvoiceProduct cadastra o produto de um item da NF-e, usando os dados informados
+// pelo usuário e completando com a proposta da nota
+func createInvoiceProduct(tx *gorm.DB, line invoiceLine, decision models.ConfirmPurchaseInvoiceItemRequest, defaultCategoryID *uint) (*models.Product, error) {
+	proposed := proposedInvoiceProduct(line)
+
+	categoryID := decision.CategoryID
+	if categoryID == nil {
+		categoryID = defaultCategoryID
+	}
+	if categoryID == nil {
+		return nil, errors.New("Categoria é obrigatória para cadastrar o produto")
+	}
+	var category models.Category
+	if err := tx.First(&category, *categoryID).Error; err != nil {
+		return nil, errors.New("Categoria não encontrada")
+	}
+
+	if decision.Price == nil {
+		return nil, errors.New("Preço de venda é obrigatório para cadastrar o produto")
+	}
+
+	product := models.Product{
+		Name:       proposed.Name,
+		Barcode:    proposed.Barcode,
+		Price:      *decision.Price,
+		Unit:       proposed.Unit,
+		CategoryID: category.ID,
+		NCM:        proposed.NCM,
+		CEST:       proposed.CEST,
+		Active:     true,
+	}
+	if decision.Name != "" {
+		product.Name = decision.Name
+	}
+	if decision.Barcode != "" {
+		product.Barcode = decision.Barcode
+	}
+	if decision.Unit != "" {
+		product.Unit = strings.ToLower(decision.Unit)
+	}
+	if len(product.Name) < 2 {
+		return nil, errors.New("Nome do produto é obrigatório")
+	}
+
+	// Verificar se o código de barras já existe
+	if product.Barcode != "" {
+		var existingProduct models.Product
+		if err := tx.Where("barcode = ?", product.Barcode).First(&existingProduct).Error; err == nil {
+			return nil, errors.New("Código de barras já existe")
+		}
+	}
+
+	// Custo e estoque são definidos na entrada da mercadoria
+	if err := tx.Create(&product).Error; err != nil {
+		return nil, errors.New("Erro ao criar produto")
+	}
+	return &product, nil
+}
+
+// invoiceProductUnit converte a unidade comercial da NF-e para a unidade do produto
+func invoiceProductUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	switch unit {
+	case "lt", "litro":
+		unit = "l"
+	case "mt", "metro":
+		unit = "m"
+	}
+	if models.IsFractionalUnit(unit) {
+		return unit
+	}
+	return "un"
+}
+
+// invoiceSupplier busca o fornecedor pelo CNPJ/CPF do emitente ou o cadastra com os dados da NF-e
+func invoiceSupplier(tx *gorm.DB, issuer fiscal.IncomingParty) (models.Supplier, error) {
+	if supplier, found := findInvoiceSupplier(tx, issuer.Document); found {
+		return supplier, nil
+	}
+
+	document, documentType, err := parseDocument(issuer.Document)
+	if err != nil {
+		return models.Supplier{}, fmt.Errorf("Emitente da NF-e: %s", err.Error())
+	}
+	if document == nil {
+		return models.Supplier{}, errors.New("Emitente da NF-e sem CNPJ/CPF")
+	}
+
+	supplier := models.Supplier{
+		Name:         issuer.Name,
+		TradeName:    issuer.TradeName,
+		DocumentType: documentType,
+		Document:     document,
+		StateReg:     issuer.StateReg,
+		Phone:        issuer.Phone,
+		ZipCode:      issuer.ZipCode,
+		Street:       issuer.Street,
+		Number:       issuer.Number,
+		District:     issuer.District,
+		City:         issuer.City,
+		State:        issuer.State,
+		Active:       true,
+	}
+	if err := tx.Create(&supplier).Error; err != nil {
+		return supplier, errors.New("Erro ao cadastrar fornecedor")
+	}
+	return supplier, nil
+}
+
+func findInvoiceSupplier(db *gorm.DB, document string) (models.Supplier, bool) {
+	var supplier models.Supplier
+	if document == "" {
+		return supplier, false
+	}
+	err := db.Where("document = ?", document).First(&supplier).Error
+	return supplier, err == nil
+}
+
+func invoiceHasLine(nfe *fiscal.IncomingNFe, line int) bool {
+	for _, item := range nfe.Items {
+		if item.Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateText corta o texto no limite de caracteres informado
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,472 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+	"pdv-backend/pricing"
+)
+
+// GetPurchaseOrders retorna os pedidos de compra
+func GetPurchaseOrders(c *gin.Context) {
+	var orders []models.PurchaseOrder
+	query := tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category")
+
+	// Filtros opcionais
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("created_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("created_at <= ?", endOfDay)
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar pedidos de compra"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.PurchaseOrderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = order.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetPurchaseOrder retorna um pedido de compra específico
+func GetPurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var order models.PurchaseOrder
+	if err := tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// CreatePurchaseOrder cria um pedido de compra em rascunho
+func CreatePurchaseOrder(c *gin.Context) {
+	var req models.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := models.PurchaseOrder{
+		Status: models.PurchaseOrderDraft,
+		UserID: c.GetUint("user_id"),
+	}
+	if err := fillPurchaseOrder(tenantDB(c), &order, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar pedido de compra"})
+		return
+	}
+
+	tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, order.ID)
+	c.JSON(http.StatusCreated, order.ToResponse())
+}
+
+// UpdatePurchaseOrder atualiza um pedido de compra em rascunho, substituindo os itens
+func UpdatePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.PurchaseOrder
+	if err := tenantDB(c).First(&order, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+		return
+	}
+
+	if order.Status != models.PurchaseOrderDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas pedidos em rascunho podem ser alterados"})
+		return
+	}
+
+	if err := fillPurchaseOrder(tenantDB(c), &order, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&order).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar pedido de compra"})
+		return
+	}
+
+	tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, order.ID)
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// SendPurchaseOrder marca o pedido em rascunho como enviado ao fornecedor
+func SendPurchaseOrder(c *gin.Context) {
+	order, ok := findPurchaseOrder(c)
+	if !ok {
+		return
+	}
+
+	if order.Status != models.PurchaseOrderDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas pedidos em rascunho podem ser enviados"})
+		return
+	}
+
+	now := time.Now()
+	if err := tenantDB(c).Model(&order).Updates(map[string]interface{}{
+		"status":  models.PurchaseOrderSent,
+		"sent_at": now,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao enviar pedido de compra"})
+		return
+	}
+
+	tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, order.ID)
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// CancelPurchaseOrder cancela um pedido de compra ainda não recebido
+func CancelPurchaseOrder(c *gin.Context) {
+	order, ok := findPurchaseOrder(c)
+	if !ok {
+		return
+	}
+
+	if order.Status != models.PurchaseOrderDraft && order.Status != models.PurchaseOrderSent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas pedidos em rascunho ou enviados podem ser cancelados"})
+		return
+	}
+
+	if err := tenantDB(c).Model(&order).Update("status", models.PurchaseOrderCancelled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar pedido de compra"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pedido de compra cancelado com sucesso"})
+}
+
+// ReceivePurchaseOrder registra o recebimento (total ou parcial) das mercadorias do pedido:
+// dá entrada no estoque, atualiza o custo médio dos produtos e registra as movimentações
+func ReceivePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	// Corpo opcional: sem ele, recebe todo o saldo pendente
+	var req models.ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A mercadoria entra no estoque da loja do usuário
+	storeID, ok := stockStore(c)
+	if !ok {
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Supplier").Preload("Items").First(&order, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+		return
+	}
+
+	if order.Status != models.PurchaseOrderSent && order.Status != models.PurchaseOrderPartiallyReceived {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas pedidos enviados ou parcialmente recebidos podem ser recebidos"})
+		return
+	}
+
+	items := make(map[uint]*models.PurchaseOrderItem, len(order.Items))
+	for i := range order.Items {
+		items[order.Items[i].ID] = &order.Items[i]
+	}
+
+	// Sem itens informados, recebe todo o saldo pendente
+	lines := req.Items
+	if len(lines) == 0 {
+		for _, item := range order.Items {
+			if pending := item.PendingQuantity(); pending > 0 {
+				lines = append(lines, models.ReceivePurchaseOrderItemRequest{ItemID: item.ID, Quantity: pending})
+			}
+		}
+	}
+	if len(lines) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pedido sem itens pendentes de recebimento"})
+		return
+	}
+
+	notes := fmt.Sprintf("Pedido de compra #%d - %s", order.ID, order.Supplier.Name)
+	if req.Notes != "" {
+		notes += " - " + req.Notes
+	}
+
+	for _, line := range lines {
+		item, found := items[line.ItemID]
+		if !found {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Item não pertence ao pedido: " + strconv.Itoa(int(line.ItemID))})
+			return
+		}
+
+		quantity := models.RoundQuantity(line.Quantity)
+		if quantity > item.PendingQuantity() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantidade recebida excede o saldo pendente do item %d (%g)", item.ID, item.PendingQuantity())})
+			return
+		}
+
+		product, err := lockProduct(tx, item.ProductID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
+			return
+		}
+
+		if err := product.ValidateQuantity(quantity); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		unitCost := item.UnitCost
+		if line.UnitCost != nil {
+			unitCost = *line.UnitCost
+		}
+
+		change := stockChange{
+			ReferenceType: "purchase_order",
+			ReferenceID:   &order.ID,
+			UserID:        c.GetUint("user_id"),
+			Notes:         notes,
+			StoreID:       storeID,
+		}
+		if item.VariantID != nil {
+			variant, err := lockVariant(tx, product.ID, *item.VariantID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Variação não encontrada para: " + product.Name})
+				return
+			}
+			change.Variant = &variant
+		} else if product.HasVariants {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto passou a ter variações após o pedido: " + product.Name})
+			return
+		}
+
+		if err := receiveStock(tx, &product, quantity, unitCost, change); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+			return
+		}
+
+		item.ReceivedQuantity = models.RoundQuantity(item.ReceivedQuantity + quantity)
+		if err := tx.Model(item).Update("received_quantity", item.ReceivedQuantity).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar item do pedido"})
+			return
+		}
+	}
+
+	// Atualizar status do pedido
+	order.Status = models.PurchaseOrderReceived
+	for _, item := range order.Items {
+		if item.PendingQuantity() > 0 {
+			order.Status = models.PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+	updates := map[string]interface{}{"status": order.Status}
+	if order.Status == models.PurchaseOrderReceived {
+		updates["received_at"] = time.Now()
+	}
+	if err := tx.Model(&order).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar pedido de compra"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar recebimento"})
+		return
+	}
+
+	tenantDB(c).Preload("Supplier").Preload("User").Preload("Items.Product.Category").First(&order, order.ID)
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// receiveStock dá entrada de mercadoria no estoque do produto, recalculando o custo médio
+// ponderado antes de alterar o saldo
+func receiveStock(tx *gorm.DB, product *models.Product, quantity float64, unitCost models.Money, change stockChange) error {
+	oldCostPrice := product.CostPrice
+	cost := product.WeightedAverageCost(quantity, unitCost)
+	if err := tx.Model(product).Update("cost_price", cost).Error; err != nil {
+		return err
+	}
+	product.CostPrice = cost
+
+	if err := pricing.Record(tx, product, product.Price, oldCostPrice, pricing.Change{
+		Source: models.PriceChangePurchase,
+		UserID: change.UserID,
+		Notes:  change.Notes,
+	}); err != nil {
+		return err
+	}
+
+	change.Type = models.StockMovementPurchase
+	change.Quantity = quantity
+	return applyStockChange(tx, product, change)
+}
+
+// findPurchaseOrder busca o pedido de compra informado na rota
+func findPurchaseOrder(c *gin.Context) (models.PurchaseOrder, bool) {
+	var order models.PurchaseOrder
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return order, false
+	}
+
+	if err := tenantDB(c).First(&order, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+		return order, false
+	}
+	return order, true
+}
+
+// fillPurchaseOrder copia os dados da requisição para o pedido, validando fornecedor e produtos
+func fillPurchaseOrder(db *gorm.DB, order *models.PurchaseOrder, req models.PurchaseOrderRequest) error {
+	var supplier models.Supplier
+	if err := db.First(&supplier, req.SupplierID).Error; err != nil {
+		return errors.New("Fornecedor não encontrado")
+	}
+	if !supplier.Active {
+		return errors.New("Fornecedor inativo: " + supplier.Name)
+	}
+
+	order.SupplierID = supplier.ID
+	order.Notes = req.Notes
+	order.ExpectedAt = nil
+	if req.ExpectedAt != "" {
+		expectedAt, err := time.ParseInLocation("2006-01-02", req.ExpectedAt, time.Local)
+		if err != nil {
+			return errors.New("Previsão de entrega inválida (use AAAA-MM-DD)")
+		}
+		order.ExpectedAt = &expectedAt
+	}
+
+	order.Items = nil
+	order.Total = 0
+	for _, itemReq := range req.Items {
+		var product models.Product
+		if err := db.First(&product, itemReq.ProductID).Error; err != nil {
+			return errors.New("Produto não encontrado: " + strconv.Itoa(int(itemReq.ProductID)))
+		}
+		// Em produtos com variações o pedido é da variação, que recebe o estoque
+		variant, err := purchaseItemVariant(db, &product, itemReq.VariantID)
+		if err != nil {
+			return err
+		}
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
+			return err
+		}
+
+		quantity := models.RoundQuantity(itemReq.Quantity)
+		item := models.PurchaseOrderItem{
+			ProductID: product.ID,
+			Quantity:  quantity,
+			UnitCost:  itemReq.UnitCost,
+			Total:     itemReq.UnitCost.Mul(quantity),
+		}
+		if variant != nil {
+			item.VariantID = &variant.ID
+			item.VariantName = variant.Name
+		}
+		order.Items = append(order.Items, item)
+		order.Total += item.Total
+	}
+	return nil
+}
+
+// purchaseItemVariant valida a variação de um item de compra: obrigatória em produtos com
+// variações e não aceita nos demais. Variações inativas podem receber mercadoria
+func purchaseItemVariant(db *gorm.DB, product *models.Product, variantID *uint) (*models.ProductVariant, error) {
+	if !product.HasVariants {
+		if variantID != nil {
+			return nil, errors.New("Produto sem variações: " + product.Name)
+		}
+		return nil, nil
+	}
+	if variantID == nil {
+		return nil, errors.New("Informe a variação de: " + product.Name)
+	}
+
+	var variant models.ProductVariant
+	if err := db.Where("product_id = ?", product.ID).First(&variant, *variantID).Error; err != nil {
+		return nil, errors.New("Variação não encontrada para: " + product.Name)
+	}
+	return &variant, nil
+}
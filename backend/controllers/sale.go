@@ -1,31 +1,44 @@
 package controllers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"pdv-backend/config"
+	"pdv-backend/fiscal"
 	"pdv-backend/models"
 )
 
 // GetSales retorna todas as vendas
 func GetSales(c *gin.Context) {
 	var sales []models.Sale
-	query := config.DB.Preload("User").Preload("SaleItems.Product.Category")
+	query := tenantDB(c).Preload("User").Preload("Customer").Preload("SaleItems.Product.Category").Preload("Payments").Preload("FiscalDocument").Preload("Authorizations.RequestedBy").Preload("Authorizations.ApprovedBy")
 
 	// Filtros opcionais
 	if userID := c.Query("user_id"); userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
 
+	if customerID := c.Query("customer_id"); customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+
 	if status := c.Query("status"); status != "" {
 		query = query.Where("status = ?", status)
 	}
 
+	// Vendas com pagamento dividido aparecem em todas as formas utilizadas
 	if paymentType := c.Query("payment_type"); paymentType != "" {
-		query = query.Where("payment_type = ?", paymentType)
+		query = query.Where("id IN (?)", tenantDB(c).Model(&models.SalePayment{}).Select("sale_id").Where("payment_type = ?", paymentType))
 	}
 
 	// Filtro por data
@@ -74,7 +87,7 @@ func GetSale(c *gin.Context) {
 	}
 
 	var sale models.Sale
-	if err := config.DB.Preload("User").Preload("SaleItems.Product.Category").First(&sale, uint(id)).Error; err != nil {
+	if err := tenantDB(c).Preload("User").Preload("Customer").Preload("SaleItems.Product.Category").Preload("Payments").Preload("FiscalDocument").Preload("Authorizations.RequestedBy").Preload("Authorizations.ApprovedBy").First(&sale, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venda não encontrada"})
 		return
 	}
@@ -98,24 +111,88 @@ func CreateSale(c *gin.Context) {
 	}
 
 	// Iniciar transação
-	tx := config.DB.Begin()
+	tx := tenantDB(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
 
-	// Validar produtos e calcular total
-	var total float64
-	var saleItems []models.SaleItem
+	// Verificar se o operador possui um caixa aberto, bloqueando a sessão até o fim da venda
+	// para que o fechamento concorrente aguarde e confira a venda
+	var cashSession models.CashSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND status = ?", userID, models.CashSessionOpen).First(&cashSession).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum caixa aberto para este operador"})
+		return
+	}
 
-	for _, itemReq := range req.Items {
-		var product models.Product
-		if err := tx.First(&product, itemReq.ProductID).Error; err != nil {
+	// Validar cliente identificado na venda
+	if req.CustomerID != nil {
+		var customer models.Customer
+		if err := tx.First(&customer, *req.CustomerID).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + strconv.Itoa(int(itemReq.ProductID))})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cliente não encontrado"})
 			return
 		}
+		if !customer.Active {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cliente inativo: " + customer.Name})
+			return
+		}
+	}
+
+	// Itens informados pelo código de barras (inclusive adicionais, como a caixa)
+	if err := resolveSaleItemBarcodes(tx, userStore(c), req.Items); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar produtos
+	var total models.Money
+	var saleItems []models.SaleItem
+	products := make(map[uint]*models.Product)
+	variants := make(map[uint]*models.ProductVariant)
+	reserved := make(map[stockKey]float64)
+
+	// Na loja do operador valem o saldo e o preço da loja
+	storeID := userStore(c)
+	storeProducts := make(map[uint]*models.StoreProduct)
+	storeReserved := make(map[stockKey]float64)
+	if storeID == nil && hasStores(tx) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Operador sem loja: vincule o usuário a uma loja para registrar vendas"})
+		return
+	}
+
+	// Preços alterados pelo operador, registrados na autorização
+	var priceOverrides []string
+
+	for _, itemReq := range req.Items {
+		product, loaded := products[itemReq.ProductID]
+		if !loaded {
+			locked, err := lockProduct(tx, itemReq.ProductID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + strconv.Itoa(int(itemReq.ProductID))})
+				return
+			}
+			product = &locked
+			products[itemReq.ProductID] = product
+
+			if storeID != nil {
+				storeProduct, err := lockStoreProduct(tx, *storeID, product.ID)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+					return
+				}
+				product.Price = storeProduct.EffectivePrice(product)
+				storeProducts[product.ID] = &storeProduct
+			}
+		}
 
 		if !product.Active {
 			tx.Rollback()
@@ -123,69 +200,166 @@ func CreateSale(c *gin.Context) {
 			return
 		}
 
-		if product.Stock < itemReq.Quantity {
+		// Itens vendidos por unidade não aceitam quantidade fracionada
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque insuficiente para: " + product.Name})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		quantity := models.RoundQuantity(itemReq.Quantity)
 
-		// Calcular total do item
-		itemTotal := product.Price * float64(itemReq.Quantity)
-		total += itemTotal
+		// Produtos com variações vendem a variação, com preço e estoque próprios
+		variant, errMessage := saleItemVariant(tx, product, itemReq.VariantID, variants, true)
+		if errMessage != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+			return
+		}
+
+		// O mesmo produto pode aparecer em mais de um item da venda
+		key := stockKey{ProductID: product.ID}
+		available, price, name := product.Stock, product.Price, product.Name
+		if variant != nil {
+			key.VariantID = variant.ID
+			available, price, name = variant.Stock, variant.EffectivePrice(product), product.Name+" "+variant.Name
+		}
+		reserved[key] = models.RoundQuantity(reserved[key] + quantity)
+		if available < reserved[key] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque insuficiente para: " + name})
+			return
+		}
+		if storeProduct := storeProducts[product.ID]; storeProduct != nil {
+			// Na loja vale o saldo da variação na própria loja
+			inStore := storeProduct.Stock
+			if variant != nil {
+				storeVariant, err := lockStoreVariant(tx, *storeID, variant)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+					return
+				}
+				inStore = storeVariant.Stock
+			}
+			storeReserved[key] = models.RoundQuantity(storeReserved[key] + quantity)
+			if inStore < storeReserved[key] {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque insuficiente na loja para: " + name})
+				return
+			}
+		}
 
 		// Criar item da venda
 		saleItem := models.SaleItem{
 			ProductID: itemReq.ProductID,
-			Quantity:  itemReq.Quantity,
-			UnitPrice: product.Price,
-			Total:     itemTotal,
+			Quantity:  quantity,
+			UnitPrice: price,
+		}
+
+		// Preço alterado pelo operador: guarda o preço de tabela para conferência
+		if itemReq.UnitPrice != nil && *itemReq.UnitPrice != price {
+			if *itemReq.UnitPrice <= 0 {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Preço inválido para: " + name})
+				return
+			}
+			originalPrice := price
+			saleItem.OriginalPrice = &originalPrice
+			saleItem.UnitPrice = *itemReq.UnitPrice
+			priceOverrides = append(priceOverrides, name+": "+price.String()+" -> "+itemReq.UnitPrice.String())
+		}
+		saleItem.Total = saleItem.UnitPrice.Mul(quantity)
+		if itemReq.LabelTotal != nil && saleItem.OriginalPrice == nil {
+			// Etiqueta de preço da balança: cobra o valor impresso, não o recalculado pelo peso
+			saleItem.Total = *itemReq.LabelTotal
+		}
+		if variant != nil {
+			saleItem.VariantID = &variant.ID
+			saleItem.VariantName = variant.Name
+			saleItem.VariantBarcode = variant.BarcodeValue()
 		}
 		saleItems = append(saleItems, saleItem)
+	}
 
-		// Atualizar estoque
-		product.Stock -= itemReq.Quantity
-		if err := tx.Save(&product).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+	var priceApprover *models.User
+	if len(priceOverrides) > 0 {
+		attempt := models.SupervisorAuthorization{
+			Action:        models.AuthorizationPriceOverride,
+			Permission:    models.PermissionSalePriceOverride,
+			CashSessionID: &cashSession.ID,
+		}
+		approver, ok := authorizeAction(c, tx, attempt, "Alteração de preço", req.Authorization)
+		if !ok {
 			return
 		}
+		priceApprover = approver
+	}
+
+	// Aplicar promoções vigentes e calcular total
+	if err := applyPromotions(tx, saleItems, products, time.Now()); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aplicar promoções"})
+		return
+	}
+	for _, saleItem := range saleItems {
+		total += saleItem.Total
 	}
 
 	// Criar venda
 	sale := models.Sale{
-		Total:       total,
-		Discount:    0,
-		Tax:         0,
-		PaymentType: req.PaymentMethod,
-		UserID:      userID.(uint),
-		Status:      "completed",
+		Total:         total,
+		Discount:      0,
+		Tax:           0,
+		UserID:        userID.(uint),
+		StoreID:       storeID,
+		CashSessionID: &cashSession.ID,
+		CustomerID:    req.CustomerID,
+		Status:        "completed",
 	}
 
 	// Processar desconto por porcentagem
 	if req.DiscountPercentage != nil {
-		sale.Discount = (total * (*req.DiscountPercentage)) / 100
+		sale.Discount = total.Percent(*req.DiscountPercentage)
 	} else if req.Discount != nil {
 		sale.Discount = *req.Discount
 	}
-	
+
 	if req.Tax != nil {
 		sale.Tax = *req.Tax
 	}
 
+	// Descontos acima do limite da organização exigem permissão própria ou a autorização
+	// de um supervisor
+	var organization models.Organization
+	if err := tx.First(&organization, c.GetUint("organization_id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar organização"})
+		return
+	}
+	var discountApprover *models.User
+	if sale.Discount > total.Percent(organization.MaxDiscountPercentage) {
+		limit := strconv.FormatFloat(organization.MaxDiscountPercentage, 'f', -1, 64)
+		attempt := models.SupervisorAuthorization{
+			Action:        models.AuthorizationSaleDiscount,
+			Permission:    models.PermissionSaleDiscountAbove10,
+			CashSessionID: &cashSession.ID,
+		}
+		approver, ok := authorizeAction(c, tx, attempt, "Desconto acima de "+limit+"%", req.Authorization)
+		if !ok {
+			return
+		}
+		discountApprover = approver
+	}
+
 	// Calcular total final
 	sale.CalculateTotal()
 
-	// Processar valor recebido e troco (apenas para dinheiro)
-	if req.PaymentMethod == "dinheiro" && req.AmountReceived != nil {
-		amountReceived := *req.AmountReceived
-		if amountReceived < sale.FinalTotal {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Valor recebido insuficiente"})
-			return
-		}
-		sale.AmountReceived = &amountReceived
-		change := amountReceived - sale.FinalTotal
-		sale.Change = &change
+	// Processar formas de pagamento, valor recebido e troco
+	payments, err := buildSalePayments(req, &sale)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := tx.Create(&sale).Error; err != nil {
@@ -194,6 +368,33 @@ func CreateSale(c *gin.Context) {
 		return
 	}
 
+	// Registrar quem aprovou o desconto e os preços alterados
+	authorizations := []struct {
+		approver *models.User
+		record   models.SupervisorAuthorization
+	}{
+		{discountApprover, models.SupervisorAuthorization{
+			Action:     models.AuthorizationSaleDiscount,
+			Permission: models.PermissionSaleDiscountAbove10,
+			Details:    "Desconto de " + sale.Discount.String() + " sobre " + total.String(),
+		}},
+		{priceApprover, models.SupervisorAuthorization{
+			Action:     models.AuthorizationPriceOverride,
+			Permission: models.PermissionSalePriceOverride,
+			Details:    strings.Join(priceOverrides, "; "),
+		}},
+	}
+	for _, authorization := range authorizations {
+		authorization.record.RequestedByID = sale.UserID
+		authorization.record.SaleID = &sale.ID
+		authorization.record.CashSessionID = sale.CashSessionID
+		if err := recordAuthorization(tx, authorization.approver, authorization.record); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar autorização"})
+			return
+		}
+	}
+
 	// Criar itens da venda
 	for i := range saleItems {
 		saleItems[i].SaleID = sale.ID
@@ -202,6 +403,34 @@ func CreateSale(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar itens da venda"})
 			return
 		}
+
+		// Baixar estoque registrando a movimentação
+		change := stockChange{
+			Type:          models.StockMovementSale,
+			Quantity:      -saleItems[i].Quantity,
+			ReferenceType: "sale",
+			ReferenceID:   &sale.ID,
+			UserID:        sale.UserID,
+			StoreID:       sale.StoreID,
+		}
+		if saleItems[i].VariantID != nil {
+			change.Variant = variants[*saleItems[i].VariantID]
+		}
+		if err := applyStockChange(tx, products[saleItems[i].ProductID], change); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+			return
+		}
+	}
+
+	// Registrar pagamentos da venda
+	for i := range payments {
+		payments[i].SaleID = sale.ID
+		if err := tx.Create(&payments[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar pagamentos da venda"})
+			return
+		}
 	}
 
 	// Confirmar transação
@@ -210,8 +439,15 @@ func CreateSale(c *gin.Context) {
 		return
 	}
 
+	// Emitir NFC-e; falhas não desfazem a venda e podem ser reprocessadas depois
+	if fiscal.Default != nil {
+		if _, err := fiscal.Default.EmitForSale(sale.ID); err != nil && !errors.Is(err, fiscal.ErrNotEmitter) {
+			log.Printf("Erro ao emitir NFC-e da venda %d: %v", sale.ID, err)
+		}
+	}
+
 	// Carregar venda completa para resposta
-	config.DB.Preload("User").Preload("SaleItems.Product.Category").First(&sale, sale.ID)
+	tenantDB(c).Preload("User").Preload("Customer").Preload("SaleItems.Product.Category").Preload("Payments").Preload("FiscalDocument").Preload("Authorizations.RequestedBy").Preload("Authorizations.ApprovedBy").First(&sale, sale.ID)
 
 	c.JSON(http.StatusCreated, sale.ToResponse())
 }
@@ -224,8 +460,66 @@ func CancelSale(c *gin.Context) {
 		return
 	}
 
+	// Corpo opcional: a justificativa só é exigida quando a venda tem NFC-e
+	var req models.CancelSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sale models.Sale
+	if err := tenantDB(c).First(&sale, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venda não encontrada"})
+		return
+	}
+
+	if sale.Status == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Venda já está cancelada"})
+		return
+	}
+
+	// Operadores sem a permissão cancelam com a autorização de um supervisor
+	attempt := models.SupervisorAuthorization{
+		Action:        models.AuthorizationSaleCancel,
+		Permission:    models.PermissionSaleCancel,
+		SaleID:        &sale.ID,
+		CashSessionID: sale.CashSessionID,
+	}
+	approver, ok := authorizeAction(c, nil, attempt, "Cancelamento de venda", req.Authorization)
+	if !ok {
+		return
+	}
+
+	// Com NFC-e, a devolução do estoque é validada antes do evento de cancelamento: sem o
+	// evento homologado a venda fica pendente de cancelamento e o estoque não é restaurado
+	fiscalCancel := hasFiscalDocumentToCancel(c, sale.ID)
+	if fiscalCancel {
+		if !restoreSaleStock(c, sale.ID, approver, req.Reason, false) {
+			return
+		}
+		if !cancelSaleFiscalDocument(c, &sale, req.Reason) {
+			return
+		}
+	}
+
+	if !restoreSaleStock(c, sale.ID, approver, req.Reason, true) {
+		// NFC-e já cancelada: a venda fica pendente e uma nova tentativa só devolve o estoque
+		if fiscalCancel {
+			if err := tenantDB(c).Model(&sale).Update("status", "cancel_pending").Error; err != nil {
+				log.Printf("Erro ao marcar venda %d como pendente de cancelamento: %v", sale.ID, err)
+			}
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Venda cancelada com sucesso"})
+}
+
+// restoreSaleStock devolve o estoque da venda e a marca como cancelada. Sem commit, a
+// transação é desfeita após as validações. Retorna false se a resposta de erro já foi enviada
+func restoreSaleStock(c *gin.Context, saleID uint, approver *models.User, reason string, commit bool) bool {
 	// Iniciar transação
-	tx := config.DB.Begin()
+	tx := tenantDB(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -234,32 +528,70 @@ func CancelSale(c *gin.Context) {
 
 	// Buscar venda
 	var sale models.Sale
-	if err := tx.Preload("SaleItems").First(&sale, uint(id)).Error; err != nil {
+	if err := tx.Preload("SaleItems").First(&sale, saleID).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venda não encontrada"})
-		return
+		return false
 	}
 
 	if sale.Status == "cancelled" {
 		tx.Rollback()
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Venda já está cancelada"})
-		return
+		return false
+	}
+
+	// Quantidades consumidas dos lotes pela venda, para devolver ao lote de origem
+	consumed, err := batchConsumption(tx, "sale", sale.ID, models.StockMovementSale)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lotes da venda"})
+		return false
 	}
 
 	// Restaurar estoque dos produtos
 	for _, item := range sale.SaleItems {
-		var product models.Product
-		if err := tx.First(&product, item.ProductID).Error; err != nil {
+		product, err := lockProduct(tx, item.ProductID)
+		if err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
-			return
+			return false
+		}
+
+		change := stockChange{
+			Type:          models.StockMovementCancellation,
+			Quantity:      item.Quantity,
+			ReferenceType: "sale",
+			ReferenceID:   &sale.ID,
+			UserID:        c.GetUint("user_id"),
+			StoreID:       sale.StoreID,
+		}
+
+		// Itens vendidos por variação devolvem o estoque à própria variação
+		if item.VariantID == nil && product.HasVariants {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produto " + product.Name + " passou a ter variações: não é possível devolver o estoque desta venda"})
+			return false
+		}
+		if item.VariantID != nil {
+			variant, err := lockVariant(tx, product.ID, *item.VariantID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar variação do produto"})
+				return false
+			}
+			change.Variant = &variant
+		}
+
+		key := stockKey{ProductID: product.ID}
+		if item.VariantID != nil {
+			key.VariantID = *item.VariantID
 		}
+		change.Restore = takeBatchConsumption(consumed, key, item.Quantity)
 
-		product.Stock += item.Quantity
-		if err := tx.Save(&product).Error; err != nil {
+		if err := applyStockChange(tx, &product, change); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao restaurar estoque"})
-			return
+			return false
 		}
 	}
 
@@ -268,31 +600,50 @@ func CancelSale(c *gin.Context) {
 	if err := tx.Save(&sale).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar venda"})
-		return
+		return false
+	}
+
+	authorization := models.SupervisorAuthorization{
+		Action:        models.AuthorizationSaleCancel,
+		Permission:    models.PermissionSaleCancel,
+		RequestedByID: c.GetUint("user_id"),
+		SaleID:        &sale.ID,
+		CashSessionID: sale.CashSessionID,
+		Details:       reason,
+	}
+	if err := recordAuthorization(tx, approver, authorization); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar autorização"})
+		return false
+	}
+
+	if !commit {
+		tx.Rollback()
+		return true
 	}
 
 	// Confirmar transação
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar cancelamento"})
-		return
+		return false
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Venda cancelada com sucesso"})
+	return true
 }
 
 // GetSalesReport retorna relatório de vendas
 func GetSalesReport(c *gin.Context) {
 	type SalesReport struct {
-		TotalSales     int64   `json:"total_sales"`
-		TotalRevenue   float64 `json:"total_revenue"`
-		AverageTicket  float64 `json:"average_ticket"`
-		CancelledSales int64   `json:"cancelled_sales"`
+		TotalSales     int64                     `json:"total_sales"`
+		TotalRevenue   models.Money              `json:"total_revenue"`
+		AverageTicket  models.Money              `json:"average_ticket"`
+		CancelledSales int64                     `json:"cancelled_sales"`
+		Payments       []models.PaymentBreakdown `json:"payments"`
 	}
 
 	var report SalesReport
 
 	// Filtros de data
-	query := config.DB.Model(&models.Sale{})
+	query := tenantDB(c).Model(&models.Sale{})
 	if startDate := c.Query("start_date"); startDate != "" {
 		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
 			query = query.Where("created_at >= ?", parsedDate)
@@ -306,19 +657,200 @@ func GetSalesReport(c *gin.Context) {
 		}
 	}
 
+	// Reutilizar os filtros de data em cada consulta do relatório
+	query = query.Session(&gorm.Session{})
+
 	// Total de vendas completadas
-	query.Where("status = ?", "completed").Count(&report.TotalSales)
+	query.Where("status IN ?", models.CompletedSaleStatuses).Count(&report.TotalSales)
 
 	// Receita total
-	query.Where("status = ?", "completed").Select("COALESCE(SUM(final_total), 0)").Scan(&report.TotalRevenue)
+	query.Where("status IN ?", models.CompletedSaleStatuses).Select("COALESCE(SUM(final_total), 0)").Scan(&report.TotalRevenue)
 
 	// Ticket médio
 	if report.TotalSales > 0 {
-		report.AverageTicket = report.TotalRevenue / float64(report.TotalSales)
+		report.AverageTicket = report.TotalRevenue.Div(report.TotalSales)
 	}
 
 	// Vendas canceladas
 	query.Where("status = ?", "cancelled").Count(&report.CancelledSales)
 
+	// Faturamento por forma de pagamento
+	payments, err := paymentBreakdown(query.Where("status IN ?", models.CompletedSaleStatuses))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar relatório de vendas"})
+		return
+	}
+	report.Payments = payments
+
 	c.JSON(http.StatusOK, report)
-}
\ No newline at end of file
+}
+
+// buildSalePayments valida as formas de pagamento da venda e calcula o troco,
+// que só pode ser devolvido sobre a parte paga em dinheiro
+func buildSalePayments(req models.SaleRequest, sale *models.Sale) ([]models.SalePayment, error) {
+	requests := req.Payments
+
+	// Compatibilidade com o formato de pagamento único
+	if len(requests) == 0 {
+		amount := sale.FinalTotal
+		if req.PaymentMethod == "dinheiro" && req.AmountReceived != nil {
+			amount = *req.AmountReceived
+		}
+		requests = []models.SalePaymentRequest{{PaymentMethod: req.PaymentMethod, Amount: amount}}
+	}
+
+	var totalPaid, cashPaid models.Money
+	methods := make(map[string]bool)
+	for _, payment := range requests {
+		totalPaid += payment.Amount
+		if payment.PaymentMethod == "dinheiro" {
+			cashPaid += payment.Amount
+		}
+		methods[payment.PaymentMethod] = true
+	}
+
+	if totalPaid < sale.FinalTotal {
+		if len(req.Payments) == 0 && req.PaymentMethod == "dinheiro" {
+			return nil, errors.New("Valor recebido insuficiente")
+		}
+		return nil, errors.New("Valor pago insuficiente para cobrir o total da venda")
+	}
+
+	change := totalPaid - sale.FinalTotal
+	if change > cashPaid {
+		return nil, errors.New("Pagamentos em cartão ou PIX não podem exceder o valor da venda")
+	}
+
+	// Distribuir o troco entre os pagamentos em dinheiro
+	remainingChange := change
+	payments := make([]models.SalePayment, len(requests))
+	for i, payment := range requests {
+		payments[i] = models.SalePayment{
+			PaymentType:    payment.PaymentMethod,
+			Amount:         payment.Amount,
+			AmountReceived: payment.Amount,
+		}
+		if payment.PaymentMethod == "dinheiro" && remainingChange > 0 {
+			paymentChange := remainingChange
+			if paymentChange > payment.Amount {
+				paymentChange = payment.Amount
+			}
+			payments[i].Change = paymentChange
+			payments[i].Amount = payment.Amount - paymentChange
+			remainingChange -= paymentChange
+		}
+	}
+
+	if len(methods) == 1 {
+		sale.PaymentType = requests[0].PaymentMethod
+	} else {
+		sale.PaymentType = "misto"
+	}
+
+	// Valor recebido e troco da venda consideram apenas o dinheiro
+	if cashPaid > 0 && (len(req.Payments) > 0 || req.AmountReceived != nil) {
+		sale.AmountReceived = &cashPaid
+		sale.Change = &change
+	}
+
+	return payments, nil
+}
+
+// paymentBreakdown soma o faturamento por forma de pagamento das vendas filtradas pela consulta
+func paymentBreakdown(salesQuery *gorm.DB) ([]models.PaymentBreakdown, error) {
+	var breakdown []models.PaymentBreakdown
+	err := config.DB.Model(&models.SalePayment{}).
+		Select("payment_type, COUNT(DISTINCT sale_id) as sales, COALESCE(SUM(amount), 0) as total").
+		Where("sale_id IN (?)", salesQuery.Session(&gorm.Session{}).Select("id")).
+		Group("payment_type").
+		Order("total DESC").
+		Scan(&breakdown).Error
+	return breakdown, err
+}
+
+// stockKey identifica o saldo reservado pelos itens da venda: do produto ou da variação
+type stockKey struct {
+	ProductID uint
+	VariantID uint
+}
+
+// saleItemVariant resolve a variação vendida no item, exigida apenas para produtos com
+// variações, reaproveitando as já carregadas. Retorna a mensagem de erro de validação, se houver
+func saleItemVariant(db *gorm.DB, product *models.Product, variantID *uint, variants map[uint]*models.ProductVariant, lock bool) (*models.ProductVariant, string) {
+	if !product.HasVariants {
+		if variantID != nil {
+			return nil, "Produto sem variações: " + product.Name
+		}
+		return nil, ""
+	}
+	if variantID == nil {
+		return nil, "Informe a variação de: " + product.Name
+	}
+
+	variant, loaded := variants[*variantID]
+	if !loaded {
+		var found models.ProductVariant
+		var err error
+		if lock {
+			found, err = lockVariant(db, product.ID, *variantID)
+		} else {
+			err = db.Where("product_id = ?", product.ID).First(&found, *variantID).Error
+		}
+		if err != nil {
+			return nil, "Variação não encontrada para: " + product.Name
+		}
+		variant = &found
+		variants[*variantID] = variant
+	}
+
+	if !variant.Active {
+		return nil, "Variação inativa: " + product.Name + " " + variant.Name
+	}
+	return variant, ""
+}
+
+// batchConsumption retorna as saídas de lotes registradas pelas movimentações do tipo
+// informado de um documento (ex.: venda), agrupadas pelo produto (ou variação) e na ordem
+// em que foram consumidas
+func batchConsumption(tx *gorm.DB, referenceType string, referenceID uint, movementType string) (map[stockKey][]models.ProductBatchMovement, error) {
+	var movements []models.ProductBatchMovement
+	err := tx.Preload("Batch").
+		Where("stock_movement_id IN (?)", tx.Model(&models.StockMovement{}).Select("id").
+			Where("reference_type = ? AND reference_id = ? AND type = ?", referenceType, referenceID, movementType)).
+		Order("id ASC").
+		Find(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	consumed := make(map[stockKey][]models.ProductBatchMovement)
+	for _, movement := range movements {
+		key := stockKey{ProductID: movement.Batch.ProductID}
+		if movement.Batch.VariantID != nil {
+			key.VariantID = *movement.Batch.VariantID
+		}
+		consumed[key] = append(consumed[key], movement)
+	}
+	return consumed, nil
+}
+
+// takeBatchConsumption retira das saídas de lotes do produto as quantidades a devolver ao
+// cancelar (ou receber) um item, até a quantidade do item (o restante volta como estoque sem lote)
+func takeBatchConsumption(consumed map[stockKey][]models.ProductBatchMovement, key stockKey, quantity float64) []models.ProductBatchMovement {
+	var restore []models.ProductBatchMovement
+	pending := consumed[key]
+	for len(pending) > 0 && quantity > 0 {
+		available := -pending[0].Quantity
+		taken := math.Min(available, quantity)
+		restore = append(restore, models.ProductBatchMovement{BatchID: pending[0].BatchID, Quantity: taken})
+		quantity = models.RoundQuantity(quantity - taken)
+
+		if taken < available {
+			pending[0].Quantity = -models.RoundQuantity(available - taken)
+		} else {
+			pending = pending[1:]
+		}
+	}
+	consumed[key] = pending
+	return restore
+}
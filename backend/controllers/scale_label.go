@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+	"pdv-backend/scale"
+)
+
+// GetScaleLabelLayouts retorna os formatos de etiqueta de balança configurados
+func GetScaleLabelLayouts(c *gin.Context) {
+	var layouts []models.ScaleLabelLayout
+	query := tenantDB(c)
+
+	// Filtro por status ativo
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	if err := query.Order("name ASC").Find(&layouts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar formatos de etiqueta"})
+		return
+	}
+
+	responses := make([]models.ScaleLabelLayoutResponse, len(layouts))
+	for i := range layouts {
+		responses[i] = layouts[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateScaleLabelLayout cadastra um formato de etiqueta de balança
+func CreateScaleLabelLayout(c *gin.Context) {
+	var req models.ScaleLabelLayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layout := models.ScaleLabelLayout{Active: true}
+	fillScaleLabelLayout(&layout, req)
+	if err := layout.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&layout).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cadastrar formato de etiqueta"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, layout.ToResponse())
+}
+
+// UpdateScaleLabelLayout atualiza um formato de etiqueta de balança
+func UpdateScaleLabelLayout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ScaleLabelLayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var layout models.ScaleLabelLayout
+	if err := tenantDB(c).First(&layout, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Formato de etiqueta não encontrado"})
+		return
+	}
+
+	fillScaleLabelLayout(&layout, req)
+	if err := layout.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Save(&layout).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar formato de etiqueta"})
+		return
+	}
+
+	c.JSON(http.StatusOK, layout.ToResponse())
+}
+
+// DeleteScaleLabelLayout exclui um formato de etiqueta de balança
+func DeleteScaleLabelLayout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var layout models.ScaleLabelLayout
+	if err := tenantDB(c).First(&layout, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Formato de etiqueta não encontrado"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&layout).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir formato de etiqueta"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Formato de etiqueta excluído com sucesso"})
+}
+
+// fillScaleLabelLayout copia os dados da requisição para o formato de etiqueta
+func fillScaleLabelLayout(layout *models.ScaleLabelLayout, req models.ScaleLabelLayoutRequest) {
+	layout.Name = req.Name
+	layout.Prefix = req.Prefix
+	layout.CodeStart = req.CodeStart
+	layout.CodeLength = req.CodeLength
+	layout.ValueStart = req.ValueStart
+	layout.ValueLength = req.ValueLength
+	layout.ValueType = req.ValueType
+
+	// Padrões: preço em centavos e peso em gramas
+	layout.ValueDecimals = 2
+	if req.ValueType == models.ScaleValueWeight {
+		layout.ValueDecimals = 3
+	}
+	if req.ValueDecimals != nil {
+		layout.ValueDecimals = *req.ValueDecimals
+	}
+	if req.Active != nil {
+		layout.Active = *req.Active
+	}
+}
+
+// ExportScaleItems gera o arquivo de carga das balanças (Toledo MGV ou Filizola) com os
+// produtos ativos vendidos por kg que possuem código de balança (PLU)
+func ExportScaleItems(c *gin.Context) {
+	format := c.DefaultQuery("format", scale.FormatToledo)
+
+	var products []models.Product
+	if err := tenantDB(c).Where("active = ? AND unit = ? AND plu != ?", true, "kg", "").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos"})
+		return
+	}
+
+	items := make([]scale.Item, 0, len(products))
+	for _, product := range products {
+		code, err := strconv.Atoi(product.PLU)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Código de balança inválido para " + product.Name})
+			return
+		}
+		items = append(items, scale.Item{
+			Code:          code,
+			Name:          product.Name,
+			Price:         product.Price,
+			Weighed:       true,
+			ShelfLifeDays: product.ShelfLifeDays,
+			Tare:          product.Tare,
+		})
+	}
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].Code < items[b].Code
+	})
+
+	// Montar o arquivo antes de responder, para que erros de validação retornem 400
+	var buffer bytes.Buffer
+	if err := scale.Write(&buffer, format, items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="balanca-%s.zip"`, format))
+	c.Data(http.StatusOK, "application/zip", buffer.Bytes())
+}
+
+// parseScaleLabel interpreta o código como etiqueta de balança nos formatos ativos (prefixos
+// mais longos primeiro) e busca o produto pelo PLU embutido
+func parseScaleLabel(db *gorm.DB, code string) (models.ScaleLabel, models.Product, error) {
+	var product models.Product
+
+	var layouts []models.ScaleLabelLayout
+	if err := db.Where("active = ?", true).Order("id ASC").Find(&layouts).Error; err != nil {
+		return models.ScaleLabel{}, product, err
+	}
+	sort.SliceStable(layouts, func(a, b int) bool {
+		return len(layouts[a].Prefix) > len(layouts[b].Prefix)
+	})
+
+	for i := range layouts {
+		label, ok := layouts[i].Parse(code)
+		if !ok {
+			continue
+		}
+		if err := db.Preload("Category").Where("plu = ?", label.PLU).First(&product).Error; err != nil {
+			continue
+		}
+		if label.Quantity(&product) <= 0 {
+			continue
+		}
+		return label, product, nil
+	}
+	return models.ScaleLabel{}, product, gorm.ErrRecordNotFound
+}
+
+// pluInUse verifica se o código de balança já pertence a outro produto
+func pluInUse(db *gorm.DB, plu string, productID uint) bool {
+	var count int64
+	db.Model(&models.Product{}).Where("plu = ? AND id != ?", plu, productID).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+)
+
+// stockChange descreve uma alteração de estoque a ser registrada no histórico
+type stockChange struct {
+	Type          string
+	Quantity      float64 // variação do saldo (negativa para saídas)
+	ReferenceType string
+	ReferenceID   *uint
+	UserID        uint
+	Notes         string
+
+	// Variant é a variação movimentada; obrigatória para produtos com variações, cujo saldo
+	// é a soma do saldo das variações
+	Variant *models.ProductVariant
+
+	// Batch é o lote movimentado, da loja movimentada. Sem lote, as saídas consomem primeiro
+	// os lotes da loja que vencem antes (FEFO) e as entradas ficam sem lote
+	Batch *models.ProductBatch
+
+	// Restore devolve aos lotes de origem as quantidades informadas (cancelamento de venda);
+	// lotes de outra loja (transferência) são recebidos no lote de mesmo número da loja
+	Restore []models.ProductBatchMovement
+
+	// StoreID é a loja movimentada. Sem loja apenas o estoque consolidado é alterado
+	StoreID *uint
+}
+
+// lockProduct carrega o produto bloqueando a linha até o fim da transação
+func lockProduct(tx *gorm.DB, productID uint) (models.Product, error) {
+	var product models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error
+	return product, err
+}
+
+// lockVariant carrega a variação do produto bloqueando a linha até o fim da transação
+func lockVariant(tx *gorm.DB, productID, variantID uint) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ?", productID).First(&variant, variantID).Error
+	return variant, err
+}
+
+// lockBatch carrega o lote bloqueando a linha até o fim da transação
+func lockBatch(tx *gorm.DB, batchID uint) (models.ProductBatch, error) {
+	var batch models.ProductBatch
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, batchID).Error
+	return batch, err
+}
+
+// lockStoreProduct carrega o produto na loja bloqueando a linha até o fim da transação,
+// criando-o com saldo zero se ainda não existir
+func lockStoreProduct(tx *gorm.DB, storeID, productID uint) (models.StoreProduct, error) {
+	storeProduct := models.StoreProduct{StoreID: storeID, ProductID: productID}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(models.StoreProduct{StoreID: storeID, ProductID: productID}).
+		FirstOrCreate(&storeProduct).Error
+	return storeProduct, err
+}
+
+// lockStoreVariant carrega a variação na loja bloqueando a linha até o fim da transação,
+// criando-a com saldo zero se ainda não existir
+func lockStoreVariant(tx *gorm.DB, storeID uint, variant *models.ProductVariant) (models.StoreVariant, error) {
+	storeVariant := models.StoreVariant{StoreID: storeID, VariantID: variant.ID, ProductID: variant.ProductID}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(models.StoreVariant{StoreID: storeID, VariantID: variant.ID}).
+		FirstOrCreate(&storeVariant).Error
+	return storeVariant, err
+}
+
+// storeStock retorna o saldo do produto (ou da variação, se informada) na loja, bloqueando-o
+// até o fim da transação. Sem loja retorna o estoque consolidado
+func storeStock(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, storeID *uint) (float64, error) {
+	switch {
+	case storeID == nil && variant != nil:
+		return variant.Stock, nil
+	case storeID == nil:
+		return product.Stock, nil
+	case variant != nil:
+		storeVariant, err := lockStoreVariant(tx, *storeID, variant)
+		return storeVariant.Stock, err
+	}
+	storeProduct, err := lockStoreProduct(tx, *storeID, product.ID)
+	return storeProduct.Stock, err
+}
+
+// applyStockChange altera o saldo do produto (e da variação, da loja e dos lotes, se houver) e
+// grava a movimentação na mesma transação
+func applyStockChange(tx *gorm.DB, product *models.Product, change stockChange) error {
+	if product.HasVariants && change.Variant == nil {
+		return models.ErrVariantRequired
+	}
+
+	before := product.Stock
+	after := models.RoundQuantity(before + change.Quantity)
+
+	if change.Variant != nil {
+		variantStock := models.RoundQuantity(change.Variant.Stock + change.Quantity)
+		if err := tx.Model(change.Variant).Update("stock", variantStock).Error; err != nil {
+			return err
+		}
+		change.Variant.Stock = variantStock
+	}
+
+	if err := tx.Model(product).Update("stock", after).Error; err != nil {
+		return err
+	}
+	product.Stock = after
+
+	if change.StoreID != nil {
+		storeProduct, err := lockStoreProduct(tx, *change.StoreID, product.ID)
+		if err != nil {
+			return err
+		}
+		storeStock := models.RoundQuantity(storeProduct.Stock + change.Quantity)
+		if err := tx.Model(&storeProduct).Update("stock", storeStock).Error; err != nil {
+			return err
+		}
+
+		if change.Variant != nil {
+			storeVariant, err := lockStoreVariant(tx, *change.StoreID, change.Variant)
+			if err != nil {
+				return err
+			}
+			variantStock := models.RoundQuantity(storeVariant.Stock + change.Quantity)
+			if err := tx.Model(&storeVariant).Update("stock", variantStock).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	movement := models.StockMovement{
+		ProductID:     product.ID,
+		StoreID:       change.StoreID,
+		Type:          change.Type,
+		Quantity:      models.RoundQuantity(change.Quantity),
+		StockBefore:   before,
+		StockAfter:    after,
+		ReferenceType: change.ReferenceType,
+		ReferenceID:   change.ReferenceID,
+		Notes:         change.Notes,
+	}
+	if change.UserID != 0 {
+		movement.UserID = &change.UserID
+	}
+	if change.Variant != nil {
+		movement.VariantID = &change.Variant.ID
+	}
+
+	if err := tx.Create(&movement).Error; err != nil {
+		return err
+	}
+	return applyBatchChange(tx, &movement, change)
+}
+
+// applyBatchChange atribui a movimentação aos lotes: ao lote informado, aos lotes de origem
+// (devolução) ou, nas saídas sem lote, aos lotes da loja com saldo por ordem de validade (FEFO)
+func applyBatchChange(tx *gorm.DB, movement *models.StockMovement, change stockChange) error {
+	var allocations []models.ProductBatchMovement
+	switch {
+	case change.Batch != nil:
+		if !change.Batch.InStore(movement.StoreID) {
+			return models.ErrBatchStore
+		}
+		allocations = append(allocations, models.ProductBatchMovement{BatchID: change.Batch.ID, Quantity: movement.Quantity})
+	case len(change.Restore) > 0:
+		for _, restore := range change.Restore {
+			batchID, err := restoreBatch(tx, restore.BatchID, movement.StoreID)
+			if err != nil {
+				return err
+			}
+			allocations = append(allocations, models.ProductBatchMovement{BatchID: batchID, Quantity: restore.Quantity})
+		}
+	case movement.Quantity < 0:
+		var batches []models.ProductBatch
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND quantity > 0", movement.ProductID)
+		if movement.VariantID != nil {
+			query = query.Where("variant_id = ?", *movement.VariantID)
+		}
+		if movement.StoreID != nil {
+			query = query.Where("store_id = ? OR store_id IS NULL", *movement.StoreID)
+		}
+		if err := query.Order("expiry_date ASC, id ASC").Find(&batches).Error; err != nil {
+			return err
+		}
+
+		remaining := -movement.Quantity
+		for _, batch := range batches {
+			if remaining <= 0 {
+				break
+			}
+			quantity := math.Min(batch.Quantity, remaining)
+			allocations = append(allocations, models.ProductBatchMovement{BatchID: batch.ID, Quantity: -quantity})
+			remaining = models.RoundQuantity(remaining - quantity)
+		}
+	}
+
+	for _, allocation := range allocations {
+		batch, err := lockBatch(tx, allocation.BatchID)
+		if err != nil {
+			return err
+		}
+		quantity := models.RoundQuantity(batch.Quantity + allocation.Quantity)
+		if quantity < 0 {
+			return models.ErrBatchQuantity
+		}
+		if err := tx.Model(&batch).Update("quantity", quantity).Error; err != nil {
+			return err
+		}
+		if change.Batch != nil {
+			change.Batch.Quantity = quantity
+		}
+
+		record := models.ProductBatchMovement{
+			BatchID:         batch.ID,
+			StockMovementID: movement.ID,
+			StoreID:         movement.StoreID,
+			Quantity:        models.RoundQuantity(allocation.Quantity),
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBatch retorna o lote que recebe na loja a devolução ao lote de origem: o próprio
+// lote ou, se ele for de outra loja (recebimento de transferência), o lote de mesmo número
+// e validade na loja, cadastrado com saldo zero se ainda não existir
+func restoreBatch(tx *gorm.DB, batchID uint, storeID *uint) (uint, error) {
+	origin, err := lockBatch(tx, batchID)
+	if err != nil {
+		return 0, err
+	}
+	if origin.InStore(storeID) {
+		return origin.ID, nil
+	}
+
+	var batch models.ProductBatch
+	query := tx.Where("product_id = ? AND store_id = ? AND code = ?", origin.ProductID, *storeID, origin.Code)
+	if origin.VariantID != nil {
+		query = query.Where("variant_id = ?", *origin.VariantID)
+	}
+	err = query.First(&batch).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		batch = models.ProductBatch{
+			OrganizationID: origin.OrganizationID,
+			ProductID:      origin.ProductID,
+			VariantID:      origin.VariantID,
+			StoreID:        storeID,
+			Code:           origin.Code,
+			ExpiryDate:     origin.ExpiryDate,
+		}
+		err = tx.Create(&batch).Error
+	}
+	return batch.ID, err
+}
+
+// GetProductMovements retorna o histórico de movimentações de estoque de um produto
+func GetProductMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var movements []models.StockMovement
+	query := tenantDB(c).Preload("User").Where("product_id = ?", product.ID)
+
+	// Filtros opcionais
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	if storeID := c.Query("store_id"); storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("created_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("created_at <= ?", endOfDay)
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar movimentações de estoque"})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// GetStockPosition reconstrói o saldo de estoque dos produtos ao final de uma data,
+// desfazendo a partir do saldo atual as movimentações posteriores
+func GetStockPosition(c *gin.Context) {
+	date := c.Query("date")
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data inválida, use o formato AAAA-MM-DD"})
+		return
+	}
+	endOfDay := parsedDate.Add(24*time.Hour - time.Nanosecond)
+
+	var products []models.Product
+	query := tenantDB(c).Order("name ASC")
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("id = ?", productID)
+	}
+
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produtos"})
+		return
+	}
+
+	type movementSum struct {
+		ProductID uint
+		Total     float64
+	}
+
+	var sums []movementSum
+	if err := tenantDB(c).Model(&models.StockMovement{}).
+		Select("product_id, COALESCE(SUM(quantity), 0) as total").
+		Where("created_at > ?", endOfDay).
+		Group("product_id").
+		Scan(&sums).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular posição de estoque"})
+		return
+	}
+
+	after := make(map[uint]float64, len(sums))
+	for _, sum := range sums {
+		after[sum.ProductID] = sum.Total
+	}
+
+	positions := make([]models.StockPosition, 0, len(products))
+	for _, product := range products {
+		// Produtos cadastrados depois da data não possuíam estoque
+		if product.CreatedAt.After(endOfDay) {
+			continue
+		}
+		positions = append(positions, models.StockPosition{
+			ProductID:    product.ID,
+			ProductName:  product.Name,
+			Barcode:      product.Barcode,
+			CurrentStock: product.Stock,
+			Stock:        models.RoundQuantity(product.Stock - after[product.ID]),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"date": date, "products": positions})
+}
@@ -0,0 +1,582 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+)
+
+// GetStockTransfers retorna as transferências entre lojas
+func GetStockTransfers(c *gin.Context) {
+	var transfers []models.StockTransfer
+	query := preloadStockTransfer(tenantDB(c))
+
+	// Filtros opcionais
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if originID := c.Query("origin_store_id"); originID != "" {
+		query = query.Where("origin_store_id = ?", originID)
+	}
+
+	if destinationID := c.Query("destination_store_id"); destinationID != "" {
+		query = query.Where("destination_store_id = ?", destinationID)
+	}
+
+	// Transferências enviadas ou recebidas pela loja
+	if storeID := c.Query("store_id"); storeID != "" {
+		query = query.Where("origin_store_id = ? OR destination_store_id = ?", storeID, storeID)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("created_at >= ?", parsedDate)
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			endOfDay := parsedDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			query = query.Where("created_at <= ?", endOfDay)
+		}
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar transferências"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.StockTransferResponse, len(transfers))
+	for i, transfer := range transfers {
+		responses[i] = transfer.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetStockTransfer retorna uma transferência específica
+func GetStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var transfer models.StockTransfer
+	if err := preloadStockTransfer(tenantDB(c)).First(&transfer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferência não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer.ToResponse())
+}
+
+// GetStockInTransit retorna as quantidades enviadas e ainda não recebidas, por produto (ou
+// variação) e loja de destino
+func GetStockInTransit(c *gin.Context) {
+	shipped := tenantDB(c).Model(&models.StockTransfer{}).Select("id").Where("status = ?", models.StockTransferShipped)
+
+	// Filtros opcionais
+	if storeID := c.Query("store_id"); storeID != "" {
+		shipped = shipped.Where("destination_store_id = ?", storeID)
+	}
+	if originID := c.Query("origin_store_id"); originID != "" {
+		shipped = shipped.Where("origin_store_id = ?", originID)
+	}
+
+	query := tenantDB(c).Model(&models.StockTransferItem{}).
+		Select("stock_transfer_items.product_id, products.name as product_name, "+
+			"stock_transfer_items.variant_id, COALESCE(product_variants.name, '') as variant_name, "+
+			"stock_transfers.destination_store_id, stores.name as destination_store_name, "+
+			"COALESCE(SUM(stock_transfer_items.quantity), 0) as quantity, "+
+			"COUNT(DISTINCT stock_transfers.id) as transfers").
+		Joins("JOIN stock_transfers ON stock_transfers.id = stock_transfer_items.stock_transfer_id").
+		Joins("JOIN products ON products.id = stock_transfer_items.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = stock_transfer_items.variant_id").
+		Joins("JOIN stores ON stores.id = stock_transfers.destination_store_id").
+		Where("stock_transfer_items.stock_transfer_id IN (?)", shipped)
+
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("stock_transfer_items.product_id = ?", productID)
+	}
+
+	rows := []models.StockInTransit{}
+	if err := query.
+		Group("stock_transfer_items.product_id, products.name, stock_transfer_items.variant_id, product_variants.name, stock_transfers.destination_store_id, stores.name").
+		Order("products.name ASC, stores.name ASC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque em trânsito"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+// CreateStockTransfer cria uma transferência entre lojas em rascunho
+func CreateStockTransfer(c *gin.Context) {
+	var req models.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer := models.StockTransfer{
+		Status: models.StockTransferDraft,
+		UserID: c.GetUint("user_id"),
+	}
+	if err := fillStockTransfer(tenantDB(c), &transfer, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar transferência"})
+		return
+	}
+
+	preloadStockTransfer(tenantDB(c)).First(&transfer, transfer.ID)
+	c.JSON(http.StatusCreated, transfer.ToResponse())
+}
+
+// UpdateStockTransfer atualiza uma transferência em rascunho, substituindo os itens
+func UpdateStockTransfer(c *gin.Context) {
+	var req models.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, ok := findStockTransfer(c)
+	if !ok {
+		return
+	}
+
+	if transfer.Status != models.StockTransferDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas transferências em rascunho podem ser alteradas"})
+		return
+	}
+
+	if err := fillStockTransfer(tenantDB(c), &transfer, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("stock_transfer_id = ?", transfer.ID).Delete(&models.StockTransferItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&transfer).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar transferência"})
+		return
+	}
+
+	preloadStockTransfer(tenantDB(c)).First(&transfer, transfer.ID)
+	c.JSON(http.StatusOK, transfer.ToResponse())
+}
+
+// CancelStockTransfer cancela uma transferência ainda não enviada
+func CancelStockTransfer(c *gin.Context) {
+	transfer, ok := findStockTransfer(c)
+	if !ok {
+		return
+	}
+
+	if transfer.Status != models.StockTransferDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas transferências em rascunho podem ser canceladas"})
+		return
+	}
+
+	if err := tenantDB(c).Model(&transfer).Update("status", models.StockTransferCancelled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar transferência"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transferência cancelada com sucesso"})
+}
+
+// ShipStockTransfer registra o envio da transferência: baixa o estoque da loja de origem
+// (e o consolidado) e os lotes da origem, deixando as quantidades em trânsito até o recebimento
+func ShipStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var transfer models.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("OriginStore").Preload("DestinationStore").Preload("Items").First(&transfer, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferência não encontrada"})
+		return
+	}
+
+	if transfer.Status != models.StockTransferDraft {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas transferências em rascunho podem ser enviadas"})
+		return
+	}
+
+	notes := fmt.Sprintf("Transferência #%d - %s para %s", transfer.ID, transfer.OriginStore.Name, transfer.DestinationStore.Name)
+	products := make(map[uint]*models.Product)
+	reserved := make(map[stockKey]float64)
+	storeReserved := make(map[stockKey]float64)
+
+	for _, item := range transfer.Items {
+		product, loaded := products[item.ProductID]
+		if !loaded {
+			locked, err := lockProduct(tx, item.ProductID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Produto não encontrado: " + strconv.Itoa(int(item.ProductID))})
+				return
+			}
+			product = &locked
+			products[item.ProductID] = product
+		}
+
+		key := stockKey{ProductID: product.ID}
+		available, name := product.Stock, product.Name
+		var variant *models.ProductVariant
+		if item.VariantID != nil {
+			locked, err := lockVariant(tx, product.ID, *item.VariantID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Variação não encontrada: " + product.Name})
+				return
+			}
+			variant = &locked
+			key.VariantID = variant.ID
+			available, name = variant.Stock, product.Name+" "+variant.Name
+		}
+
+		reserved[key] = models.RoundQuantity(reserved[key] + item.Quantity)
+		if available < reserved[key] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque insuficiente para: " + name})
+			return
+		}
+
+		// Na loja de origem vale o saldo da loja (da variação, se houver)
+		inStore, err := storeStock(tx, product, variant, &transfer.OriginStoreID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+			return
+		}
+		storeReserved[key] = models.RoundQuantity(storeReserved[key] + item.Quantity)
+		if inStore < storeReserved[key] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estoque insuficiente na loja de origem para: " + name})
+			return
+		}
+
+		if err := applyStockChange(tx, product, stockChange{
+			Type:          models.StockMovementTransferOut,
+			Quantity:      -item.Quantity,
+			ReferenceType: "stock_transfer",
+			ReferenceID:   &transfer.ID,
+			UserID:        c.GetUint("user_id"),
+			Notes:         notes,
+			Variant:       variant,
+			StoreID:       &transfer.OriginStoreID,
+		}); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+			return
+		}
+	}
+
+	userID := c.GetUint("user_id")
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":        models.StockTransferShipped,
+		"shipped_at":    time.Now(),
+		"shipped_by_id": userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao enviar transferência"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar envio"})
+		return
+	}
+
+	preloadStockTransfer(tenantDB(c)).First(&transfer, transfer.ID)
+	c.JSON(http.StatusOK, transfer.ToResponse())
+}
+
+// ReceiveStockTransfer registra a conferência do recebimento: dá entrada na loja de destino
+// das quantidades recebidas, nos lotes de mesmo número e validade dos lotes enviados, e
+// grava as divergências
+func ReceiveStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	// Corpo opcional: sem ele, recebe todas as quantidades enviadas
+	var req models.ReceiveStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Iniciar transação
+	tx := tenantDB(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var transfer models.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("OriginStore").Preload("DestinationStore").Preload("Items").First(&transfer, uint(id)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferência não encontrada"})
+		return
+	}
+
+	if transfer.Status != models.StockTransferShipped {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas transferências enviadas podem ser recebidas"})
+		return
+	}
+
+	// Conferência informada por item; os demais são recebidos integralmente
+	lines := make(map[uint]models.ReceiveStockTransferItemRequest, len(req.Items))
+	for _, line := range req.Items {
+		lines[line.ItemID] = line
+	}
+	items := make(map[uint]bool, len(transfer.Items))
+	for _, item := range transfer.Items {
+		items[item.ID] = true
+	}
+	for itemID := range lines {
+		if !items[itemID] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Item não pertence à transferência: " + strconv.Itoa(int(itemID))})
+			return
+		}
+	}
+
+	// Quantidades baixadas dos lotes no envio, recebidas nos lotes correspondentes do destino
+	consumed, err := batchConsumption(tx, "stock_transfer", transfer.ID, models.StockMovementTransferOut)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lotes da transferência"})
+		return
+	}
+
+	notes := fmt.Sprintf("Transferência #%d - %s para %s", transfer.ID, transfer.OriginStore.Name, transfer.DestinationStore.Name)
+	if req.Notes != "" {
+		notes += " - " + req.Notes
+	}
+
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+		received := item.Quantity
+		var itemNotes string
+		if line, found := lines[item.ID]; found {
+			received = models.RoundQuantity(line.ReceivedQuantity)
+			itemNotes = line.Notes
+		}
+
+		if received > item.Quantity {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantidade recebida excede a enviada no item %d (%g)", item.ID, item.Quantity)})
+			return
+		}
+
+		if received > 0 {
+			product, err := lockProduct(tx, item.ProductID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar produto"})
+				return
+			}
+
+			if err := product.ValidateQuantity(received); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			key := stockKey{ProductID: product.ID}
+			var variant *models.ProductVariant
+			if item.VariantID != nil {
+				locked, err := lockVariant(tx, product.ID, *item.VariantID)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar variação"})
+					return
+				}
+				variant = &locked
+				key.VariantID = variant.ID
+			}
+
+			if err := applyStockChange(tx, &product, stockChange{
+				Type:          models.StockMovementTransferIn,
+				Quantity:      received,
+				ReferenceType: "stock_transfer",
+				ReferenceID:   &transfer.ID,
+				UserID:        c.GetUint("user_id"),
+				Notes:         notes,
+				Variant:       variant,
+				Restore:       takeBatchConsumption(consumed, key, received),
+				StoreID:       &transfer.DestinationStoreID,
+			}); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar estoque"})
+				return
+			}
+		}
+
+		item.ReceivedQuantity = &received
+		item.Difference = models.RoundQuantity(item.Quantity - received)
+		item.Notes = itemNotes
+		if err := tx.Model(item).Updates(map[string]interface{}{
+			"received_quantity": received,
+			"difference":        item.Difference,
+			"notes":             item.Notes,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar item da transferência"})
+			return
+		}
+	}
+
+	userID := c.GetUint("user_id")
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":         models.StockTransferReceived,
+		"received_at":    time.Now(),
+		"received_by_id": userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar transferência"})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar recebimento"})
+		return
+	}
+
+	preloadStockTransfer(tenantDB(c)).First(&transfer, transfer.ID)
+	c.JSON(http.StatusOK, transfer.ToResponse())
+}
+
+// preloadStockTransfer carrega as lojas, o usuário e os itens da transferência
+func preloadStockTransfer(db *gorm.DB) *gorm.DB {
+	return db.Preload("OriginStore").Preload("DestinationStore").Preload("User").
+		Preload("Items.Product.Category").Preload("Items.Variant")
+}
+
+// findStockTransfer busca a transferência do parâmetro id, respondendo com erro se não existir
+func findStockTransfer(c *gin.Context) (models.StockTransfer, bool) {
+	var transfer models.StockTransfer
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return transfer, false
+	}
+
+	if err := tenantDB(c).First(&transfer, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferência não encontrada"})
+		return transfer, false
+	}
+	return transfer, true
+}
+
+// fillStockTransfer copia os dados da requisição para a transferência, validando as lojas,
+// os produtos e as variações
+func fillStockTransfer(db *gorm.DB, transfer *models.StockTransfer, req models.StockTransferRequest) error {
+	if req.OriginStoreID == req.DestinationStoreID {
+		return errors.New("A loja de destino deve ser diferente da loja de origem")
+	}
+
+	var origin, destination models.Store
+	if err := db.First(&origin, req.OriginStoreID).Error; err != nil {
+		return errors.New("Loja de origem não encontrada")
+	}
+	if err := db.First(&destination, req.DestinationStoreID).Error; err != nil {
+		return errors.New("Loja de destino não encontrada")
+	}
+	if !origin.Active {
+		return errors.New("Loja de origem inativa: " + origin.Name)
+	}
+	if !destination.Active {
+		return errors.New("Loja de destino inativa: " + destination.Name)
+	}
+
+	transfer.OriginStoreID = origin.ID
+	transfer.DestinationStoreID = destination.ID
+	transfer.Notes = req.Notes
+
+	transfer.Items = nil
+	seen := make(map[stockKey]bool, len(req.Items))
+	for _, itemReq := range req.Items {
+		var product models.Product
+		if err := db.First(&product, itemReq.ProductID).Error; err != nil {
+			return errors.New("Produto não encontrado: " + strconv.Itoa(int(itemReq.ProductID)))
+		}
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
+			return err
+		}
+
+		// Em produtos com variações transfere-se a variação, que tem estoque próprio
+		key := stockKey{ProductID: product.ID}
+		switch {
+		case product.HasVariants && itemReq.VariantID == nil:
+			return errors.New(models.ErrVariantRequired.Error() + ": " + product.Name)
+		case !product.HasVariants && itemReq.VariantID != nil:
+			return errors.New("Produto sem variações: " + product.Name)
+		case itemReq.VariantID != nil:
+			var variant models.ProductVariant
+			if err := db.Where("product_id = ?", product.ID).First(&variant, *itemReq.VariantID).Error; err != nil {
+				return errors.New("Variação não encontrada: " + product.Name)
+			}
+			key.VariantID = variant.ID
+		}
+
+		if seen[key] {
+			return errors.New("Produto repetido na transferência: " + product.Name)
+		}
+		seen[key] = true
+
+		transfer.Items = append(transfer.Items, models.StockTransferItem{
+			ProductID: product.ID,
+			VariantID: itemReq.VariantID,
+			Quantity:  models.RoundQuantity(itemReq.Quantity),
+		})
+	}
+	return nil
+}
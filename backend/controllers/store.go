@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// GetOrganization retorna a organização do usuário autenticado
+func GetOrganization(c *gin.Context) {
+	var organization models.Organization
+	if err := tenantDB(c).First(&organization, c.GetUint("organization_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organização não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// UpdateOrganization atualiza os dados da organização do usuário autenticado
+func UpdateOrganization(c *gin.Context) {
+	var req models.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var organization models.Organization
+	if err := tenantDB(c).First(&organization, c.GetUint("organization_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organização não encontrada"})
+		return
+	}
+
+	document, err := parseCNPJ(req.Document)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	organization.Name = req.Name
+	organization.Document = document
+	if req.MaxDiscountPercentage != nil {
+		organization.MaxDiscountPercentage = *req.MaxDiscountPercentage
+	}
+
+	if err := tenantDB(c).Save(&organization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar organização"})
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// GetStores retorna as lojas da organização
+func GetStores(c *gin.Context) {
+	var stores []models.Store
+	query := tenantDB(c)
+
+	// Filtro por status ativo
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	if err := query.Order("name ASC").Find(&stores).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lojas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stores)
+}
+
+// GetStore retorna uma loja específica
+func GetStore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var store models.Store
+	if err := tenantDB(c).First(&store, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, store)
+}
+
+// CreateStore cadastra uma loja na organização
+func CreateStore(c *gin.Context) {
+	var req models.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	store := models.Store{Active: true}
+	if err := fillStore(&store, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&store).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar loja"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, store)
+}
+
+// UpdateStore atualiza uma loja
+func UpdateStore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var store models.Store
+	if err := tenantDB(c).First(&store, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+		return
+	}
+
+	if err := fillStore(&store, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Save(&store).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar loja"})
+		return
+	}
+
+	c.JSON(http.StatusOK, store)
+}
+
+// DeleteStore exclui uma loja sem usuários ou vendas associados
+func DeleteStore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var store models.Store
+	if err := tenantDB(c).First(&store, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+		return
+	}
+
+	var userCount int64
+	tenantDB(c).Model(&models.User{}).Where("store_id = ?", store.ID).Count(&userCount)
+	if userCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir loja com usuários associados"})
+		return
+	}
+
+	var saleCount int64
+	tenantDB(c).Model(&models.Sale{}).Where("store_id = ?", store.ID).Count(&saleCount)
+	if saleCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir loja com vendas associadas"})
+		return
+	}
+
+	var transferCount int64
+	tenantDB(c).Model(&models.StockTransfer{}).Where("origin_store_id = ? OR destination_store_id = ?", store.ID, store.ID).Count(&transferCount)
+	if transferCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir loja com transferências associadas"})
+		return
+	}
+
+	var stockCount int64
+	tenantDB(c).Model(&models.StoreProduct{}).Where("store_id = ? AND stock <> 0", store.ID).Count(&stockCount)
+	if stockCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir loja com estoque"})
+		return
+	}
+
+	if err := tenantDB(c).Where("store_id = ?", store.ID).Delete(&models.StoreProduct{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir loja"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&store).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir loja"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Loja excluída com sucesso"})
+}
+
+// fillStore copia os dados da requisição para a loja, validando o CNPJ
+func fillStore(store *models.Store, req models.StoreRequest) error {
+	document, err := parseCNPJ(req.Document)
+	if err != nil {
+		return err
+	}
+
+	store.Name = req.Name
+	store.Code = req.Code
+	store.Document = document
+	store.Address = req.Address
+	store.Phone = req.Phone
+
+	if req.Active != nil {
+		store.Active = *req.Active
+	}
+	return nil
+}
+
+// parseCNPJ normaliza o CNPJ informado para apenas dígitos, validando-o quando preenchido
+func parseCNPJ(raw string) (string, error) {
+	document := validators.OnlyDigits(raw)
+	if document != "" && !validators.ValidateCNPJ(document) {
+		return "", errors.New("CNPJ inválido")
+	}
+	return document, nil
+}
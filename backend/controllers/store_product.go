@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/models"
+)
+
+// GetProductStores retorna o saldo, o estoque mínimo e o preço do produto em cada loja da
+// organização. Lojas sem movimentação do produto aparecem com saldo zero
+func GetProductStores(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var stores []models.Store
+	if err := tenantDB(c).Order("name ASC").Find(&stores).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lojas"})
+		return
+	}
+
+	var storeProducts []models.StoreProduct
+	if err := tenantDB(c).Where("product_id = ?", product.ID).Find(&storeProducts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque das lojas"})
+		return
+	}
+	byStore := make(map[uint]models.StoreProduct, len(storeProducts))
+	for _, storeProduct := range storeProducts {
+		byStore[storeProduct.StoreID] = storeProduct
+	}
+
+	responses := make([]models.StoreProductResponse, len(stores))
+	for i, store := range stores {
+		storeProduct, ok := byStore[store.ID]
+		if !ok {
+			storeProduct = models.StoreProduct{StoreID: store.ID, ProductID: product.ID}
+		}
+		storeProduct.Store = store
+		responses[i] = storeProduct.ToResponse(&product)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// UpdateProductStore define o estoque mínimo e o preço de venda do produto na loja. O saldo
+// é alterado apenas por movimentações (PUT /products/:id/stock com store_id)
+func UpdateProductStore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	storeID, err := strconv.ParseUint(c.Param("store_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da loja inválido"})
+		return
+	}
+
+	var req models.StoreProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := tenantDB(c).First(&product, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Produto não encontrado"})
+		return
+	}
+
+	var store models.Store
+	if err := tenantDB(c).First(&store, uint(storeID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+		return
+	}
+
+	storeProduct := models.StoreProduct{StoreID: store.ID, ProductID: product.ID}
+	if err := tenantDB(c).Where(storeProduct).FirstOrCreate(&storeProduct).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar estoque da loja"})
+		return
+	}
+
+	// Alterar o preço da loja exige permissão própria
+	if priceChanged(storeProduct.Price, req.Price) && !requirePermission(c, models.PermissionProductPriceEdit) {
+		return
+	}
+
+	if req.MinStock != nil {
+		minStock := models.RoundQuantity(*req.MinStock)
+		req.MinStock = &minStock
+	}
+	storeProduct.MinStock = req.MinStock
+	storeProduct.Price = req.Price
+
+	// Select grava também os valores nulos, que voltam a usar os do produto
+	if err := tenantDB(c).Model(&storeProduct).Select("min_stock", "price").Updates(&storeProduct).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar produto na loja"})
+		return
+	}
+
+	storeProduct.Store = store
+	c.JSON(http.StatusOK, storeProduct.ToResponse(&product))
+}
+
+// storeParam lê o parâmetro opcional store_id da consulta, verificando se a loja pertence
+// à organização. Sem o parâmetro retorna nil (consolidado da organização)
+func storeParam(c *gin.Context) (*uint, bool) {
+	value := c.Query("store_id")
+	if value == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da loja inválido"})
+		return nil, false
+	}
+
+	var store models.Store
+	if err := tenantDB(c).First(&store, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loja não encontrada"})
+		return nil, false
+	}
+	return &store.ID, true
+}
+
+// attachStoreProducts preenche nas respostas o saldo e o preço dos produtos na loja informada
+func attachStoreProducts(db *gorm.DB, storeID *uint, products []models.Product, responses []models.ProductResponse) error {
+	if storeID == nil || len(products) == 0 {
+		return nil
+	}
+
+	var store models.Store
+	if err := db.First(&store, *storeID).Error; err != nil {
+		return err
+	}
+
+	ids := make([]uint, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	var storeProducts []models.StoreProduct
+	if err := db.Where("store_id = ? AND product_id IN ?", store.ID, ids).Find(&storeProducts).Error; err != nil {
+		return err
+	}
+	byProduct := make(map[uint]models.StoreProduct, len(storeProducts))
+	for _, storeProduct := range storeProducts {
+		byProduct[storeProduct.ProductID] = storeProduct
+	}
+
+	for i := range products {
+		storeProduct, ok := byProduct[products[i].ID]
+		if !ok {
+			storeProduct = models.StoreProduct{StoreID: store.ID, ProductID: products[i].ID}
+		}
+		storeProduct.Store = store
+		response := storeProduct.ToResponse(&products[i])
+		responses[i].Store = &response
+	}
+	return nil
+}
+
+// applyStorePrice substitui o preço do produto carregado pelo preço próprio da loja, se houver
+func applyStorePrice(db *gorm.DB, storeID *uint, product *models.Product) error {
+	if storeID == nil {
+		return nil
+	}
+
+	var storeProduct models.StoreProduct
+	err := db.Where("store_id = ? AND product_id = ?", *storeID, product.ID).First(&storeProduct).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	product.Price = storeProduct.EffectivePrice(product)
+	return nil
+}
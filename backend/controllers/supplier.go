@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// GetSuppliers retorna todos os fornecedores
+func GetSuppliers(c *gin.Context) {
+	var suppliers []models.Supplier
+	query := tenantDB(c)
+
+	// Filtro por status ativo
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active)
+	}
+
+	// Filtro de busca por razão social, nome fantasia ou documento
+	if search := c.Query("search"); search != "" {
+		like := "%" + search + "%"
+		conditions := "name LIKE ? OR trade_name LIKE ?"
+		args := []interface{}{like, like}
+		if digits := validators.OnlyDigits(search); digits != "" {
+			conditions += " OR document LIKE ?"
+			args = append(args, "%"+digits+"%")
+		}
+		query = query.Where(conditions, args...)
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset := (page - 1) * limit
+
+	if err := query.Order("name ASC").Offset(offset).Limit(limit).Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar fornecedores"})
+		return
+	}
+
+	// Converter para response
+	responses := make([]models.SupplierResponse, len(suppliers))
+	for i, supplier := range suppliers {
+		responses[i] = supplier.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetSupplier retorna um fornecedor específico
+func GetSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := tenantDB(c).First(&supplier, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fornecedor não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier.ToResponse())
+}
+
+// CreateSupplier cria um novo fornecedor
+func CreateSupplier(c *gin.Context) {
+	var req models.SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier := models.Supplier{Active: true}
+	if err := fillSupplier(&supplier, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verificar se o documento já está cadastrado
+	if supplier.Document != nil {
+		var existingSupplier models.Supplier
+		if err := tenantDB(c).Where("document = ?", *supplier.Document).First(&existingSupplier).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um fornecedor com este CPF/CNPJ"})
+			return
+		}
+	}
+
+	if err := tenantDB(c).Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar fornecedor"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplier.ToResponse())
+}
+
+// UpdateSupplier atualiza um fornecedor
+func UpdateSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Buscar fornecedor
+	var supplier models.Supplier
+	if err := tenantDB(c).First(&supplier, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fornecedor não encontrado"})
+		return
+	}
+
+	if err := fillSupplier(&supplier, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verificar se o documento já pertence a outro fornecedor
+	if supplier.Document != nil {
+		var existingSupplier models.Supplier
+		if err := tenantDB(c).Where("document = ? AND id != ?", *supplier.Document, supplier.ID).First(&existingSupplier).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Já existe um fornecedor com este CPF/CNPJ"})
+			return
+		}
+	}
+
+	if err := tenantDB(c).Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar fornecedor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier.ToResponse())
+}
+
+// DeleteSupplier exclui um fornecedor
+func DeleteSupplier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := tenantDB(c).First(&supplier, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fornecedor não encontrado"})
+		return
+	}
+
+	// Verificar se o fornecedor tem pedidos de compra associados
+	var orderCount int64
+	tenantDB(c).Model(&models.PurchaseOrder{}).Where("supplier_id = ?", supplier.ID).Count(&orderCount)
+	if orderCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir fornecedor com pedidos de compra associados"})
+		return
+	}
+
+	if err := tenantDB(c).Delete(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir fornecedor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fornecedor excluído com sucesso"})
+}
+
+// fillSupplier copia os dados da requisição para o fornecedor, validando CPF/CNPJ
+func fillSupplier(supplier *models.Supplier, req models.SupplierRequest) error {
+	supplier.Name = req.Name
+	supplier.TradeName = req.TradeName
+	supplier.StateReg = strings.ToUpper(strings.TrimSpace(req.StateReg))
+	supplier.ContactName = req.ContactName
+	supplier.Phone = req.Phone
+	supplier.Email = req.Email
+	supplier.ZipCode = validators.OnlyDigits(req.ZipCode)
+	supplier.Street = req.Street
+	supplier.Number = req.Number
+	supplier.Complement = req.Complement
+	supplier.District = req.District
+	supplier.City = req.City
+	supplier.State = strings.ToUpper(req.State)
+	supplier.Notes = req.Notes
+
+	if req.Active != nil {
+		supplier.Active = *req.Active
+	}
+
+	document, documentType, err := parseDocument(req.Document)
+	if err != nil {
+		return err
+	}
+	supplier.Document = document
+	supplier.DocumentType = documentType
+	return nil
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pdv-backend/config"
+	"pdv-backend/models"
+)
+
+// errStoreRequired é a resposta às movimentações de estoque sem loja em organizações com lojas
+const errStoreRequired = "Informe a loja: o estoque da organização é controlado por loja"
+
+// tenantDB retorna a conexão restrita à organização do usuário autenticado: consultas,
+// alterações e exclusões de modelos com organization_id são filtradas por ela e os
+// registros criados pertencem a ela
+func tenantDB(c *gin.Context) *gorm.DB {
+	return config.DB.WithContext(c.Request.Context())
+}
+
+// userStore retorna a loja do usuário autenticado, se houver
+func userStore(c *gin.Context) *uint {
+	storeID, ok := c.Get("store_id")
+	if !ok {
+		return nil
+	}
+	id := storeID.(uint)
+	return &id
+}
+
+// stockStore retorna a loja do usuário para movimentar o estoque. Em organizações com lojas,
+// usuários sem loja recebem erro. Retorna false se a resposta de erro já foi enviada
+func stockStore(c *gin.Context) (*uint, bool) {
+	storeID := userStore(c)
+	if storeID == nil && hasStores(tenantDB(c)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errStoreRequired})
+		return nil, false
+	}
+	return storeID, true
+}
+
+// hasStores indica se a organização tem lojas. A partir da primeira loja o estoque é
+// controlado por loja e as movimentações precisam informá-la
+func hasStores(db *gorm.DB) bool {
+	var count int64
+	db.Model(&models.Store{}).Count(&count)
+	return count > 0
+}
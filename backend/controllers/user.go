@@ -15,13 +15,18 @@ type UserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"omitempty,min=6"`
 	Role     string `json:"role" binding:"required,oneof=admin manager cashier"`
+	StoreID  *uint  `json:"store_id"` // loja em que o usuário opera
 	Active   *bool  `json:"active"`
+
+	// Permissões personalizadas: true concede e false revoga a permissão do perfil.
+	// Nulo mantém as atuais; vazio volta ao modelo do perfil
+	Permissions map[string]bool `json:"permissions"`
 }
 
 // GetUsers retorna todos os usuários
 func GetUsers(c *gin.Context) {
 	var users []models.User
-	query := config.DB
+	query := tenantDB(c)
 
 	// Filtro por role
 	if role := c.Query("role"); role != "" {
@@ -61,7 +66,7 @@ func GetUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.First(&user, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&user, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
 		return
 	}
@@ -90,19 +95,33 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Verificar se a loja pertence à organização
+	if req.StoreID != nil {
+		if err := tenantDB(c).First(&models.Store{}, *req.StoreID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Loja não encontrada"})
+			return
+		}
+	}
+
 	// Criar usuário
 	user := models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password, // Será hasheada no hook BeforeCreate
 		Role:     req.Role,
+		StoreID:  req.StoreID,
 	}
 
 	if req.Active != nil {
 		user.Active = *req.Active
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
+	if err := user.SetPermissionOverrides(req.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tenantDB(c).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar usuário"})
 		return
 	}
@@ -126,7 +145,7 @@ func UpdateUser(c *gin.Context) {
 
 	// Buscar usuário
 	var user models.User
-	if err := config.DB.First(&user, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&user, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
 		return
 	}
@@ -138,6 +157,14 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Verificar se a loja pertence à organização
+	if req.StoreID != nil {
+		if err := tenantDB(c).First(&models.Store{}, *req.StoreID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Loja não encontrada"})
+			return
+		}
+	}
+
 	// Verificar se o usuário está tentando alterar seu próprio role
 	currentUserID, _ := c.Get("user_id")
 	if currentUserID == user.ID && req.Role != user.Role {
@@ -145,11 +172,29 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Permissões personalizadas
+	if req.Permissions != nil {
+		updated := user
+		if err := updated.SetPermissionOverrides(req.Permissions); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if currentUserID == user.ID && updated.Permissions != user.Permissions {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível alterar suas próprias permissões"})
+			return
+		}
+		user.Permissions = updated.Permissions
+	}
+
 	// Atualizar campos
 	user.Name = req.Name
 	user.Email = req.Email
 	user.Role = req.Role
 
+	if req.StoreID != nil {
+		user.StoreID = req.StoreID
+	}
+
 	if req.Active != nil {
 		user.Active = *req.Active
 	}
@@ -159,7 +204,7 @@ func UpdateUser(c *gin.Context) {
 		user.Password = req.Password // Será hasheada no hook BeforeUpdate
 	}
 
-	if err := config.DB.Save(&user).Error; err != nil {
+	if err := tenantDB(c).Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar usuário"})
 		return
 	}
@@ -176,7 +221,7 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.First(&user, uint(id)).Error; err != nil {
+	if err := tenantDB(c).First(&user, uint(id)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
 		return
 	}
@@ -190,13 +235,13 @@ func DeleteUser(c *gin.Context) {
 
 	// Verificar se o usuário tem vendas associadas
 	var saleCount int64
-	config.DB.Model(&models.Sale{}).Where("user_id = ?", user.ID).Count(&saleCount)
+	tenantDB(c).Model(&models.Sale{}).Where("user_id = ?", user.ID).Count(&saleCount)
 	if saleCount > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível excluir usuário com vendas associadas"})
 		return
 	}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
+	if err := tenantDB(c).Delete(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir usuário"})
 		return
 	}
@@ -0,0 +1,70 @@
+package fiscal
+
+import (
+	"fmt"
+	"time"
+
+	"pdv-backend/validators"
+)
+
+// AccessKey reúne os campos que compõem a chave de acesso de 44 dígitos
+type AccessKey struct {
+	UFCode       int
+	IssuedAt     time.Time
+	CNPJ         string
+	Model        int
+	Series       int
+	Number       int
+	EmissionType int
+	Code         int // cNF: código numérico aleatório de 8 dígitos
+}
+
+// String monta a chave de acesso com o dígito verificador
+func (k AccessKey) String() string {
+	base := k.base()
+	return fmt.Sprintf("%s%d", base, checkDigit(base))
+}
+
+// CheckDigit retorna o dígito verificador (cDV) da chave
+func (k AccessKey) CheckDigit() int {
+	return checkDigit(k.base())
+}
+
+func (k AccessKey) base() string {
+	return fmt.Sprintf("%02d%s%014s%02d%03d%09d%d%08d",
+		k.UFCode,
+		k.IssuedAt.Format("0601"),
+		k.CNPJ,
+		k.Model,
+		k.Series,
+		k.Number,
+		k.EmissionType,
+		k.Code,
+	)
+}
+
+// checkDigit calcula o dígito verificador módulo 11 com pesos de 2 a 9
+func checkDigit(digits string) int {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return 0
+	}
+	return 11 - rest
+}
+
+// ValidAccessKey verifica o tamanho e o dígito verificador de uma chave de acesso
+func ValidAccessKey(key string) bool {
+	if len(key) != 44 || validators.OnlyDigits(key) != key {
+		return false
+	}
+	return checkDigit(key[:43]) == int(key[43]-'0')
+}
@@ -0,0 +1,80 @@
+package fiscal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessKey(t *testing.T) {
+	issuedAt := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		key      AccessKey
+		expected string
+		digit    int
+	}{
+		{
+			name:     "emissão normal",
+			key:      AccessKey{UFCode: 35, IssuedAt: issuedAt, CNPJ: "11222333000181", Model: 65, Series: 1, Number: 1, EmissionType: EmissionNormal, Code: 86590701},
+			expected: "35261011222333000181650010000000011865907018",
+			digit:    8,
+		},
+		{
+			name:     "contingência off-line",
+			key:      AccessKey{UFCode: 35, IssuedAt: issuedAt, CNPJ: "11222333000181", Model: 65, Series: 1, Number: 1, EmissionType: EmissionOfflineNFCe, Code: 86590701},
+			expected: "35261011222333000181650010000000019865907013",
+			digit:    3,
+		},
+		{
+			name:     "série e número com todos os dígitos",
+			key:      AccessKey{UFCode: 43, IssuedAt: time.Date(2001, 12, 1, 0, 0, 0, 0, time.UTC), CNPJ: "00123456000190", Model: 65, Series: 123, Number: 123456, EmissionType: EmissionNormal, Code: 12345678},
+			expected: "43011200123456000190651230001234561123456785",
+			digit:    5,
+		},
+		{
+			name:     "resto menor que 2 gera dígito zero",
+			key:      AccessKey{UFCode: 35, IssuedAt: issuedAt, CNPJ: "11222333000181", Model: 65, Series: 1, Number: 42, EmissionType: EmissionNormal, Code: 10000000},
+			expected: "35261011222333000181650010000000421100000000",
+			digit:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.String(); got != tt.expected {
+				t.Errorf("String() = %s, esperado %s", got, tt.expected)
+			}
+			if got := tt.key.CheckDigit(); got != tt.digit {
+				t.Errorf("CheckDigit() = %d, esperado %d", got, tt.digit)
+			}
+			if !ValidAccessKey(tt.expected) {
+				t.Errorf("ValidAccessKey(%s) = false", tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidAccessKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"chave válida", "35261011222333000181650010000000011865907018", true},
+		{"dígito verificador errado", "35261011222333000181650010000000011865907017", false},
+		{"número alterado", "35261011222333000181650010000000021865907018", false},
+		{"curta", "3526101122233300018165001000000001186590701", false},
+		{"longa", "352610112223330001816500100000000118659070180", false},
+		{"com letras", "35261011222333000181650010000000011865907A18", false},
+		{"vazia", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidAccessKey(tt.key); got != tt.valid {
+				t.Errorf("ValidAccessKey(%s) = %v, esperado %v", tt.key, got, tt.valid)
+			}
+		})
+	}
+}
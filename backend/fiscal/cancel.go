@@ -0,0 +1,218 @@
+package fiscal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"pdv-backend/models"
+)
+
+var (
+	// ErrInvalidJustification indica justificativa fora do tamanho exigido pela SEFAZ
+	ErrInvalidJustification = errors.New("Justificativa deve ter entre 15 e 255 caracteres")
+	// ErrCancelWindowExpired indica que o prazo legal para cancelar a NFC-e terminou
+	ErrCancelWindowExpired = errors.New("Prazo para cancelamento da NFC-e expirado")
+	// ErrNumbersInUse indica que a faixa a inutilizar contém números já utilizados
+	ErrNumbersInUse = errors.New("A faixa informada contém números de NFC-e já emitidas")
+	// ErrAlreadyInvalidated indica sobreposição com uma inutilização já homologada
+	ErrAlreadyInvalidated = errors.New("A faixa informada já foi inutilizada")
+)
+
+// RejectionError indica que a SEFAZ recebeu o pedido mas não o homologou
+type RejectionError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("%d - %s", e.StatusCode, e.Message)
+}
+
+// CancelForSale envia o evento de cancelamento da NFC-e da venda. Vendas sem NFC-e, ou com
+// NFC-e rejeitada, não precisam de evento e retornam sem erro. NFC-e em contingência é
+// transmitida antes do cancelamento
+func (s *Service) CancelForSale(saleID uint, reason string) (*models.FiscalDocument, error) {
+	var document models.FiscalDocument
+	if err := s.db.Where("sale_id = ?", saleID).First(&document).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if document.Status != models.FiscalStatusAuthorized && document.Status != models.FiscalStatusContingency {
+		return &document, nil
+	}
+	if err := s.CheckEmitter(document.OrganizationID); err != nil {
+		return &document, err
+	}
+	if !validJustification(reason) {
+		return &document, ErrInvalidJustification
+	}
+
+	// NFC-e em contingência precisa ser autorizada antes de ser cancelada
+	if document.Status == models.FiscalStatusContingency {
+		signed := []byte(document.XML)
+		result, err := s.authorize(&document, signed)
+		if err != nil {
+			document.CancelMessage = err.Error()
+			s.db.Save(&document)
+			return &document, err
+		}
+		s.applyResult(&document, signed, result)
+		if err := s.db.Save(&document).Error; err != nil {
+			return &document, err
+		}
+		if document.Status != models.FiscalStatusAuthorized {
+			return &document, nil
+		}
+	}
+
+	if document.AuthorizedAt != nil && time.Since(*document.AuthorizedAt) > s.cfg.CancelWindow {
+		return &document, fmt.Errorf("%w (%d minutos após a autorização)", ErrCancelWindowExpired, int(s.cfg.CancelWindow.Minutes()))
+	}
+
+	event := buildCancellationEvent(s.cfg, document.AccessKey, document.Protocol, reason, time.Now())
+	element, err := marshalCanonical(event)
+	if err != nil {
+		return &document, fmt.Errorf("erro ao gerar XML do evento: %w", err)
+	}
+	signature, _, err := s.signer.Sign(element, event.ID)
+	if err != nil {
+		return &document, err
+	}
+	signed := assembleSigned("evento", eventVersion, element, signature)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	document.CancelReason = event.DetEvento.XJust
+	result, err := s.transport.SendEvent(ctx, signed)
+	if err != nil {
+		document.CancelMessage = err.Error()
+		s.db.Save(&document)
+		return &document, err
+	}
+
+	document.CancelMessage = fmt.Sprintf("%d - %s", result.StatusCode, result.Message)
+	if !result.Accepted {
+		s.db.Save(&document)
+		return &document, &RejectionError{StatusCode: result.StatusCode, Message: result.Message}
+	}
+
+	cancelledAt := result.RegisteredAt
+	if cancelledAt.IsZero() {
+		cancelledAt = time.Now()
+	}
+	document.Status = models.FiscalStatusCancelled
+	document.CancelProtocol = result.Protocol
+	document.CancelledAt = &cancelledAt
+	document.CancelXML = string(wrapResponse("procEventoNFe", eventVersion, signed, result.ResponseXML))
+
+	if err := s.db.Save(&document).Error; err != nil {
+		return &document, fmt.Errorf("erro ao salvar cancelamento da NFC-e: %w", err)
+	}
+	return &document, nil
+}
+
+// Invalidate inutiliza uma faixa de numeração não utilizada da série da organização
+// emitente, a pedido do usuário informado. Documentos rejeitados na faixa passam a inutilizados e a
+// numeração da série avança além da faixa
+func (s *Service) Invalidate(series, start, end int, reason string, organizationID, userID uint) (*models.FiscalInvalidation, error) {
+	if !validJustification(reason) {
+		return nil, ErrInvalidJustification
+	}
+	if err := s.CheckEmitter(organizationID); err != nil {
+		return nil, err
+	}
+
+	var used int64
+	s.db.Model(&models.FiscalDocument{}).
+		Where("organization_id = ? AND series = ? AND number BETWEEN ? AND ? AND status NOT IN ?", organizationID, series, start, end,
+			[]string{models.FiscalStatusRejected, models.FiscalStatusInvalidated}).
+		Count(&used)
+	if used > 0 {
+		return nil, ErrNumbersInUse
+	}
+
+	var overlapping int64
+	s.db.Model(&models.FiscalInvalidation{}).
+		Where("organization_id = ? AND series = ? AND status = ? AND start_number <= ? AND end_number >= ?", organizationID, series, models.FiscalStatusAuthorized, end, start).
+		Count(&overlapping)
+	if overlapping > 0 {
+		return nil, ErrAlreadyInvalidated
+	}
+
+	request := buildInvalidation(s.cfg, series, start, end, reason, time.Now())
+	element, err := marshalCanonical(request)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar XML da inutilização: %w", err)
+	}
+	signature, _, err := s.signer.Sign(element, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	signed := assembleSigned("inutNFe", LayoutVersion, element, signature)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	result, err := s.transport.Invalidate(ctx, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidation := models.FiscalInvalidation{
+		OrganizationID: organizationID,
+		Model:          ModelNFCe,
+		Series:         series,
+		StartNumber:    start,
+		EndNumber:      end,
+		Reason:         request.XJust,
+		Environment:    s.cfg.Environment,
+		Status:         models.FiscalStatusRejected,
+		StatusCode:     result.StatusCode,
+		StatusMessage:  result.Message,
+		XML:            string(signed),
+		UserID:         userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if result.Accepted {
+			invalidation.Status = models.FiscalStatusAuthorized
+			invalidation.Protocol = result.Protocol
+			invalidation.XML = string(wrapResponse("procInutNFe", LayoutVersion, signed, result.ResponseXML))
+
+			if err := tx.Model(&models.FiscalDocument{}).
+				Where("organization_id = ? AND series = ? AND number BETWEEN ? AND ? AND status = ?", organizationID, series, start, end, models.FiscalStatusRejected).
+				Update("status", models.FiscalStatusInvalidated).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.FiscalSequence{}).
+				Where("organization_id = ? AND series = ? AND next_number <= ?", organizationID, series, end).
+				Update("next_number", end+1).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&invalidation).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar inutilização: %w", err)
+	}
+
+	if !result.Accepted {
+		return &invalidation, &RejectionError{StatusCode: result.StatusCode, Message: result.Message}
+	}
+	return &invalidation, nil
+}
+
+func validJustification(reason string) bool {
+	length := utf8.RuneCountInString(strings.TrimSpace(reason))
+	return length >= 15 && length <= 255
+}
@@ -0,0 +1,142 @@
+package fiscal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/validators"
+)
+
+// Ambientes da SEFAZ
+const (
+	EnvironmentProduction   = 1
+	EnvironmentHomologation = 2
+)
+
+// Tipos de emissão
+const (
+	EmissionNormal          = 1
+	EmissionOfflineNFCe     = 9 // contingência off-line da NFC-e
+	ModelNFCe               = 65
+	LayoutVersion           = "4.00"
+	ApplicationVersion      = "PDV 1.0"
+	contingencyDefaultNotes = "Falha de comunicação com o webservice da SEFAZ"
+)
+
+// Config reúne os dados do emitente e da comunicação com a SEFAZ
+type Config struct {
+	Enabled     bool
+	Environment int
+	UF          string
+	Series      int
+	FirstNumber int // número inicial da série, para quem migra de outro emissor
+
+	// Emitente
+	CNPJ         string
+	IE           string
+	CRT          int // 1 = Simples Nacional, 3 = Regime Normal
+	Name         string
+	TradeName    string
+	Street       string
+	Number       string
+	District     string
+	CityCode     string // código IBGE do município
+	City         string
+	ZipCode      string
+	Phone        string
+	DefaultCFOP  string
+	NatureOfSale string
+
+	// Código de Segurança do Contribuinte, usado no QR Code
+	CSCID string
+	CSC   string
+
+	// Certificado A1 (.pfx)
+	CertificatePath     string
+	CertificatePassword string
+
+	// Transporte: "mock" (SEFAZ simulada local) ou "soap"
+	Transport        string
+	AuthorizationURL string
+	EventURL         string
+	InvalidationURL  string
+	QRCodeURL        string
+	ConsultURL       string
+	Timeout          time.Duration
+
+	// Intervalo da retransmissão automática das notas em contingência
+	RetransmitInterval time.Duration
+
+	// Prazo para cancelamento da NFC-e após a autorização
+	CancelWindow time.Duration
+}
+
+// LoadConfig lê a configuração fiscal das variáveis de ambiente FISCAL_*
+func LoadConfig() Config {
+	cfg := Config{
+		Enabled:             getEnv("FISCAL_ENABLED", "false") == "true",
+		Environment:         getEnvInt("FISCAL_ENVIRONMENT", EnvironmentHomologation),
+		UF:                  strings.ToUpper(getEnv("FISCAL_UF", "SP")),
+		Series:              getEnvInt("FISCAL_SERIES", 1),
+		FirstNumber:         getEnvInt("FISCAL_FIRST_NUMBER", 1),
+		CNPJ:                validators.OnlyDigits(getEnv("FISCAL_CNPJ", "")),
+		IE:                  validators.OnlyDigits(getEnv("FISCAL_IE", "")),
+		CRT:                 getEnvInt("FISCAL_CRT", 1),
+		Name:                getEnv("FISCAL_NAME", ""),
+		TradeName:           getEnv("FISCAL_TRADE_NAME", ""),
+		Street:              getEnv("FISCAL_STREET", ""),
+		Number:              getEnv("FISCAL_NUMBER", "S/N"),
+		District:            getEnv("FISCAL_DISTRICT", ""),
+		CityCode:            getEnv("FISCAL_CITY_CODE", ""),
+		City:                getEnv("FISCAL_CITY", ""),
+		ZipCode:             validators.OnlyDigits(getEnv("FISCAL_ZIP_CODE", "")),
+		Phone:               validators.OnlyDigits(getEnv("FISCAL_PHONE", "")),
+		DefaultCFOP:         getEnv("FISCAL_DEFAULT_CFOP", "5102"),
+		NatureOfSale:        getEnv("FISCAL_NATURE", "VENDA"),
+		CSCID:               getEnv("FISCAL_CSC_ID", ""),
+		CSC:                 getEnv("FISCAL_CSC", ""),
+		CertificatePath:     getEnv("FISCAL_CERT_PATH", ""),
+		CertificatePassword: getEnv("FISCAL_CERT_PASSWORD", ""),
+		Transport:           getEnv("FISCAL_TRANSPORT", "mock"),
+		AuthorizationURL:    getEnv("FISCAL_AUTHORIZATION_URL", ""),
+		EventURL:            getEnv("FISCAL_EVENT_URL", ""),
+		InvalidationURL:     getEnv("FISCAL_INVALIDATION_URL", ""),
+		QRCodeURL:           getEnv("FISCAL_QRCODE_URL", "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode"),
+		ConsultURL:          getEnv("FISCAL_CONSULT_URL", "https://www.homologacao.nfce.fazenda.sp.gov.br/consulta"),
+		Timeout:             time.Duration(getEnvInt("FISCAL_TIMEOUT_SECONDS", 15)) * time.Second,
+		RetransmitInterval:  time.Duration(getEnvInt("FISCAL_RETRANSMIT_MINUTES", 5)) * time.Minute,
+		CancelWindow:        time.Duration(getEnvInt("FISCAL_CANCEL_WINDOW_MINUTES", 30)) * time.Minute,
+	}
+
+	return cfg
+}
+
+// UFCode retorna o código IBGE da UF do emitente
+func (c Config) UFCode() int {
+	return ufCodes[c.UF]
+}
+
+// ufCodes mapeia a sigla da UF para o código IBGE usado na chave de acesso
+var ufCodes = map[string]int{
+	"RO": 11, "AC": 12, "AM": 13, "RR": 14, "PA": 15, "AP": 16, "TO": 17,
+	"MA": 21, "PI": 22, "CE": 23, "RN": 24, "PB": 25, "PE": 26, "AL": 27, "SE": 28, "BA": 29,
+	"MG": 31, "ES": 32, "RJ": 33, "SP": 35,
+	"PR": 41, "SC": 42, "RS": 43,
+	"MS": 50, "MT": 51, "GO": 52, "DF": 53,
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
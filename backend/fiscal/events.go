@@ -0,0 +1,117 @@
+package fiscal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const (
+	eventVersion      = "1.00"
+	eventCancellation = "110111"
+)
+
+// infEvento segue o leiaute do evento de cancelamento (NFeRecepcaoEvento4)
+type infEvento struct {
+	XMLName   xml.Name  `xml:"infEvento"`
+	Xmlns     string    `xml:"xmlns,attr,omitempty"`
+	ID        string    `xml:"Id,attr"`
+	COrgao    int       `xml:"cOrgao"`
+	TpAmb     int       `xml:"tpAmb"`
+	CNPJ      string    `xml:"CNPJ"`
+	ChNFe     string    `xml:"chNFe"`
+	DhEvento  string    `xml:"dhEvento"`
+	TpEvento  string    `xml:"tpEvento"`
+	NSeq      int       `xml:"nSeqEvento"`
+	VerEvento string    `xml:"verEvento"`
+	DetEvento detEvento `xml:"detEvento"`
+}
+
+type detEvento struct {
+	Version    string `xml:"versao,attr"`
+	DescEvento string `xml:"descEvento"`
+	NProt      string `xml:"nProt"`
+	XJust      string `xml:"xJust"`
+}
+
+// infInut segue o leiaute do pedido de inutilização (NFeInutilizacao4)
+type infInut struct {
+	XMLName xml.Name `xml:"infInut"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	ID      string   `xml:"Id,attr"`
+	TpAmb   int      `xml:"tpAmb"`
+	XServ   string   `xml:"xServ"`
+	CUF     int      `xml:"cUF"`
+	Ano     string   `xml:"ano"`
+	CNPJ    string   `xml:"CNPJ"`
+	Mod     int      `xml:"mod"`
+	Serie   int      `xml:"serie"`
+	NNFIni  int      `xml:"nNFIni"`
+	NNFFin  int      `xml:"nNFFin"`
+	XJust   string   `xml:"xJust"`
+}
+
+// buildCancellationEvent monta o evento de cancelamento da NFC-e autorizada
+func buildCancellationEvent(cfg Config, accessKey, protocol, reason string, at time.Time) *infEvento {
+	const sequence = 1
+	return &infEvento{
+		Xmlns:     namespaceNFe,
+		ID:        fmt.Sprintf("ID%s%s%02d", eventCancellation, accessKey, sequence),
+		COrgao:    cfg.UFCode(),
+		TpAmb:     cfg.Environment,
+		CNPJ:      cfg.CNPJ,
+		ChNFe:     accessKey,
+		DhEvento:  at.Format(dateTimeLayout),
+		TpEvento:  eventCancellation,
+		NSeq:      sequence,
+		VerEvento: eventVersion,
+		DetEvento: detEvento{
+			Version:    eventVersion,
+			DescEvento: "Cancelamento",
+			NProt:      protocol,
+			XJust:      sanitize(reason, 255),
+		},
+	}
+}
+
+// buildInvalidation monta o pedido de inutilização de uma faixa de numeração
+func buildInvalidation(cfg Config, series, start, end int, reason string, at time.Time) *infInut {
+	year := at.Format("06")
+	return &infInut{
+		Xmlns: namespaceNFe,
+		ID: fmt.Sprintf("ID%02d%s%s%02d%03d%09d%09d",
+			cfg.UFCode(), year, cfg.CNPJ, ModelNFCe, series, start, end),
+		TpAmb:  cfg.Environment,
+		XServ:  "INUTILIZAR",
+		CUF:    cfg.UFCode(),
+		Ano:    year,
+		CNPJ:   cfg.CNPJ,
+		Mod:    ModelNFCe,
+		Serie:  series,
+		NNFIni: start,
+		NNFFin: end,
+		XJust:  sanitize(reason, 255),
+	}
+}
+
+// assembleSigned monta o elemento raiz com o grupo assinado e a assinatura
+func assembleSigned(root, version string, element, signature []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<` + root + ` xmlns="` + namespaceNFe + `" versao="` + version + `">`)
+	b.Write(bytes.Replace(element, []byte(` xmlns="`+namespaceNFe+`"`), nil, 1))
+	b.Write(signature)
+	b.WriteString(`</` + root + `>`)
+	return b.Bytes()
+}
+
+// wrapResponse anexa o retorno da SEFAZ ao pedido assinado (procEventoNFe, procInutNFe)
+func wrapResponse(root, version string, request, response []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	b.WriteString(`<` + root + ` xmlns="` + namespaceNFe + `" versao="` + version + `">`)
+	b.Write(request)
+	b.Write(response)
+	b.WriteString(`</` + root + `>`)
+	return b.Bytes()
+}
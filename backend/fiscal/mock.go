@@ -0,0 +1,146 @@
+package fiscal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Modos da SEFAZ simulada
+const (
+	MockAuthorize = "authorize" // autoriza todos os documentos com assinatura válida
+	MockOffline   = "offline"   // simula indisponibilidade (força contingência)
+	MockReject    = "reject"    // rejeita todos os documentos
+)
+
+// MockTransport simula a SEFAZ localmente, para desenvolvimento e testes. Confere a
+// assinatura dos documentos e responde com protocolos sequenciais
+type MockTransport struct {
+	mu            sync.Mutex
+	Mode          string
+	RejectCode    int
+	RejectMessage string
+	Received      []string // chaves de acesso recebidas
+	sequence      int64
+}
+
+// NewMockTransport cria a SEFAZ simulada no modo informado
+func NewMockTransport(mode string) *MockTransport {
+	if mode == "" {
+		mode = MockAuthorize
+	}
+	return &MockTransport{
+		Mode:          mode,
+		sequence:      time.Now().Unix() % 1000000000 * 1000, // protocolos distintos entre reinícios
+		RejectCode:    225,
+		RejectMessage: "Rejeição: Falha no Schema XML da NFe",
+	}
+}
+
+// newMockTransportFromEnv lê o modo da SEFAZ simulada de FISCAL_MOCK_MODE
+func newMockTransportFromEnv() *MockTransport {
+	mock := NewMockTransport(os.Getenv("FISCAL_MOCK_MODE"))
+	if code, err := strconv.Atoi(os.Getenv("FISCAL_MOCK_REJECT_CODE")); err == nil {
+		mock.RejectCode = code
+	}
+	return mock
+}
+
+// SetMode altera o modo da SEFAZ simulada
+func (m *MockTransport) SetMode(mode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Mode = mode
+}
+
+// Authorize simula o webservice NFeAutorizacao4
+func (m *MockTransport) Authorize(ctx context.Context, accessKey string, signedNFe []byte) (*AuthorizationResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Mode == MockOffline {
+		return nil, fmt.Errorf("%w: SEFAZ simulada fora do ar", ErrUnavailable)
+	}
+
+	m.Received = append(m.Received, accessKey)
+
+	if err := VerifySignature(signedNFe, "infNFe", namespaceNFe); err != nil {
+		return &AuthorizationResult{StatusCode: 297, Message: "Rejeição: Assinatura difere do calculado"}, nil
+	}
+	if m.Mode == MockReject {
+		return &AuthorizationResult{StatusCode: m.RejectCode, Message: m.RejectMessage}, nil
+	}
+
+	m.sequence++
+	now := time.Now()
+	protocol := fmt.Sprintf("1%s%012d", now.Format("06"), m.sequence)
+	result := &AuthorizationResult{
+		Authorized: true,
+		StatusCode: 100,
+		Message:    "Autorizado o uso da NF-e",
+		Protocol:   protocol,
+		ReceivedAt: now,
+	}
+	result.ProtocolXML = []byte(fmt.Sprintf(
+		`<protNFe versao="%s"><infProt><tpAmb>%d</tpAmb><verAplic>MOCK</verAplic><chNFe>%s</chNFe>`+
+			`<dhRecbto>%s</dhRecbto><nProt>%s</nProt><cStat>100</cStat><xMotivo>%s</xMotivo></infProt></protNFe>`,
+		LayoutVersion, EnvironmentHomologation, accessKey, now.Format(dateTimeLayout), protocol, result.Message))
+
+	return result, nil
+}
+
+// SendEvent simula o webservice NFeRecepcaoEvento4
+func (m *MockTransport) SendEvent(ctx context.Context, signedEvent []byte) (*EventResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Mode == MockOffline {
+		return nil, fmt.Errorf("%w: SEFAZ simulada fora do ar", ErrUnavailable)
+	}
+	if err := VerifySignature(signedEvent, "infEvento", namespaceNFe); err != nil {
+		return &EventResult{StatusCode: 297, Message: "Rejeição: Assinatura difere do calculado"}, nil
+	}
+	if m.Mode == MockReject {
+		return &EventResult{StatusCode: 501, Message: "Rejeição: Prazo do evento de cancelamento superior ao previsto na legislação"}, nil
+	}
+
+	return m.accept(135, "Evento registrado e vinculado a NF-e", "retEvento"), nil
+}
+
+// Invalidate simula o webservice NFeInutilizacao4
+func (m *MockTransport) Invalidate(ctx context.Context, signedRequest []byte) (*EventResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Mode == MockOffline {
+		return nil, fmt.Errorf("%w: SEFAZ simulada fora do ar", ErrUnavailable)
+	}
+	if err := VerifySignature(signedRequest, "infInut", namespaceNFe); err != nil {
+		return &EventResult{StatusCode: 297, Message: "Rejeição: Assinatura difere do calculado"}, nil
+	}
+	if m.Mode == MockReject {
+		return &EventResult{StatusCode: 563, Message: "Rejeição: Já existe pedido de inutilização com a mesma faixa de inutilização"}, nil
+	}
+
+	return m.accept(invalidationHomologated, "Inutilização de número homologado", "retInutNFe"), nil
+}
+
+// accept gera uma resposta positiva com protocolo sequencial
+func (m *MockTransport) accept(code int, message, element string) *EventResult {
+	m.sequence++
+	now := time.Now()
+	protocol := fmt.Sprintf("1%s%012d", now.Format("06"), m.sequence)
+	return &EventResult{
+		Accepted:     true,
+		StatusCode:   code,
+		Message:      message,
+		Protocol:     protocol,
+		RegisteredAt: now,
+		ResponseXML: []byte(fmt.Sprintf(
+			`<%s versao="%s"><tpAmb>%d</tpAmb><verAplic>MOCK</verAplic><cStat>%d</cStat><xMotivo>%s</xMotivo><nProt>%s</nProt></%s>`,
+			element, eventVersion, EnvironmentHomologation, code, message, protocol, element)),
+	}
+}
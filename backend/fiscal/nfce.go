@@ -0,0 +1,560 @@
+package fiscal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+const (
+	namespaceNFe       = "http://www.portalfiscal.inf.br/nfe"
+	homologationNotice = "NF-E EMITIDA EM AMBIENTE DE HOMOLOGACAO - SEM VALOR FISCAL"
+	homologationItem   = "NOTA FISCAL EMITIDA EM AMBIENTE DE HOMOLOGACAO - SEM VALOR FISCAL"
+	dateTimeLayout     = "2006-01-02T15:04:05-07:00"
+)
+
+// paymentCodes mapeia a forma de pagamento da venda para o código tPag da NFC-e
+var paymentCodes = map[string]string{
+	"dinheiro":       "01",
+	"cartao_credito": "03",
+	"cartao_debito":  "04",
+	"pix":            "17",
+}
+
+// Invoice reúne a numeração e o tipo de emissão de uma NFC-e
+type Invoice struct {
+	Series            int
+	Number            int
+	Code              int // cNF
+	EmissionType      int
+	IssuedAt          time.Time
+	ContingencyAt     time.Time
+	ContingencyReason string
+}
+
+// infNFe e os grupos abaixo seguem o leiaute 4.00 da NF-e/NFC-e. A ordem dos campos
+// é a ordem exigida pelo schema e os atributos já estão na ordem canônica (C14N)
+type infNFe struct {
+	XMLName xml.Name `xml:"infNFe"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	ID      string   `xml:"Id,attr"`
+	Version string   `xml:"versao,attr"`
+	Ide     ide      `xml:"ide"`
+	Emit    emit     `xml:"emit"`
+	Dest    *dest    `xml:"dest,omitempty"`
+	Det     []det    `xml:"det"`
+	Total   total    `xml:"total"`
+	Transp  transp   `xml:"transp"`
+	Pag     pag      `xml:"pag"`
+	InfAdic *infAdic `xml:"infAdic,omitempty"`
+}
+
+type ide struct {
+	CUF      int    `xml:"cUF"`
+	CNF      string `xml:"cNF"`
+	NatOp    string `xml:"natOp"`
+	Mod      int    `xml:"mod"`
+	Serie    int    `xml:"serie"`
+	NNF      int    `xml:"nNF"`
+	DhEmi    string `xml:"dhEmi"`
+	TpNF     int    `xml:"tpNF"`
+	IDDest   int    `xml:"idDest"`
+	CMunFG   string `xml:"cMunFG"`
+	TpImp    int    `xml:"tpImp"`
+	TpEmis   int    `xml:"tpEmis"`
+	CDV      int    `xml:"cDV"`
+	TpAmb    int    `xml:"tpAmb"`
+	FinNFe   int    `xml:"finNFe"`
+	IndFinal int    `xml:"indFinal"`
+	IndPres  int    `xml:"indPres"`
+	ProcEmi  int    `xml:"procEmi"`
+	VerProc  string `xml:"verProc"`
+	DhCont   string `xml:"dhCont,omitempty"`
+	XJust    string `xml:"xJust,omitempty"`
+}
+
+type emit struct {
+	CNPJ      string    `xml:"CNPJ"`
+	XNome     string    `xml:"xNome"`
+	XFant     string    `xml:"xFant,omitempty"`
+	EnderEmit enderEmit `xml:"enderEmit"`
+	IE        string    `xml:"IE"`
+	CRT       int       `xml:"CRT"`
+}
+
+type enderEmit struct {
+	XLgr    string `xml:"xLgr"`
+	Nro     string `xml:"nro"`
+	XBairro string `xml:"xBairro"`
+	CMun    string `xml:"cMun"`
+	XMun    string `xml:"xMun"`
+	UF      string `xml:"UF"`
+	CEP     string `xml:"CEP"`
+	CPais   string `xml:"cPais"`
+	XPais   string `xml:"xPais"`
+	Fone    string `xml:"fone,omitempty"`
+}
+
+type dest struct {
+	CNPJ      string `xml:"CNPJ,omitempty"`
+	CPF       string `xml:"CPF,omitempty"`
+	XNome     string `xml:"xNome,omitempty"`
+	IndIEDest int    `xml:"indIEDest"`
+}
+
+type det struct {
+	NItem   int     `xml:"nItem,attr"`
+	Prod    prod    `xml:"prod"`
+	Imposto imposto `xml:"imposto"`
+}
+
+type prod struct {
+	CProd    string `xml:"cProd"`
+	CEAN     string `xml:"cEAN"`
+	XProd    string `xml:"xProd"`
+	NCM      string `xml:"NCM"`
+	CEST     string `xml:"CEST,omitempty"`
+	CFOP     string `xml:"CFOP"`
+	UCom     string `xml:"uCom"`
+	QCom     string `xml:"qCom"`
+	VUnCom   string `xml:"vUnCom"`
+	VProd    string `xml:"vProd"`
+	CEANTrib string `xml:"cEANTrib"`
+	UTrib    string `xml:"uTrib"`
+	QTrib    string `xml:"qTrib"`
+	VUnTrib  string `xml:"vUnTrib"`
+	VDesc    string `xml:"vDesc,omitempty"`
+	VOutro   string `xml:"vOutro,omitempty"`
+	IndTot   int    `xml:"indTot"`
+}
+
+type imposto struct {
+	ICMS icms `xml:"ICMS"`
+}
+
+// icms contém apenas um dos grupos de tributação, conforme o CST/CSOSN do produto
+type icms struct {
+	ICMS00    *icms00  `xml:"ICMS00,omitempty"`
+	ICMS40    *icmsCST `xml:"ICMS40,omitempty"`
+	ICMS60    *icmsCST `xml:"ICMS60,omitempty"`
+	ICMSSN102 *icmsSN  `xml:"ICMSSN102,omitempty"`
+	ICMSSN500 *icmsSN  `xml:"ICMSSN500,omitempty"`
+	ICMSSN900 *icmsSN  `xml:"ICMSSN900,omitempty"`
+}
+
+type icms00 struct {
+	Orig  int    `xml:"orig"`
+	CST   string `xml:"CST"`
+	ModBC int    `xml:"modBC"`
+	VBC   string `xml:"vBC"`
+	PICMS string `xml:"pICMS"`
+	VICMS string `xml:"vICMS"`
+}
+
+type icmsCST struct {
+	Orig int    `xml:"orig"`
+	CST  string `xml:"CST"`
+}
+
+type icmsSN struct {
+	Orig  int    `xml:"orig"`
+	CSOSN string `xml:"CSOSN"`
+}
+
+type total struct {
+	ICMSTot icmsTot `xml:"ICMSTot"`
+}
+
+type icmsTot struct {
+	VBC        string `xml:"vBC"`
+	VICMS      string `xml:"vICMS"`
+	VICMSDeson string `xml:"vICMSDeson"`
+	VFCP       string `xml:"vFCP"`
+	VBCST      string `xml:"vBCST"`
+	VST        string `xml:"vST"`
+	VFCPST     string `xml:"vFCPST"`
+	VFCPSTRet  string `xml:"vFCPSTRet"`
+	VProd      string `xml:"vProd"`
+	VFrete     string `xml:"vFrete"`
+	VSeg       string `xml:"vSeg"`
+	VDesc      string `xml:"vDesc"`
+	VII        string `xml:"vII"`
+	VIPI       string `xml:"vIPI"`
+	VIPIDevol  string `xml:"vIPIDevol"`
+	VPIS       string `xml:"vPIS"`
+	VCOFINS    string `xml:"vCOFINS"`
+	VOutro     string `xml:"vOutro"`
+	VNF        string `xml:"vNF"`
+}
+
+type transp struct {
+	ModFrete int `xml:"modFrete"`
+}
+
+type pag struct {
+	DetPag []detPag `xml:"detPag"`
+	VTroco string   `xml:"vTroco,omitempty"`
+}
+
+type detPag struct {
+	TPag string `xml:"tPag"`
+	VPag string `xml:"vPag"`
+	Card *card  `xml:"card,omitempty"`
+}
+
+type card struct {
+	TpIntegra int `xml:"tpIntegra"`
+}
+
+type infAdic struct {
+	InfCpl string `xml:"infCpl,omitempty"`
+}
+
+// Validate verifica se os dados do emitente permitem emitir NFC-e
+func (c Config) Validate() error {
+	switch {
+	case c.UFCode() == 0:
+		return fmt.Errorf("UF do emitente inválida: %s", c.UF)
+	case len(c.CNPJ) != 14:
+		return errors.New("CNPJ do emitente deve ter 14 dígitos")
+	case c.IE == "":
+		return errors.New("Inscrição estadual do emitente não informada")
+	case c.Name == "":
+		return errors.New("Razão social do emitente não informada")
+	case len(c.CityCode) != 7:
+		return errors.New("Código IBGE do município do emitente deve ter 7 dígitos")
+	case c.CSC == "" || c.CSCID == "":
+		return errors.New("CSC e identificador do CSC são obrigatórios para o QR Code da NFC-e")
+	case c.Environment != EnvironmentProduction && c.Environment != EnvironmentHomologation:
+		return errors.New("Ambiente fiscal deve ser 1 (produção) ou 2 (homologação)")
+	}
+	return nil
+}
+
+// buildNFCe monta o grupo infNFe da NFC-e de uma venda e retorna a chave de acesso
+func buildNFCe(cfg Config, sale *models.Sale, invoice Invoice) (*infNFe, string, error) {
+	if len(sale.SaleItems) == 0 {
+		return nil, "", errors.New("Venda sem itens")
+	}
+
+	key := AccessKey{
+		UFCode:       cfg.UFCode(),
+		IssuedAt:     invoice.IssuedAt,
+		CNPJ:         cfg.CNPJ,
+		Model:        ModelNFCe,
+		Series:       invoice.Series,
+		Number:       invoice.Number,
+		EmissionType: invoice.EmissionType,
+		Code:         invoice.Code,
+	}
+	accessKey := key.String()
+
+	doc := &infNFe{
+		Xmlns:   namespaceNFe,
+		ID:      "NFe" + accessKey,
+		Version: LayoutVersion,
+		Ide: ide{
+			CUF:      key.UFCode,
+			CNF:      fmt.Sprintf("%08d", invoice.Code),
+			NatOp:    cfg.NatureOfSale,
+			Mod:      ModelNFCe,
+			Serie:    invoice.Series,
+			NNF:      invoice.Number,
+			DhEmi:    invoice.IssuedAt.Format(dateTimeLayout),
+			TpNF:     1,
+			IDDest:   1,
+			CMunFG:   cfg.CityCode,
+			TpImp:    4, // DANFE NFC-e
+			TpEmis:   invoice.EmissionType,
+			CDV:      key.CheckDigit(),
+			TpAmb:    cfg.Environment,
+			FinNFe:   1,
+			IndFinal: 1,
+			IndPres:  1,
+			ProcEmi:  0,
+			VerProc:  ApplicationVersion,
+		},
+		Emit: emit{
+			CNPJ:  cfg.CNPJ,
+			XNome: sanitize(cfg.Name, 60),
+			XFant: sanitize(cfg.TradeName, 60),
+			EnderEmit: enderEmit{
+				XLgr:    sanitize(cfg.Street, 60),
+				Nro:     sanitize(cfg.Number, 60),
+				XBairro: sanitize(cfg.District, 60),
+				CMun:    cfg.CityCode,
+				XMun:    sanitize(cfg.City, 60),
+				UF:      cfg.UF,
+				CEP:     cfg.ZipCode,
+				CPais:   "1058",
+				XPais:   "BRASIL",
+				Fone:    cfg.Phone,
+			},
+			IE:  cfg.IE,
+			CRT: cfg.CRT,
+		},
+		Transp:  transp{ModFrete: 9}, // sem frete
+		InfAdic: &infAdic{InfCpl: fmt.Sprintf("Venda %d", sale.ID)},
+	}
+
+	if invoice.EmissionType == EmissionOfflineNFCe {
+		doc.Ide.DhCont = invoice.ContingencyAt.Format(dateTimeLayout)
+		doc.Ide.XJust = sanitize(invoice.ContingencyReason, 256)
+	}
+
+	// Consumidor identificado
+	if sale.Customer != nil && sale.Customer.Document != nil {
+		recipient := &dest{IndIEDest: 9}
+		if sale.Customer.DocumentType == "cnpj" {
+			recipient.CNPJ = *sale.Customer.Document
+		} else {
+			recipient.CPF = *sale.Customer.Document
+		}
+		recipient.XNome = sanitize(sale.Customer.Name, 60)
+		if cfg.Environment == EnvironmentHomologation {
+			recipient.XNome = homologationNotice
+		}
+		doc.Dest = recipient
+	}
+
+	// Itens: desconto e acréscimo da venda são rateados proporcionalmente ao valor dos itens;
+	// o desconto das promoções de cada item compõe o vDesc do próprio item
+	itemTotals := make([]models.Money, len(sale.SaleItems))
+	var itemDiscounts models.Money
+	for i, item := range sale.SaleItems {
+		itemTotals[i] = item.Total
+		itemDiscounts += item.Discount
+	}
+	discounts := apportion(sale.Discount, itemTotals)
+	surcharges := apportion(sale.Tax, itemTotals)
+
+	var totalICMSBase, totalICMS models.Money
+	for i, item := range sale.SaleItems {
+		product := item.Product
+		if product.NCM == "" {
+			return nil, "", fmt.Errorf("Produto %s sem NCM cadastrado", product.Name)
+		}
+
+		cfop := product.CFOP
+		if cfop == "" {
+			cfop = cfg.DefaultCFOP
+		}
+
+		name := sanitize(item.Description(), 120)
+		if i == 0 && cfg.Environment == EnvironmentHomologation {
+			name = homologationItem
+		}
+
+		unit := strings.ToUpper(product.Unit)
+		if unit == "" {
+			unit = "UN"
+		}
+
+		gtin := "SEM GTIN"
+		if isGTIN(item.Barcode()) {
+			gtin = item.Barcode()
+		}
+
+		// Variações recebem código próprio (produto-variação)
+		code := strconv.FormatUint(uint64(product.ID), 10)
+		if item.VariantID != nil {
+			code += "-" + strconv.FormatUint(uint64(*item.VariantID), 10)
+		}
+
+		quantity := strconv.FormatFloat(item.Quantity, 'f', 4, 64)
+		itemProd := prod{
+			CProd:    code,
+			CEAN:     gtin,
+			XProd:    name,
+			NCM:      product.NCM,
+			CEST:     product.CEST,
+			CFOP:     cfop,
+			UCom:     unit,
+			QCom:     quantity,
+			VUnCom:   item.UnitPrice.String(),
+			VProd:    (item.Total + item.Discount).String(),
+			CEANTrib: gtin,
+			UTrib:    unit,
+			QTrib:    quantity,
+			VUnTrib:  item.UnitPrice.String(),
+			VDesc:    optionalMoney(item.Discount + discounts[i]),
+			VOutro:   optionalMoney(surcharges[i]),
+			IndTot:   1,
+		}
+
+		base := item.Total - discounts[i] + surcharges[i]
+		tax, icmsValue, err := buildICMS(cfg, &product, base)
+		if err != nil {
+			return nil, "", err
+		}
+		if tax.ICMS00 != nil {
+			totalICMSBase += base
+			totalICMS += icmsValue
+		}
+
+		doc.Det = append(doc.Det, det{NItem: i + 1, Prod: itemProd, Imposto: imposto{ICMS: tax}})
+	}
+
+	zero := models.Money(0).String()
+	doc.Total.ICMSTot = icmsTot{
+		VBC:        totalICMSBase.String(),
+		VICMS:      totalICMS.String(),
+		VICMSDeson: zero,
+		VFCP:       zero,
+		VBCST:      zero,
+		VST:        zero,
+		VFCPST:     zero,
+		VFCPSTRet:  zero,
+		VProd:      (sale.Total + itemDiscounts).String(),
+		VFrete:     zero,
+		VSeg:       zero,
+		VDesc:      (sale.Discount + itemDiscounts).String(),
+		VII:        zero,
+		VIPI:       zero,
+		VIPIDevol:  zero,
+		VPIS:       zero,
+		VCOFINS:    zero,
+		VOutro:     sale.Tax.String(),
+		VNF:        sale.FinalTotal.String(),
+	}
+
+	// Pagamentos: vPag é o valor entregue e vTroco o troco devolvido
+	var change models.Money
+	for _, payment := range sale.Payments {
+		code, ok := paymentCodes[payment.PaymentType]
+		if !ok {
+			return nil, "", fmt.Errorf("Forma de pagamento sem código fiscal: %s", payment.PaymentType)
+		}
+
+		paid := payment.AmountReceived
+		if paid == 0 {
+			paid = payment.Amount
+		}
+		detail := detPag{TPag: code, VPag: paid.String()}
+		if code == "03" || code == "04" {
+			detail.Card = &card{TpIntegra: 2} // pagamento não integrado ao sistema de automação
+		}
+		doc.Pag.DetPag = append(doc.Pag.DetPag, detail)
+		change += payment.Change
+	}
+	if len(doc.Pag.DetPag) == 0 {
+		return nil, "", errors.New("Venda sem pagamentos registrados")
+	}
+	doc.Pag.VTroco = optionalMoney(change)
+
+	return doc, accessKey, nil
+}
+
+// buildICMS monta o grupo de ICMS do item conforme o regime tributário do emitente
+func buildICMS(cfg Config, product *models.Product, base models.Money) (icms, models.Money, error) {
+	code := product.CST
+
+	// Simples Nacional: CSOSN
+	if cfg.CRT == 1 || cfg.CRT == 2 {
+		if code == "" {
+			code = "102"
+		}
+		group := &icmsSN{Orig: product.Origin, CSOSN: code}
+		switch code {
+		case "102", "103", "300", "400":
+			return icms{ICMSSN102: group}, 0, nil
+		case "500":
+			return icms{ICMSSN500: group}, 0, nil
+		case "900":
+			return icms{ICMSSN900: group}, 0, nil
+		}
+		return icms{}, 0, fmt.Errorf("CSOSN %s não suportado (produto %s)", code, product.Name)
+	}
+
+	// Regime normal: CST
+	switch code {
+	case "00":
+		value := base.Percent(product.ICMSRate)
+		return icms{ICMS00: &icms00{
+			Orig:  product.Origin,
+			CST:   code,
+			ModBC: 3, // valor da operação
+			VBC:   base.String(),
+			PICMS: strconv.FormatFloat(product.ICMSRate, 'f', 2, 64),
+			VICMS: value.String(),
+		}}, value, nil
+	case "40", "41", "50":
+		return icms{ICMS40: &icmsCST{Orig: product.Origin, CST: code}}, 0, nil
+	case "60":
+		return icms{ICMS60: &icmsCST{Orig: product.Origin, CST: code}}, 0, nil
+	case "":
+		return icms{}, 0, fmt.Errorf("Produto %s sem CST cadastrado", product.Name)
+	}
+	return icms{}, 0, fmt.Errorf("CST %s não suportado (produto %s)", code, product.Name)
+}
+
+// apportion rateia um valor proporcionalmente aos pesos, lançando a diferença de
+// arredondamento no último item para que a soma seja exata
+func apportion(value models.Money, weights []models.Money) []models.Money {
+	shares := make([]models.Money, len(weights))
+	if value == 0 || len(weights) == 0 {
+		return shares
+	}
+
+	var sum models.Money
+	for _, weight := range weights {
+		sum += weight
+	}
+	if sum == 0 {
+		shares[len(shares)-1] = value
+		return shares
+	}
+
+	var allocated models.Money
+	for i, weight := range weights {
+		if i == len(weights)-1 {
+			shares[i] = value - allocated
+			break
+		}
+		shares[i] = models.Money(int64(value) * int64(weight) / int64(sum))
+		allocated += shares[i]
+	}
+	return shares
+}
+
+// marshalCanonical serializa o elemento no formato canônico usado na assinatura
+func marshalCanonical(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// encoding/xml escapa aspas no texto, o que a canonicalização não faz
+	data = bytes.ReplaceAll(data, []byte("&#34;"), []byte(`"`))
+	data = bytes.ReplaceAll(data, []byte("&#39;"), []byte("'"))
+	return data, nil
+}
+
+// sanitize remove quebras de linha e espaços repetidos e limita o tamanho do texto
+func sanitize(value string, limit int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	runes := []rune(value)
+	if len(runes) > limit {
+		runes = runes[:limit]
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+func optionalMoney(value models.Money) string {
+	if value == 0 {
+		return ""
+	}
+	return value.String()
+}
+
+// isGTIN verifica se o código de barras é um GTIN válido (8, 12, 13 ou 14 dígitos com dígito
+// verificador correto); códigos internos são informados como "SEM GTIN"
+func isGTIN(code string) bool {
+	return validators.ValidateGTIN(code)
+}
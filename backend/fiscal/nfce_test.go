@@ -0,0 +1,164 @@
+package fiscal
+
+import (
+	"testing"
+	"time"
+
+	"pdv-backend/models"
+)
+
+// testConfig retorna um emitente do Simples Nacional em homologação
+func testConfig() Config {
+	return Config{
+		Enabled:            true,
+		Environment:        EnvironmentHomologation,
+		UF:                 "SP",
+		Series:             1,
+		FirstNumber:        1,
+		CNPJ:               "11222333000181",
+		IE:                 "111222333444",
+		CRT:                1,
+		Name:               "Empresa de Teste",
+		CityCode:           "3550308",
+		City:               "Sao Paulo",
+		DefaultCFOP:        "5102",
+		NatureOfSale:       "VENDA",
+		CSCID:              "1",
+		CSC:                "CSC-TESTE",
+		Transport:          "mock",
+		QRCodeURL:          "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode",
+		ConsultURL:         "https://www.homologacao.nfce.fazenda.sp.gov.br/consulta",
+		Timeout:            time.Second,
+		RetransmitInterval: time.Minute,
+		CancelWindow:       30 * time.Minute,
+	}
+}
+
+// testSaleItem monta um item com o total líquido e o desconto de promoção informados
+func testSaleItem(productID uint, total, promotion models.Money) models.SaleItem {
+	return models.SaleItem{
+		ProductID: productID,
+		Quantity:  1,
+		UnitPrice: total + promotion,
+		Total:     total,
+		Discount:  promotion,
+		Product:   models.Product{ID: productID, Name: "Produto de teste", NCM: "22021000", Unit: "un"},
+	}
+}
+
+// testSale monta uma venda concluída, paga em dinheiro, com os itens, o desconto e o
+// acréscimo informados
+func testSale(items []models.SaleItem, discount, tax models.Money) *models.Sale {
+	sale := &models.Sale{ID: 1, Discount: discount, Tax: tax, Status: "completed", SaleItems: items}
+	for _, item := range items {
+		sale.Total += item.Total
+	}
+	sale.FinalTotal = sale.Total - discount + tax
+	sale.Payments = []models.SalePayment{{PaymentType: "dinheiro", Amount: sale.FinalTotal}}
+	return sale
+}
+
+func TestBuildNFCeDiscountApportionment(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []models.SaleItem
+		discount  models.Money
+		tax       models.Money
+		itemDesc  []string // vDesc de cada item (desconto da promoção + rateio do desconto da venda)
+		itemOther []string // vOutro de cada item (rateio do acréscimo)
+		itemProd  []string // vProd de cada item (valor bruto)
+		totalDesc string
+		totalProd string
+	}{
+		{
+			name:      "sem desconto",
+			items:     []models.SaleItem{testSaleItem(1, 1000, 0), testSaleItem(2, 2000, 0)},
+			itemDesc:  []string{"", ""},
+			itemOther: []string{"", ""},
+			itemProd:  []string{"10.00", "20.00"},
+			totalDesc: "0.00",
+			totalProd: "30.00",
+		},
+		{
+			name:      "desconto proporcional com arredondamento no último item",
+			items:     []models.SaleItem{testSaleItem(1, 1000, 0), testSaleItem(2, 2000, 0), testSaleItem(3, 3001, 0)},
+			discount:  1000,
+			itemDesc:  []string{"1.66", "3.33", "5.01"},
+			itemOther: []string{"", "", ""},
+			itemProd:  []string{"10.00", "20.00", "30.01"},
+			totalDesc: "10.00",
+			totalProd: "60.01",
+		},
+		{
+			name:      "desconto da promoção somado ao rateio",
+			items:     []models.SaleItem{testSaleItem(1, 1800, 200), testSaleItem(2, 1200, 0)},
+			discount:  300,
+			itemDesc:  []string{"3.80", "1.20"},
+			itemOther: []string{"", ""},
+			itemProd:  []string{"20.00", "12.00"},
+			totalDesc: "5.00",
+			totalProd: "32.00",
+		},
+		{
+			name:      "acréscimo rateado em vOutro",
+			items:     []models.SaleItem{testSaleItem(1, 500, 0), testSaleItem(2, 500, 0)},
+			tax:       101,
+			itemDesc:  []string{"", ""},
+			itemOther: []string{"0.50", "0.51"},
+			itemProd:  []string{"5.00", "5.00"},
+			totalDesc: "0.00",
+			totalProd: "10.00",
+		},
+		{
+			name:      "desconto e acréscimo na mesma venda",
+			items:     []models.SaleItem{testSaleItem(1, 750, 0), testSaleItem(2, 250, 0)},
+			discount:  100,
+			tax:       40,
+			itemDesc:  []string{"0.75", "0.25"},
+			itemOther: []string{"0.30", "0.10"},
+			itemProd:  []string{"7.50", "2.50"},
+			totalDesc: "1.00",
+			totalProd: "10.00",
+		},
+	}
+
+	invoice := Invoice{Series: 1, Number: 1, Code: 12345678, EmissionType: EmissionNormal, IssuedAt: time.Now()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale := testSale(tt.items, tt.discount, tt.tax)
+			doc, _, err := buildNFCe(testConfig(), sale, invoice)
+			if err != nil {
+				t.Fatalf("erro ao montar NFC-e: %v", err)
+			}
+			if len(doc.Det) != len(tt.items) {
+				t.Fatalf("%d itens na NFC-e, esperado %d", len(doc.Det), len(tt.items))
+			}
+
+			for i, item := range doc.Det {
+				if item.Prod.VDesc != tt.itemDesc[i] {
+					t.Errorf("item %d: vDesc = %q, esperado %q", i+1, item.Prod.VDesc, tt.itemDesc[i])
+				}
+				if item.Prod.VOutro != tt.itemOther[i] {
+					t.Errorf("item %d: vOutro = %q, esperado %q", i+1, item.Prod.VOutro, tt.itemOther[i])
+				}
+				if item.Prod.VProd != tt.itemProd[i] {
+					t.Errorf("item %d: vProd = %q, esperado %q", i+1, item.Prod.VProd, tt.itemProd[i])
+				}
+			}
+
+			totals := doc.Total.ICMSTot
+			if totals.VDesc != tt.totalDesc {
+				t.Errorf("vDesc total = %s, esperado %s", totals.VDesc, tt.totalDesc)
+			}
+			if totals.VProd != tt.totalProd {
+				t.Errorf("vProd total = %s, esperado %s", totals.VProd, tt.totalProd)
+			}
+			if totals.VOutro != tt.tax.String() {
+				t.Errorf("vOutro total = %s, esperado %s", totals.VOutro, tt.tax.String())
+			}
+			if totals.VNF != sale.FinalTotal.String() {
+				t.Errorf("vNF = %s, esperado %s", totals.VNF, sale.FinalTotal.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package fiscal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// ErrInvalidNFe indica um XML que não é uma NF-e (procNFe ou NFe) reconhecível
+var ErrInvalidNFe = errors.New("XML não é uma NF-e válida")
+
+// IncomingNFe representa uma NF-e de fornecedor lida do XML recebido
+type IncomingNFe struct {
+	AccessKey string
+	Model     int
+	Series    int
+	Number    int
+	IssuedAt  time.Time
+	Protocol  string // protocolo de autorização, quando o XML é o nfeProc
+	Issuer    IncomingParty
+	Recipient IncomingParty
+	Items     []IncomingItem
+	Total     models.Money // vNF
+}
+
+// IncomingParty representa o emitente ou destinatário da NF-e
+type IncomingParty struct {
+	Document  string // CNPJ ou CPF
+	Name      string
+	TradeName string
+	StateReg  string
+	Phone     string
+	ZipCode   string
+	Street    string
+	Number    string
+	District  string
+	City      string
+	State     string
+}
+
+// IncomingItem representa um item (det) da NF-e
+type IncomingItem struct {
+	Line         int
+	Code         string // código do produto no fornecedor (cProd)
+	EAN          string // GTIN comercial, vazio se "SEM GTIN"
+	EANTrib      string // GTIN tributável, vazio se "SEM GTIN"
+	Description  string
+	NCM          string
+	CEST         string
+	CFOP         string
+	Unit         string
+	Quantity     float64
+	UnitTrib     string  // unidade tributável (uTrib)
+	QuantityTrib float64 // quantidade tributável (qTrib)
+	UnitPrice    models.Money
+	Total        models.Money // vProd
+	Discount     models.Money
+	Freight      models.Money
+	Insurance    models.Money
+	Other        models.Money
+	IPI          models.Money
+	ICMSST       models.Money
+}
+
+// Cost retorna o custo total do item: valor dos produtos menos desconto, mais frete, seguro,
+// outras despesas, IPI e ICMS-ST
+func (i IncomingItem) Cost() models.Money {
+	return i.Total - i.Discount + i.Freight + i.Insurance + i.Other + i.IPI + i.ICMSST
+}
+
+// Estruturas de leitura: o encoding/xml compara apenas o nome local dos elementos, o que
+// aceita tanto o nfeProc quanto a NFe isolada
+type incomingProc struct {
+	NFe     incomingDocument `xml:"NFe"`
+	ProtNFe struct {
+		InfProt struct {
+			ChNFe string `xml:"chNFe"`
+			NProt string `xml:"nProt"`
+			CStat int    `xml:"cStat"`
+		} `xml:"infProt"`
+	} `xml:"protNFe"`
+}
+
+type incomingDocument struct {
+	InfNFe struct {
+		ID  string `xml:"Id,attr"`
+		Ide struct {
+			Mod   int    `xml:"mod"`
+			Serie int    `xml:"serie"`
+			NNF   int    `xml:"nNF"`
+			DhEmi string `xml:"dhEmi"`
+		} `xml:"ide"`
+		Emit struct {
+			incomingPartyXML
+			Ender incomingAddress `xml:"enderEmit"`
+		} `xml:"emit"`
+		Dest struct {
+			incomingPartyXML
+			Ender incomingAddress `xml:"enderDest"`
+		} `xml:"dest"`
+		Det []struct {
+			NItem int `xml:"nItem,attr"`
+			Prod  struct {
+				CProd    string `xml:"cProd"`
+				CEAN     string `xml:"cEAN"`
+				XProd    string `xml:"xProd"`
+				NCM      string `xml:"NCM"`
+				CEST     string `xml:"CEST"`
+				CFOP     string `xml:"CFOP"`
+				UCom     string `xml:"uCom"`
+				QCom     string `xml:"qCom"`
+				VUnCom   string `xml:"vUnCom"`
+				VProd    string `xml:"vProd"`
+				CEANTrib string `xml:"cEANTrib"`
+				UTrib    string `xml:"uTrib"`
+				QTrib    string `xml:"qTrib"`
+				VFrete   string `xml:"vFrete"`
+				VSeg     string `xml:"vSeg"`
+				VDesc    string `xml:"vDesc"`
+				VOutro   string `xml:"vOutro"`
+			} `xml:"prod"`
+			Imposto struct {
+				ICMS struct {
+					Groups []struct {
+						VICMSST string `xml:"vICMSST"`
+					} `xml:",any"`
+				} `xml:"ICMS"`
+				IPI struct {
+					IPITrib struct {
+						VIPI string `xml:"vIPI"`
+					} `xml:"IPITrib"`
+				} `xml:"IPI"`
+			} `xml:"imposto"`
+		} `xml:"det"`
+		Total struct {
+			ICMSTot struct {
+				VNF string `xml:"vNF"`
+			} `xml:"ICMSTot"`
+		} `xml:"total"`
+	} `xml:"infNFe"`
+}
+
+type incomingPartyXML struct {
+	CNPJ  string `xml:"CNPJ"`
+	CPF   string `xml:"CPF"`
+	XNome string `xml:"xNome"`
+	XFant string `xml:"xFant"`
+	IE    string `xml:"IE"`
+}
+
+type incomingAddress struct {
+	XLgr    string `xml:"xLgr"`
+	Nro     string `xml:"nro"`
+	XBairro string `xml:"xBairro"`
+	XMun    string `xml:"xMun"`
+	UF      string `xml:"UF"`
+	CEP     string `xml:"CEP"`
+	Fone    string `xml:"fone"`
+}
+
+// ParseNFe lê o XML de uma NF-e recebida de fornecedor (nfeProc ou NFe)
+func ParseNFe(data []byte) (*IncomingNFe, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, ErrInvalidNFe
+	}
+
+	var document incomingDocument
+	var protocol string
+	switch root {
+	case "nfeProc":
+		var proc incomingProc
+		if err := xml.Unmarshal(data, &proc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNFe, err)
+		}
+		document = proc.NFe
+		protocol = proc.ProtNFe.InfProt.NProt
+	case "NFe":
+		if err := xml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNFe, err)
+		}
+	default:
+		return nil, ErrInvalidNFe
+	}
+
+	inf := document.InfNFe
+	accessKey := strings.TrimPrefix(inf.ID, "NFe")
+	if !ValidAccessKey(accessKey) {
+		return nil, fmt.Errorf("%w: chave de acesso inválida", ErrInvalidNFe)
+	}
+	if len(inf.Det) == 0 {
+		return nil, fmt.Errorf("%w: nenhum item encontrado", ErrInvalidNFe)
+	}
+
+	nfe := &IncomingNFe{
+		AccessKey: accessKey,
+		Model:     inf.Ide.Mod,
+		Series:    inf.Ide.Serie,
+		Number:    inf.Ide.NNF,
+		Protocol:  protocol,
+		Issuer:    incomingParty(inf.Emit.incomingPartyXML, inf.Emit.Ender),
+		Recipient: incomingParty(inf.Dest.incomingPartyXML, inf.Dest.Ender),
+	}
+	if issuedAt, err := time.Parse(dateTimeLayout, inf.Ide.DhEmi); err == nil {
+		nfe.IssuedAt = issuedAt
+	}
+
+	var parseErr error
+	money := func(value string) models.Money {
+		if value == "" {
+			return 0
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil && parseErr == nil {
+			parseErr = fmt.Errorf("%w: valor inválido %q", ErrInvalidNFe, value)
+		}
+		return models.NewMoney(parsed)
+	}
+
+	for _, det := range inf.Det {
+		prod := det.Prod
+		quantity, err := strconv.ParseFloat(strings.TrimSpace(prod.QCom), 64)
+		if err != nil || quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantidade inválida no item %d", ErrInvalidNFe, det.NItem)
+		}
+
+		item := IncomingItem{
+			Line:        det.NItem,
+			Code:        strings.TrimSpace(prod.CProd),
+			EAN:         incomingGTIN(prod.CEAN),
+			EANTrib:     incomingGTIN(prod.CEANTrib),
+			Description: strings.TrimSpace(prod.XProd),
+			NCM:         prod.NCM,
+			CEST:        prod.CEST,
+			CFOP:        prod.CFOP,
+			Unit:        strings.TrimSpace(prod.UCom),
+			Quantity:    models.RoundQuantity(quantity),
+			UnitTrib:    strings.TrimSpace(prod.UTrib),
+			UnitPrice:   money(prod.VUnCom),
+			Total:       money(prod.VProd),
+			Discount:    money(prod.VDesc),
+			Freight:     money(prod.VFrete),
+			Insurance:   money(prod.VSeg),
+			Other:       money(prod.VOutro),
+			IPI:         money(det.Imposto.IPI.IPITrib.VIPI),
+		}
+		if quantityTrib, err := strconv.ParseFloat(strings.TrimSpace(prod.QTrib), 64); err == nil && quantityTrib > 0 {
+			item.QuantityTrib = models.RoundQuantity(quantityTrib)
+		}
+		for _, group := range det.Imposto.ICMS.Groups {
+			item.ICMSST += money(group.VICMSST)
+		}
+		nfe.Items = append(nfe.Items, item)
+	}
+	nfe.Total = money(inf.Total.ICMSTot.VNF)
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return nfe, nil
+}
+
+// rootElement retorna o nome local do elemento raiz do XML
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func incomingParty(party incomingPartyXML, address incomingAddress) IncomingParty {
+	document := party.CNPJ
+	if document == "" {
+		document = party.CPF
+	}
+	return IncomingParty{
+		Document:  validators.OnlyDigits(document),
+		Name:      strings.TrimSpace(party.XNome),
+		TradeName: strings.TrimSpace(party.XFant),
+		StateReg:  strings.TrimSpace(party.IE),
+		Phone:     validators.OnlyDigits(address.Fone),
+		ZipCode:   validators.OnlyDigits(address.CEP),
+		Street:    strings.TrimSpace(address.XLgr),
+		Number:    strings.TrimSpace(address.Nro),
+		District:  strings.TrimSpace(address.XBairro),
+		City:      strings.TrimSpace(address.XMun),
+		State:     strings.ToUpper(strings.TrimSpace(address.UF)),
+	}
+}
+
+// incomingGTIN normaliza o GTIN informado na nota ("SEM GTIN" ou vazio resultam em "")
+func incomingGTIN(code string) string {
+	code = strings.TrimSpace(code)
+	if !isGTIN(code) {
+		return ""
+	}
+	return code
+}
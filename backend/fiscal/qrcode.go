@@ -0,0 +1,37 @@
+package fiscal
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/models"
+)
+
+// qrCodeVersion é a versão do QR Code da NFC-e (NT 2015.002)
+const qrCodeVersion = 2
+
+// QRCodeURL monta a URL do QR Code impresso no DANFE NFC-e. Na emissão normal o QR Code
+// leva apenas a chave; em contingência off-line inclui dia, valor e DigestValue da nota
+func QRCodeURL(cfg Config, accessKey string, emissionType int, issuedAt time.Time, total models.Money, digestValue string) string {
+	tokenID := strings.TrimLeft(cfg.CSCID, "0")
+	if id, err := strconv.Atoi(cfg.CSCID); err == nil {
+		tokenID = strconv.Itoa(id)
+	}
+
+	var params string
+	if emissionType == EmissionOfflineNFCe {
+		params = fmt.Sprintf("%s|%d|%d|%s|%s|%s|%s",
+			accessKey, qrCodeVersion, cfg.Environment,
+			issuedAt.Format("02"), total.String(),
+			hex.EncodeToString([]byte(digestValue)), tokenID)
+	} else {
+		params = fmt.Sprintf("%s|%d|%d|%s", accessKey, qrCodeVersion, cfg.Environment, tokenID)
+	}
+
+	hash := sha1.Sum([]byte(params + cfg.CSC))
+	return cfg.QRCodeURL + "?p=" + params + "|" + strings.ToUpper(hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,427 @@
+package fiscal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// Default é o serviço fiscal da aplicação; nil quando a emissão está desabilitada
+var Default *Service
+
+// ErrDisabled indica que a emissão fiscal não está habilitada
+var ErrDisabled = errors.New("Emissão fiscal desabilitada")
+
+// ErrNotEmitter indica que a organização não é a emitente configurada (FISCAL_CNPJ)
+var ErrNotEmitter = errors.New("Emissão fiscal não configurada para esta organização")
+
+// Service emite, transmite e armazena as NFC-e das vendas
+type Service struct {
+	db        *gorm.DB
+	cfg       Config
+	transport Transport
+	signer    *Signer
+
+	mu           sync.Mutex
+	offlineUntil time.Time
+}
+
+// NewService cria o serviço fiscal
+func NewService(db *gorm.DB, cfg Config, transport Transport, signer *Signer) *Service {
+	return &Service{db: db, cfg: cfg, transport: transport, signer: signer}
+}
+
+// Setup inicializa o serviço fiscal a partir das variáveis de ambiente
+func Setup(db *gorm.DB) error {
+	cfg := LoadConfig()
+	if !cfg.Enabled {
+		log.Println("Emissão de NFC-e desabilitada (FISCAL_ENABLED)")
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	var signer *Signer
+	var err error
+	switch {
+	case cfg.CertificatePath != "":
+		signer, err = LoadCertificate(cfg.CertificatePath, cfg.CertificatePassword)
+	case cfg.Transport == "mock":
+		signer, err = NewSelfSignedSigner(cfg.Name)
+	default:
+		err = errors.New("Certificado A1 não configurado (FISCAL_CERT_PATH)")
+	}
+	if err != nil {
+		return err
+	}
+	if time.Now().After(signer.NotAfter()) {
+		return fmt.Errorf("Certificado digital vencido em %s", signer.NotAfter().Format("02/01/2006"))
+	}
+
+	var transport Transport
+	switch cfg.Transport {
+	case "mock":
+		transport = newMockTransportFromEnv()
+	case "soap":
+		transport, err = NewSOAPTransport(cfg, signer)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Transporte fiscal desconhecido: %s", cfg.Transport)
+	}
+
+	Default = NewService(db, cfg, transport, signer)
+	log.Printf("Emissão de NFC-e habilitada (UF %s, ambiente %d, série %d, transporte %s)",
+		cfg.UF, cfg.Environment, cfg.Series, cfg.Transport)
+	return nil
+}
+
+// Config retorna a configuração fiscal em uso
+func (s *Service) Config() Config {
+	return s.cfg
+}
+
+// Emitter retorna a organização emitente: a de CNPJ igual a FISCAL_CNPJ ou, se nenhuma
+// corresponder, a única organização cadastrada. Com várias organizações e nenhuma com o
+// CNPJ configurado, a emissão é recusada para todas
+func (s *Service) Emitter() (uint, error) {
+	var organizations []models.Organization
+	if err := s.db.Select("id", "document").Order("id ASC").Find(&organizations).Error; err != nil {
+		return 0, err
+	}
+	for _, organization := range organizations {
+		if validators.OnlyDigits(organization.Document) == s.cfg.CNPJ {
+			return organization.ID, nil
+		}
+	}
+	if len(organizations) == 1 {
+		return organizations[0].ID, nil
+	}
+	return 0, ErrNotEmitter
+}
+
+// CheckEmitter retorna ErrNotEmitter se a organização não for a emitente
+func (s *Service) CheckEmitter(organizationID uint) error {
+	emitter, err := s.Emitter()
+	if err != nil {
+		return err
+	}
+	if emitter != organizationID {
+		return ErrNotEmitter
+	}
+	return nil
+}
+
+// EmitForSale emite a NFC-e de uma venda concluída. Se a SEFAZ estiver indisponível, a nota
+// é emitida em contingência off-line e transmitida depois. Documentos já autorizados ou em
+// contingência são retornados sem nova emissão; documentos rejeitados são reemitidos com o
+// mesmo número e documentos com número inutilizado recebem nova numeração
+func (s *Service) EmitForSale(saleID uint) (*models.FiscalDocument, error) {
+	var sale models.Sale
+	if err := s.db.Preload("SaleItems.Product").Preload("Payments").Preload("Customer").First(&sale, saleID).Error; err != nil {
+		return nil, fmt.Errorf("venda %d não encontrada: %w", saleID, err)
+	}
+	if sale.Status != "completed" {
+		return nil, errors.New("Somente vendas concluídas podem ter NFC-e emitida")
+	}
+	if err := s.CheckEmitter(sale.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	var document models.FiscalDocument
+	err := s.db.Where("sale_id = ?", sale.ID).First(&document).Error
+	switch {
+	case err == nil:
+		switch document.Status {
+		case models.FiscalStatusRejected:
+			// Reemitir com o mesmo número
+		case models.FiscalStatusInvalidated:
+			// Número inutilizado: reemitir com nova numeração
+			reserved, err := s.reserveDocument(&sale)
+			if err != nil {
+				return nil, err
+			}
+			reserved.ID = document.ID
+			reserved.CreatedAt = document.CreatedAt
+			document = *reserved
+		default:
+			return &document, nil
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reserved, err := s.reserveDocument(&sale)
+		if err != nil {
+			return nil, err
+		}
+		document = *reserved
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	invoice := Invoice{
+		Series:       document.Series,
+		Number:       document.Number,
+		Code:         randomCode(document.Number),
+		EmissionType: EmissionNormal,
+		IssuedAt:     now,
+	}
+
+	// Durante uma indisponibilidade recente, emitir direto em contingência
+	var transportErr error
+	if s.isOffline() {
+		transportErr = ErrUnavailable
+	} else {
+		signed, err := s.prepare(&document, &sale, invoice)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := s.authorize(&document, signed)
+		if err != nil {
+			transportErr = err
+		} else {
+			s.applyResult(&document, signed, result)
+		}
+	}
+
+	if transportErr != nil {
+		invoice.EmissionType = EmissionOfflineNFCe
+		invoice.ContingencyAt = time.Now()
+		invoice.ContingencyReason = contingencyDefaultNotes
+		if _, err := s.prepare(&document, &sale, invoice); err != nil {
+			return nil, err
+		}
+		document.Status = models.FiscalStatusContingency
+		document.StatusCode = 0
+		document.StatusMessage = transportErr.Error()
+	}
+
+	if err := s.db.Save(&document).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar documento fiscal: %w", err)
+	}
+	return &document, nil
+}
+
+// TransmitContingency transmite as NFC-e da organização emitente emitidas em contingência e
+// retorna quantas foram autorizadas. Interrompe na primeira falha de comunicação
+func (s *Service) TransmitContingency() (int, error) {
+	emitter, err := s.Emitter()
+	if err != nil {
+		return 0, err
+	}
+
+	var documents []models.FiscalDocument
+	if err := s.db.Where("organization_id = ? AND status = ?", emitter, models.FiscalStatusContingency).Order("id ASC").Find(&documents).Error; err != nil {
+		return 0, err
+	}
+
+	authorized := 0
+	for i := range documents {
+		document := &documents[i]
+		signed := []byte(document.XML)
+
+		result, err := s.authorize(document, signed)
+		if err != nil {
+			document.StatusMessage = err.Error()
+			s.db.Save(document)
+			return authorized, err
+		}
+
+		s.applyResult(document, signed, result)
+		if err := s.db.Save(document).Error; err != nil {
+			return authorized, err
+		}
+		if document.Status == models.FiscalStatusAuthorized {
+			authorized++
+		}
+	}
+
+	return authorized, nil
+}
+
+// StartContingencyWorker retransmite periodicamente as notas emitidas em contingência
+func (s *Service) StartContingencyWorker() {
+	if s.cfg.RetransmitInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.RetransmitInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			authorized, err := s.TransmitContingency()
+			if err != nil {
+				log.Printf("Retransmissão de NFC-e em contingência interrompida: %v", err)
+			}
+			if authorized > 0 {
+				log.Printf("%d NFC-e em contingência autorizada(s)", authorized)
+			}
+		}
+	}()
+}
+
+// authorize envia o documento à SEFAZ, registrando a tentativa e a disponibilidade do serviço
+func (s *Service) authorize(document *models.FiscalDocument, signed []byte) (*AuthorizationResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	now := time.Now()
+	document.Attempts++
+	document.LastAttemptAt = &now
+
+	result, err := s.transport.Authorize(ctx, document.AccessKey, signed)
+
+	s.mu.Lock()
+	if err != nil {
+		s.offlineUntil = time.Now().Add(s.cfg.RetransmitInterval)
+	} else {
+		s.offlineUntil = time.Time{}
+	}
+	s.mu.Unlock()
+
+	return result, err
+}
+
+func (s *Service) isOffline() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().Before(s.offlineUntil)
+}
+
+// prepare monta, assina e armazena no documento o XML da NFC-e
+func (s *Service) prepare(document *models.FiscalDocument, sale *models.Sale, invoice Invoice) ([]byte, error) {
+	doc, accessKey, err := buildNFCe(s.cfg, sale, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	element, err := marshalCanonical(doc)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar XML da NFC-e: %w", err)
+	}
+	signature, digestValue, err := s.signer.Sign(element, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	qrCode := QRCodeURL(s.cfg, accessKey, invoice.EmissionType, invoice.IssuedAt, sale.FinalTotal, digestValue)
+	signed := assembleNFe(element, qrCode, s.cfg.ConsultURL, signature)
+
+	document.AccessKey = accessKey
+	document.EmissionType = invoice.EmissionType
+	document.IssuedAt = invoice.IssuedAt
+	document.QRCodeURL = qrCode
+	document.ContingencyReason = invoice.ContingencyReason
+	document.XML = string(signed)
+	return signed, nil
+}
+
+// applyResult registra no documento a resposta da SEFAZ
+func (s *Service) applyResult(document *models.FiscalDocument, signed []byte, result *AuthorizationResult) {
+	document.StatusCode = result.StatusCode
+	document.StatusMessage = result.Message
+
+	if !result.Authorized {
+		document.Status = models.FiscalStatusRejected
+		return
+	}
+
+	authorizedAt := result.ReceivedAt
+	if authorizedAt.IsZero() {
+		authorizedAt = time.Now()
+	}
+	document.Status = models.FiscalStatusAuthorized
+	document.Protocol = result.Protocol
+	document.AuthorizedAt = &authorizedAt
+	document.XML = string(wrapResponse("nfeProc", LayoutVersion, signed, result.ProtocolXML))
+}
+
+// reserveDocument valida os dados da venda e só então consome um número da série
+func (s *Service) reserveDocument(sale *models.Sale) (*models.FiscalDocument, error) {
+	if _, _, err := buildNFCe(s.cfg, sale, Invoice{Series: s.cfg.Series, EmissionType: EmissionNormal, IssuedAt: time.Now()}); err != nil {
+		return nil, err
+	}
+	number, err := s.nextNumber(sale.OrganizationID, s.cfg.Series)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FiscalDocument{
+		OrganizationID: sale.OrganizationID,
+		SaleID:         sale.ID,
+		Model:          ModelNFCe,
+		Series:         s.cfg.Series,
+		Number:         number,
+		Environment:    s.cfg.Environment,
+	}, nil
+}
+
+// nextNumber reserva o próximo número da série da organização
+func (s *Service) nextNumber(organizationID uint, series int) (int, error) {
+	var number int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var sequence models.FiscalSequence
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? AND series = ?", organizationID, series).First(&sequence).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			sequence = models.FiscalSequence{OrganizationID: organizationID, Series: series, NextNumber: s.cfg.FirstNumber}
+			if sequence.NextNumber < 1 {
+				sequence.NextNumber = 1
+			}
+			err = tx.Create(&sequence).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		number = sequence.NextNumber
+		return tx.Model(&sequence).Update("next_number", number+1).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erro ao reservar numeração da NFC-e: %w", err)
+	}
+	return number, nil
+}
+
+// randomCode gera o código numérico (cNF) da chave de acesso, diferente do número da nota
+func randomCode(number int) int {
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+		if err != nil {
+			return (number + 1) % 100000000
+		}
+		if code := int(n.Int64()); code != number {
+			return code
+		}
+	}
+}
+
+// assembleNFe monta o elemento NFe com o QR Code e a assinatura
+func assembleNFe(element []byte, qrCode, consultURL string, signature []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<NFe xmlns="` + namespaceNFe + `">`)
+	// O namespace é herdado de NFe
+	b.Write(bytes.Replace(element, []byte(` xmlns="`+namespaceNFe+`"`), nil, 1))
+	b.WriteString("<infNFeSupl><qrCode>")
+	xml.EscapeText(&b, []byte(qrCode))
+	b.WriteString("</qrCode><urlChave>")
+	xml.EscapeText(&b, []byte(consultURL))
+	b.WriteString("</urlChave></infNFeSupl>")
+	b.Write(signature)
+	b.WriteString("</NFe>")
+	return b.Bytes()
+}
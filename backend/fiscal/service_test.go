@@ -0,0 +1,166 @@
+package fiscal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"pdv-backend/models"
+)
+
+// newTestService cria o serviço fiscal sobre um banco SQLite temporário, com a organização
+// emitente e a SEFAZ simulada no modo informado
+func newTestService(t *testing.T, mode string) (*Service, *MockTransport, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fiscal.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Organization{}, &models.Product{}, &models.Customer{}, &models.Sale{},
+		&models.SaleItem{}, &models.SalePayment{}, &models.FiscalDocument{}, &models.FiscalSequence{}); err != nil {
+		t.Fatalf("erro ao migrar banco de teste: %v", err)
+	}
+
+	cfg := testConfig()
+	if err := db.Create(&models.Organization{Name: cfg.Name, Document: "11.222.333/0001-81", Active: true}).Error; err != nil {
+		t.Fatalf("erro ao criar organização: %v", err)
+	}
+
+	mock := NewMockTransport(mode)
+	return NewService(db, cfg, mock, loadFixtureSigner(t)), mock, db
+}
+
+// createTestSale grava uma venda concluída de um item, paga em dinheiro
+func createTestSale(t *testing.T, db *gorm.DB) uint {
+	t.Helper()
+	product := models.Product{OrganizationID: 1, Name: "Refrigerante lata", Barcode: "7890000000017", Price: 500, NCM: "22021000", Unit: "un"}
+	if err := db.Where("barcode = ?", product.Barcode).FirstOrCreate(&product).Error; err != nil {
+		t.Fatalf("erro ao criar produto: %v", err)
+	}
+	sale := models.Sale{OrganizationID: 1, Total: 1000, FinalTotal: 1000, PaymentType: "dinheiro", Status: "completed", UserID: 1}
+	if err := db.Create(&sale).Error; err != nil {
+		t.Fatalf("erro ao criar venda: %v", err)
+	}
+	item := models.SaleItem{SaleID: sale.ID, ProductID: product.ID, Quantity: 2, UnitPrice: 500, Total: 1000}
+	payment := models.SalePayment{SaleID: sale.ID, PaymentType: "dinheiro", Amount: 1000, AmountReceived: 1000}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("erro ao criar item da venda: %v", err)
+	}
+	if err := db.Create(&payment).Error; err != nil {
+		t.Fatalf("erro ao criar pagamento da venda: %v", err)
+	}
+	return sale.ID
+}
+
+func TestEmitForSale(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		status       string
+		emissionType int
+		received     int
+	}{
+		{"SEFAZ autoriza", MockAuthorize, models.FiscalStatusAuthorized, EmissionNormal, 1},
+		{"SEFAZ rejeita", MockReject, models.FiscalStatusRejected, EmissionNormal, 1},
+		{"SEFAZ fora do ar", MockOffline, models.FiscalStatusContingency, EmissionOfflineNFCe, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock, db := newTestService(t, tt.mode)
+			document, err := service.EmitForSale(createTestSale(t, db))
+			if err != nil {
+				t.Fatalf("erro ao emitir NFC-e: %v", err)
+			}
+
+			if document.Status != tt.status {
+				t.Errorf("status = %s, esperado %s", document.Status, tt.status)
+			}
+			if document.EmissionType != tt.emissionType {
+				t.Errorf("tipo de emissão = %d, esperado %d", document.EmissionType, tt.emissionType)
+			}
+			if document.Number != 1 {
+				t.Errorf("número = %d, esperado 1", document.Number)
+			}
+			if !ValidAccessKey(document.AccessKey) {
+				t.Errorf("chave de acesso inválida: %s", document.AccessKey)
+			}
+			if got := document.AccessKey[34:35]; got != string(rune('0'+tt.emissionType)) {
+				t.Errorf("tipo de emissão na chave = %s, esperado %d", got, tt.emissionType)
+			}
+			if len(mock.Received) != tt.received {
+				t.Errorf("SEFAZ recebeu %d documento(s), esperado %d", len(mock.Received), tt.received)
+			}
+			if tt.status == models.FiscalStatusAuthorized && document.Protocol == "" {
+				t.Error("NFC-e autorizada sem protocolo")
+			}
+			if tt.status != models.FiscalStatusAuthorized && document.Protocol != "" {
+				t.Errorf("protocolo %s em NFC-e não autorizada", document.Protocol)
+			}
+		})
+	}
+}
+
+func TestTransmitContingency(t *testing.T) {
+	service, mock, db := newTestService(t, MockOffline)
+
+	first, err := service.EmitForSale(createTestSale(t, db))
+	if err != nil {
+		t.Fatalf("erro ao emitir primeira NFC-e: %v", err)
+	}
+	// Com a SEFAZ indisponível há pouco, a segunda nota vai direto para contingência
+	mock.SetMode(MockAuthorize)
+	second, err := service.EmitForSale(createTestSale(t, db))
+	if err != nil {
+		t.Fatalf("erro ao emitir segunda NFC-e: %v", err)
+	}
+
+	for i, document := range []*models.FiscalDocument{first, second} {
+		if document.Status != models.FiscalStatusContingency {
+			t.Errorf("NFC-e %d: status = %s, esperado %s", i+1, document.Status, models.FiscalStatusContingency)
+		}
+		if document.Number != i+1 {
+			t.Errorf("NFC-e %d: número = %d, esperado %d", i+1, document.Number, i+1)
+		}
+	}
+	if first.Attempts != 1 || second.Attempts != 0 {
+		t.Errorf("tentativas = %d e %d, esperado 1 e 0", first.Attempts, second.Attempts)
+	}
+	if len(mock.Received) != 0 {
+		t.Fatalf("SEFAZ recebeu %d documento(s) durante a indisponibilidade", len(mock.Received))
+	}
+
+	authorized, err := service.TransmitContingency()
+	if err != nil {
+		t.Fatalf("erro ao retransmitir contingência: %v", err)
+	}
+	if authorized != 2 {
+		t.Errorf("%d NFC-e autorizada(s), esperado 2", authorized)
+	}
+
+	var documents []models.FiscalDocument
+	db.Order("id ASC").Find(&documents)
+	for i, document := range documents {
+		if document.Status != models.FiscalStatusAuthorized {
+			t.Errorf("NFC-e %d: status = %s após retransmissão, esperado %s", i+1, document.Status, models.FiscalStatusAuthorized)
+		}
+		// A chave da contingência é mantida na transmissão
+		if document.EmissionType != EmissionOfflineNFCe {
+			t.Errorf("NFC-e %d: tipo de emissão = %d, esperado %d", i+1, document.EmissionType, EmissionOfflineNFCe)
+		}
+		if i < len(mock.Received) && mock.Received[i] != document.AccessKey {
+			t.Errorf("NFC-e %d: SEFAZ recebeu a chave %s, esperado %s", i+1, mock.Received[i], document.AccessKey)
+		}
+	}
+	if len(mock.Received) != 2 {
+		t.Errorf("SEFAZ recebeu %d documento(s), esperado 2", len(mock.Received))
+	}
+
+	// Sem notas pendentes, nada é retransmitido
+	if authorized, err := service.TransmitContingency(); err != nil || authorized != 0 {
+		t.Errorf("segunda retransmissão = %d, %v; esperado 0, nil", authorized, err)
+	}
+}
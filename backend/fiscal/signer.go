@@ -0,0 +1,226 @@
+package fiscal
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+const namespaceDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+// Signer assina documentos fiscais com o certificado digital A1 do emitente
+type Signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// LoadCertificate carrega um certificado A1 no formato PKCS#12 (.pfx/.p12)
+func LoadCertificate(path, password string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler certificado: %w", err)
+	}
+
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir certificado (senha incorreta ou formato não suportado): %w", err)
+	}
+
+	signer := &Signer{}
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		switch block.Type {
+		case "PRIVATE KEY", "RSA PRIVATE KEY":
+			signer.key, err = parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao ler certificado: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if signer.key == nil {
+		return nil, errors.New("chave privada não encontrada no certificado")
+	}
+
+	// O arquivo pode conter a cadeia: usar o certificado correspondente à chave
+	for _, cert := range certs {
+		if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); ok && publicKey.Equal(&signer.key.PublicKey) {
+			signer.cert = cert
+			break
+		}
+	}
+	if signer.cert == nil {
+		return nil, errors.New("certificado do emitente não encontrado no arquivo")
+	}
+
+	return signer, nil
+}
+
+// NewSelfSignedSigner gera um certificado autoassinado temporário, usado apenas com a
+// SEFAZ simulada em desenvolvimento
+func NewSelfSignedSigner(commonName string) (*Signer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{key: key, cert: cert}, nil
+}
+
+func parsePrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave privada: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("chave privada do certificado deve ser RSA")
+	}
+	return key, nil
+}
+
+// NotAfter retorna a data de validade do certificado
+func (s *Signer) NotAfter() time.Time {
+	return s.cert.NotAfter
+}
+
+// TLSCertificate retorna o certificado para autenticação mútua com os webservices
+func (s *Signer) TLSCertificate() tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{s.cert.Raw},
+		PrivateKey:  s.key,
+		Leaf:        s.cert,
+	}
+}
+
+// Sign gera a assinatura XMLDSig envelopada (RSA-SHA1, C14N) do elemento com o Id informado.
+// O elemento deve estar em forma canônica e declarar o próprio namespace. Retorna o elemento
+// Signature e o DigestValue, usado no QR Code da contingência
+func (s *Signer) Sign(element []byte, id string) ([]byte, string, error) {
+	digest := sha1.Sum(element)
+	digestValue := base64.StdEncoding.EncodeToString(digest[:])
+
+	signedInfo := signedInfoXML(id, digestValue)
+	hashed := sha1.Sum([]byte(fmt.Sprintf(signedInfo, ` xmlns="`+namespaceDSig+`"`)))
+	signatureValue, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, hashed[:])
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao assinar documento: %w", err)
+	}
+
+	var signature bytes.Buffer
+	signature.WriteString(`<Signature xmlns="` + namespaceDSig + `">`)
+	signature.WriteString(fmt.Sprintf(signedInfo, ""))
+	signature.WriteString("<SignatureValue>" + base64.StdEncoding.EncodeToString(signatureValue) + "</SignatureValue>")
+	signature.WriteString("<KeyInfo><X509Data><X509Certificate>" + base64.StdEncoding.EncodeToString(s.cert.Raw) + "</X509Certificate></X509Data></KeyInfo>")
+	signature.WriteString("</Signature>")
+
+	return signature.Bytes(), digestValue, nil
+}
+
+// signedInfoXML monta o SignedInfo canônico; o %s recebe a declaração de namespace,
+// presente apenas no cálculo da assinatura (no documento ela é herdada de Signature)
+func signedInfoXML(id, digestValue string) string {
+	return `<SignedInfo%s>` +
+		`<CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"></SignatureMethod>` +
+		`<Reference URI="#` + id + `">` +
+		`<Transforms>` +
+		`<Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></Transform>` +
+		`<Transform Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"></Transform>` +
+		`</Transforms>` +
+		`<DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"></DigestMethod>` +
+		`<DigestValue>` + digestValue + `</DigestValue>` +
+		`</Reference>` +
+		`</SignedInfo>`
+}
+
+var (
+	signedInfoPattern  = regexp.MustCompile(`(?s)<SignedInfo>.*</SignedInfo>`)
+	signatureValueExpr = regexp.MustCompile(`<SignatureValue>([^<]+)</SignatureValue>`)
+	certificateExpr    = regexp.MustCompile(`<X509Certificate>([^<]+)</X509Certificate>`)
+	digestValueExpr    = regexp.MustCompile(`<DigestValue>([^<]+)</DigestValue>`)
+)
+
+// VerifySignature confere a assinatura de um documento gerado por este pacote: recalcula o
+// digest do elemento assinado e valida o SignatureValue com o certificado embutido
+func VerifySignature(document []byte, element, namespace string) error {
+	start := bytes.Index(document, []byte("<"+element+" "))
+	end := bytes.Index(document, []byte("</"+element+">"))
+	if start < 0 || end < 0 {
+		return fmt.Errorf("elemento %s não encontrado", element)
+	}
+	signed := document[start : end+len(element)+3]
+	signed = bytes.Replace(signed, []byte("<"+element+" "), []byte("<"+element+` xmlns="`+namespace+`" `), 1)
+
+	signedInfo := signedInfoPattern.Find(document)
+	digestMatch := digestValueExpr.FindSubmatch(document)
+	signatureMatch := signatureValueExpr.FindSubmatch(document)
+	certificateMatch := certificateExpr.FindSubmatch(document)
+	if signedInfo == nil || digestMatch == nil || signatureMatch == nil || certificateMatch == nil {
+		return errors.New("assinatura não encontrada")
+	}
+
+	digest := sha1.Sum(signed)
+	if base64.StdEncoding.EncodeToString(digest[:]) != string(digestMatch[1]) {
+		return errors.New("digest do documento não confere")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(string(certificateMatch[1]))
+	if err != nil {
+		return err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("certificado sem chave RSA")
+	}
+	signatureValue, err := base64.StdEncoding.DecodeString(string(signatureMatch[1]))
+	if err != nil {
+		return err
+	}
+
+	canonical := bytes.Replace(signedInfo, []byte("<SignedInfo>"), []byte(`<SignedInfo xmlns="`+namespaceDSig+`">`), 1)
+	hashed := sha1.Sum(canonical)
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, hashed[:], signatureValue)
+}
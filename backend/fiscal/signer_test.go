@@ -0,0 +1,67 @@
+package fiscal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A assinatura esperada (testdata/nfce_signature.xml) foi gerada fora do pacote, com a
+// canonicalização do xmllint e o openssl, a partir da NFC-e e do certificado de teste
+const (
+	fixtureCertificate = "testdata/certificado.pfx"
+	fixturePassword    = "1234"
+	fixtureID          = "NFe35261011222333000181650010000000011865907018"
+	fixtureDigestValue = "rKMhVPO3Gq2SFlSZQiI7A6HevZA="
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("erro ao ler %s: %v", name, err)
+	}
+	return data
+}
+
+func loadFixtureSigner(t *testing.T) *Signer {
+	t.Helper()
+	signer, err := LoadCertificate(fixtureCertificate, fixturePassword)
+	if err != nil {
+		t.Fatalf("erro ao carregar certificado de teste: %v", err)
+	}
+	return signer
+}
+
+func TestSignNFCe(t *testing.T) {
+	signer := loadFixtureSigner(t)
+	element := readFixture(t, "nfce_infnfe.xml")
+
+	signature, digestValue, err := signer.Sign(element, fixtureID)
+	if err != nil {
+		t.Fatalf("erro ao assinar NFC-e: %v", err)
+	}
+	if digestValue != fixtureDigestValue {
+		t.Errorf("DigestValue = %s, esperado %s", digestValue, fixtureDigestValue)
+	}
+	if expected := readFixture(t, "nfce_signature.xml"); !bytes.Equal(signature, expected) {
+		t.Errorf("assinatura diferente da esperada\nobtida:   %s\nesperada: %s", signature, expected)
+	}
+}
+
+func TestVerifySignedNFCe(t *testing.T) {
+	element := readFixture(t, "nfce_infnfe.xml")
+	signature := readFixture(t, "nfce_signature.xml")
+	document := assembleNFe(element, "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode?p=teste",
+		"https://www.homologacao.nfce.fazenda.sp.gov.br/consulta", signature)
+
+	if err := VerifySignature(document, "infNFe", namespaceNFe); err != nil {
+		t.Fatalf("assinatura da NFC-e recusada: %v", err)
+	}
+
+	tampered := bytes.Replace(document, []byte("<nNF>1</nNF>"), []byte("<nNF>2</nNF>"), 1)
+	if err := VerifySignature(tampered, "infNFe", namespaceNFe); err == nil {
+		t.Error("assinatura aceita após alteração da NFC-e")
+	}
+}
@@ -0,0 +1,274 @@
+package fiscal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrUnavailable indica falha de comunicação com a SEFAZ; a emissão segue em contingência
+var ErrUnavailable = errors.New("SEFAZ indisponível")
+
+// AuthorizationResult representa a resposta da SEFAZ para uma NFC-e
+type AuthorizationResult struct {
+	Authorized  bool
+	StatusCode  int
+	Message     string
+	Protocol    string
+	ReceivedAt  time.Time
+	ProtocolXML []byte // grupo protNFe, anexado ao XML autorizado (nfeProc)
+}
+
+// EventResult representa a resposta da SEFAZ a um evento ou pedido de inutilização
+type EventResult struct {
+	Accepted     bool
+	StatusCode   int
+	Message      string
+	Protocol     string
+	RegisteredAt time.Time
+	ResponseXML  []byte // grupo retEvento ou retInutNFe, anexado ao XML processado
+}
+
+// Transport envia documentos assinados à SEFAZ. Erros retornados são tratados como
+// indisponibilidade do serviço; rejeições vêm no resultado
+type Transport interface {
+	Authorize(ctx context.Context, accessKey string, signedNFe []byte) (*AuthorizationResult, error)
+	SendEvent(ctx context.Context, signedEvent []byte) (*EventResult, error)
+	Invalidate(ctx context.Context, signedRequest []byte) (*EventResult, error)
+}
+
+// authorizedStatusCodes lista os cStat que autorizam o uso da NF-e
+var authorizedStatusCodes = map[int]bool{
+	100: true, // autorizado o uso da NF-e
+	150: true, // autorizado o uso da NF-e, autorização fora de prazo
+}
+
+// acceptedEventStatusCodes lista os cStat de evento de cancelamento registrado
+var acceptedEventStatusCodes = map[int]bool{
+	135: true, // evento registrado e vinculado a NF-e
+	155: true, // cancelamento homologado fora de prazo
+}
+
+// invalidationHomologated é o cStat de inutilização de número homologada
+const invalidationHomologated = 102
+
+// SOAPTransport comunica com os webservices da SEFAZ (autorização síncrona, eventos e inutilização)
+type SOAPTransport struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewSOAPTransport cria o transporte SOAP autenticado com o certificado do emitente
+func NewSOAPTransport(cfg Config, signer *Signer) (*SOAPTransport, error) {
+	if cfg.AuthorizationURL == "" {
+		return nil, errors.New("URL do webservice de autorização não configurada (FISCAL_AUTHORIZATION_URL)")
+	}
+
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{signer.TLSCertificate()},
+				MinVersion:   tls.VersionTLS12,
+			},
+		},
+	}
+
+	return &SOAPTransport{cfg: cfg, client: client}, nil
+}
+
+// Authorize envia a NFC-e em um lote síncrono de um documento
+func (t *SOAPTransport) Authorize(ctx context.Context, accessKey string, signedNFe []byte) (*AuthorizationResult, error) {
+	var body bytes.Buffer
+	body.WriteString(`<enviNFe xmlns="` + namespaceNFe + `" versao="` + LayoutVersion + `">`)
+	body.WriteString("<idLote>" + strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10) + "</idLote>")
+	body.WriteString("<indSinc>1</indSinc>")
+	body.Write(signedNFe)
+	body.WriteString("</enviNFe>")
+
+	response, err := t.call(ctx, t.cfg.AuthorizationURL, "NFeAutorizacao4", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var ret struct {
+		StatusCode int    `xml:"cStat"`
+		Message    string `xml:"xMotivo"`
+		Protocol   *struct {
+			Info protocolInfo `xml:"infProt"`
+		} `xml:"protNFe"`
+	}
+	if err := decodeElement(response, "retEnviNFe", &ret); err != nil {
+		return nil, fmt.Errorf("%w: resposta inválida: %v", ErrUnavailable, err)
+	}
+
+	// Lote não processado: a rejeição está no próprio retorno
+	if ret.Protocol == nil {
+		return &AuthorizationResult{StatusCode: ret.StatusCode, Message: ret.Message}, nil
+	}
+
+	info := ret.Protocol.Info
+	result := &AuthorizationResult{
+		Authorized:  authorizedStatusCodes[info.StatusCode],
+		StatusCode:  info.StatusCode,
+		Message:     info.Message,
+		Protocol:    info.Protocol,
+		ProtocolXML: extractElement(response, "protNFe"),
+	}
+	result.ReceivedAt, _ = time.Parse(dateTimeLayout, info.ReceivedAt)
+	return result, nil
+}
+
+// SendEvent envia um evento (cancelamento) em um lote de um evento
+func (t *SOAPTransport) SendEvent(ctx context.Context, signedEvent []byte) (*EventResult, error) {
+	if t.cfg.EventURL == "" {
+		return nil, fmt.Errorf("%w: URL do webservice de eventos não configurada (FISCAL_EVENT_URL)", ErrUnavailable)
+	}
+
+	var body bytes.Buffer
+	body.WriteString(`<envEvento xmlns="` + namespaceNFe + `" versao="` + eventVersion + `">`)
+	body.WriteString("<idLote>" + strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10) + "</idLote>")
+	body.Write(signedEvent)
+	body.WriteString("</envEvento>")
+
+	response, err := t.call(ctx, t.cfg.EventURL, "NFeRecepcaoEvento4", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var ret struct {
+		StatusCode int    `xml:"cStat"`
+		Message    string `xml:"xMotivo"`
+		Event      *struct {
+			Info eventInfo `xml:"infEvento"`
+		} `xml:"retEvento"`
+	}
+	if err := decodeElement(response, "retEnvEvento", &ret); err != nil {
+		return nil, fmt.Errorf("%w: resposta inválida: %v", ErrUnavailable, err)
+	}
+	if ret.Event == nil {
+		return &EventResult{StatusCode: ret.StatusCode, Message: ret.Message}, nil
+	}
+
+	info := ret.Event.Info
+	result := &EventResult{
+		Accepted:    acceptedEventStatusCodes[info.StatusCode],
+		StatusCode:  info.StatusCode,
+		Message:     info.Message,
+		Protocol:    info.Protocol,
+		ResponseXML: extractElement(response, "retEvento"),
+	}
+	result.RegisteredAt, _ = time.Parse(dateTimeLayout, info.RegisteredAt)
+	return result, nil
+}
+
+// Invalidate envia o pedido de inutilização de numeração
+func (t *SOAPTransport) Invalidate(ctx context.Context, signedRequest []byte) (*EventResult, error) {
+	if t.cfg.InvalidationURL == "" {
+		return nil, fmt.Errorf("%w: URL do webservice de inutilização não configurada (FISCAL_INVALIDATION_URL)", ErrUnavailable)
+	}
+
+	response, err := t.call(ctx, t.cfg.InvalidationURL, "NFeInutilizacao4", signedRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret struct {
+		Info struct {
+			StatusCode int    `xml:"cStat"`
+			Message    string `xml:"xMotivo"`
+			Protocol   string `xml:"nProt"`
+			ReceivedAt string `xml:"dhRecbto"`
+		} `xml:"infInut"`
+	}
+	if err := decodeElement(response, "retInutNFe", &ret); err != nil {
+		return nil, fmt.Errorf("%w: resposta inválida: %v", ErrUnavailable, err)
+	}
+
+	result := &EventResult{
+		Accepted:    ret.Info.StatusCode == invalidationHomologated,
+		StatusCode:  ret.Info.StatusCode,
+		Message:     ret.Info.Message,
+		Protocol:    ret.Info.Protocol,
+		ResponseXML: extractElement(response, "retInutNFe"),
+	}
+	result.RegisteredAt, _ = time.Parse(dateTimeLayout, ret.Info.ReceivedAt)
+	return result, nil
+}
+
+type eventInfo struct {
+	StatusCode   int    `xml:"cStat"`
+	Message      string `xml:"xMotivo"`
+	Protocol     string `xml:"nProt"`
+	RegisteredAt string `xml:"dhRegEvento"`
+}
+
+type protocolInfo struct {
+	AccessKey  string `xml:"chNFe"`
+	ReceivedAt string `xml:"dhRecbto"`
+	Protocol   string `xml:"nProt"`
+	StatusCode int    `xml:"cStat"`
+	Message    string `xml:"xMotivo"`
+}
+
+// call envia o envelope SOAP 1.2 para o serviço informado
+func (t *SOAPTransport) call(ctx context.Context, url, service string, payload []byte) ([]byte, error) {
+	var envelope bytes.Buffer
+	envelope.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	envelope.WriteString(`<soap12:Envelope xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body>`)
+	envelope.WriteString(`<nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/` + service + `">`)
+	envelope.Write(payload)
+	envelope.WriteString(`</nfeDadosMsg></soap12:Body></soap12:Envelope>`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: HTTP %d", ErrUnavailable, resp.StatusCode)
+	}
+	return data, nil
+}
+
+// decodeElement decodifica o primeiro elemento com o nome informado, ignorando o envelope SOAP
+func decodeElement(data []byte, name string, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("elemento %s não encontrado", name)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			return decoder.DecodeElement(v, &start)
+		}
+	}
+}
+
+// extractElement retorna o XML bruto do primeiro elemento com o nome informado
+func extractElement(data []byte, name string) []byte {
+	start := bytes.Index(data, []byte("<"+name))
+	end := bytes.Index(data, []byte("</"+name+">"))
+	if start < 0 || end < start {
+		return nil
+	}
+	return data[start : end+len(name)+3]
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"pdv-backend/config"
+	"pdv-backend/fiscal"
+	"pdv-backend/pricing"
 	"pdv-backend/routes"
 )
 
@@ -20,6 +22,17 @@ func main() {
 	// Inicializar banco de dados
 	config.InitDB()
 
+	// Inicializar emissão de NFC-e (opcional)
+	if err := fiscal.Setup(config.DB); err != nil {
+		log.Fatal("Erro na configuração fiscal: ", err)
+	}
+	if fiscal.Default != nil {
+		fiscal.Default.StartContingencyWorker()
+	}
+
+	// Aplicar alterações de preço agendadas
+	pricing.StartScheduler(config.DB)
+
 	// Configurar Gin
 	r := gin.Default()
 	
@@ -94,51 +94,24 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if user.OrganizationID == nil || *user.OrganizationID == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Usuário sem organização"})
+			c.Abort()
+			return
+		}
+
 		// Adicionar informações do usuário ao contexto
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
 		c.Set("user", user)
-
-		c.Next()
-	}
-}
-
-// AdminMiddleware middleware para verificar se o usuário é admin
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Acesso negado"})
-			c.Abort()
-			return
-		}
-
-		if userRole != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
-}
-
-// ManagerOrAdminMiddleware middleware para verificar se o usuário é manager ou admin
-func ManagerOrAdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Acesso negado"})
-			c.Abort()
-			return
+		c.Set("organization_id", *user.OrganizationID)
+		if user.StoreID != nil {
+			c.Set("store_id", *user.StoreID)
 		}
 
-		if userRole != "admin" && userRole != "manager" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a gerentes e administradores"})
-			c.Abort()
-			return
-		}
+		// Restringir as consultas do request à organização do usuário
+		c.Request = c.Request.WithContext(config.WithOrganization(c.Request.Context(), *user.OrganizationID))
 
 		c.Next()
 	}
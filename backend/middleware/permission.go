@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pdv-backend/models"
+)
+
+// RequirePermission middleware para verificar se o usuário autenticado possui todas as
+// permissões informadas (modelo do perfil mais as permissões personalizadas)
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user")
+		user, ok := value.(models.User)
+		if !exists || !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Acesso negado"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !user.HasPermission(permission) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":      "Permissão necessária: " + permission,
+					"permission": permission,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+)
+
+// Status possíveis de uma sessão de caixa
+const (
+	CashSessionOpen       = "open"
+	CashSessionClosed     = "closed"
+	CashSessionReconciled = "reconciled"
+)
+
+// Tipos de movimentação de caixa
+const (
+	CashMovementSangria    = "sangria"    // retirada de dinheiro
+	CashMovementSuprimento = "suprimento" // reforço de dinheiro
+)
+
+// PaymentTypes lista as formas de pagamento aceitas pelo PDV
+var PaymentTypes = []string{"dinheiro", "cartao_credito", "cartao_debito", "pix"}
+
+// CashSession representa o caixa de um operador, da abertura à conferência. Cada operador
+// tem no máximo um caixa aberto, garantido pelo índice único parcial sobre user_id
+type CashSession struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	UserID         uint       `json:"user_id" gorm:"not null;index;uniqueIndex:idx_cash_sessions_open_user,where:status = 'open'"`
+	OpeningAmount  Money      `json:"opening_amount" gorm:"default:0"`  // fundo de troco
+	Status         string     `json:"status" gorm:"default:open;index"` // open, closed, reconciled
+	OpeningNotes   string     `json:"opening_notes"`
+	ClosingNotes   string     `json:"closing_notes"`
+	OpenedAt       time.Time  `json:"opened_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	ReconciledAt   *time.Time `json:"reconciled_at"`
+	ReconciledByID *uint      `json:"reconciled_by_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	User      User               `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Movements []CashMovement     `json:"movements,omitempty" gorm:"foreignKey:CashSessionID"`
+	Counts    []CashSessionCount `json:"counts,omitempty" gorm:"foreignKey:CashSessionID"`
+	Sales     []Sale             `json:"-" gorm:"foreignKey:CashSessionID"`
+}
+
+// CashMovement representa uma sangria ou suprimento realizado durante a sessão
+type CashMovement struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CashSessionID uint      `json:"cash_session_id" gorm:"not null;index"`
+	Type          string    `json:"type" gorm:"not null"` // sangria, suprimento
+	Amount        Money     `json:"amount" gorm:"not null"`
+	Reason        string    `json:"reason"`
+	UserID        uint      `json:"user_id" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CashSessionCount representa o valor esperado e o conferido de uma forma de pagamento no fechamento
+type CashSessionCount struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CashSessionID uint      `json:"cash_session_id" gorm:"not null;index"`
+	PaymentType   string    `json:"payment_type" gorm:"not null"`
+	Expected      Money     `json:"expected"`
+	Counted       Money     `json:"counted"`
+	Difference    Money     `json:"difference"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OpenCashSessionRequest representa os dados de abertura de caixa
+type OpenCashSessionRequest struct {
+	OpeningAmount *Money `json:"opening_amount" binding:"required,gte=0"`
+	Notes         string `json:"notes" binding:"max=500"`
+}
+
+// CashMovementRequest representa os dados de uma sangria ou suprimento
+type CashMovementRequest struct {
+	Type   string `json:"type" binding:"required,oneof=sangria suprimento"`
+	Amount Money  `json:"amount" binding:"required,gt=0"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// CloseCashSessionRequest representa os valores conferidos no fechamento do caixa
+type CloseCashSessionRequest struct {
+	Counts []CashCountRequest `json:"counts" binding:"required,min=1,dive"`
+	Notes  string             `json:"notes" binding:"max=500"`
+}
+
+type CashCountRequest struct {
+	PaymentType string `json:"payment_type" binding:"required,oneof=dinheiro cartao_credito cartao_debito pix"`
+	Counted     *Money `json:"counted" binding:"required,gte=0"`
+}
+
+// CashPaymentSummary representa o total movimentado por forma de pagamento
+type CashPaymentSummary struct {
+	PaymentType string `json:"payment_type"`
+	Sales       int64  `json:"sales"`
+	Expected    Money  `json:"expected"`
+	Counted     Money  `json:"counted"`
+	Difference  Money  `json:"difference"`
+}
+
+// CashSessionReport representa o relatório de fechamento (redução Z) da sessão
+type CashSessionReport struct {
+	Session          CashSession          `json:"session"`
+	TotalSales       int64                `json:"total_sales"`
+	CancelledSales   int64                `json:"cancelled_sales"`
+	GrossTotal       Money                `json:"gross_total"`
+	TotalDiscount    Money                `json:"total_discount"`
+	TotalTax         Money                `json:"total_tax"`
+	NetTotal         Money                `json:"net_total"`
+	CancelledTotal   Money                `json:"cancelled_total"`
+	TotalSangrias    Money                `json:"total_sangrias"`
+	TotalSuprimentos Money                `json:"total_suprimentos"`
+	ExpectedCash     Money                `json:"expected_cash"`
+	Payments         []CashPaymentSummary `json:"payments"`
+	TotalDifference  Money                `json:"total_difference"`
+}
+
+// IsOpen verifica se a sessão de caixa está aberta
+func (cs *CashSession) IsOpen() bool {
+	return cs.Status == CashSessionOpen
+}
@@ -5,12 +5,13 @@ import (
 )
 
 type Category struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	Active      bool      `json:"active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	Description    string    `json:"description"`
+	Active         bool      `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	// Relacionamentos
 	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
@@ -43,4 +44,4 @@ func (c *Category) ToResponse() CategoryResponse {
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
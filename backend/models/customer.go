@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+)
+
+type Customer struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_customers_organization_document,priority:1"`
+	Name           string    `json:"name" gorm:"not null"`
+	DocumentType   string    `json:"document_type"`                                                              // cpf, cnpj
+	Document       *string   `json:"document" gorm:"uniqueIndex:idx_customers_organization_document,priority:2"` // apenas dígitos
+	Phone          string    `json:"phone"`
+	Email          string    `json:"email"`
+	ZipCode        string    `json:"zip_code"`
+	Street         string    `json:"street"`
+	Number         string    `json:"number"`
+	Complement     string    `json:"complement"`
+	District       string    `json:"district"`
+	City           string    `json:"city"`
+	State          string    `json:"state"`
+	Notes          string    `json:"notes"`
+	Active         bool      `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Sales []Sale `json:"-" gorm:"foreignKey:CustomerID"`
+}
+
+// CustomerRequest representa os dados de entrada para criar/atualizar cliente
+type CustomerRequest struct {
+	Name       string `json:"name" binding:"required,min=2,max=200"`
+	Document   string `json:"document" binding:"max=20"`
+	Phone      string `json:"phone" binding:"max=20"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	ZipCode    string `json:"zip_code" binding:"max=10"`
+	Street     string `json:"street" binding:"max=200"`
+	Number     string `json:"number" binding:"max=20"`
+	Complement string `json:"complement" binding:"max=100"`
+	District   string `json:"district" binding:"max=100"`
+	City       string `json:"city" binding:"max=100"`
+	State      string `json:"state" binding:"omitempty,len=2"`
+	Notes      string `json:"notes" binding:"max=1000"`
+	Active     *bool  `json:"active"`
+}
+
+// CustomerResponse representa a resposta do cliente
+type CustomerResponse struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	DocumentType string    `json:"document_type"`
+	Document     string    `json:"document"`
+	Phone        string    `json:"phone"`
+	Email        string    `json:"email"`
+	ZipCode      string    `json:"zip_code"`
+	Street       string    `json:"street"`
+	Number       string    `json:"number"`
+	Complement   string    `json:"complement"`
+	District     string    `json:"district"`
+	City         string    `json:"city"`
+	State        string    `json:"state"`
+	Notes        string    `json:"notes"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CustomerSalesSummary representa o histórico de compras do cliente
+type CustomerSalesSummary struct {
+	Customer       CustomerResponse `json:"customer"`
+	TotalPurchases int64            `json:"total_purchases"`
+	LifetimeValue  Money            `json:"lifetime_value"`
+	AverageTicket  Money            `json:"average_ticket"`
+	FirstPurchase  *time.Time       `json:"first_purchase_at"`
+	LastPurchase   *time.Time       `json:"last_purchase_at"`
+	Sales          []SaleResponse   `json:"sales"`
+}
+
+// ToResponse converte Customer para CustomerResponse
+func (c *Customer) ToResponse() CustomerResponse {
+	document := ""
+	if c.Document != nil {
+		document = *c.Document
+	}
+
+	return CustomerResponse{
+		ID:           c.ID,
+		Name:         c.Name,
+		DocumentType: c.DocumentType,
+		Document:     document,
+		Phone:        c.Phone,
+		Email:        c.Email,
+		ZipCode:      c.ZipCode,
+		Street:       c.Street,
+		Number:       c.Number,
+		Complement:   c.Complement,
+		District:     c.District,
+		City:         c.City,
+		State:        c.State,
+		Notes:        c.Notes,
+		Active:       c.Active,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+)
+
+// Situações do documento fiscal
+const (
+	FiscalStatusAuthorized  = "authorized"  // autorizado pela SEFAZ
+	FiscalStatusRejected    = "rejected"    // rejeitado pela SEFAZ
+	FiscalStatusContingency = "contingency" // emitido off-line, aguardando transmissão
+	FiscalStatusCancelled   = "cancelled"   // cancelado por evento homologado
+	FiscalStatusInvalidated = "invalidated" // número inutilizado (documento nunca autorizado)
+)
+
+// FiscalDocument representa a NFC-e emitida para uma venda
+type FiscalDocument struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID    uint       `json:"organization_id" gorm:"not null;default:0;index;uniqueIndex:idx_fiscal_documents_organization_number"` // mesma organização da venda
+	SaleID            uint       `json:"sale_id" gorm:"not null;uniqueIndex"`
+	Model             int        `json:"model" gorm:"not null;default:65"`
+	Series            int        `json:"series" gorm:"not null;uniqueIndex:idx_fiscal_documents_organization_number"`
+	Number            int        `json:"number" gorm:"not null;uniqueIndex:idx_fiscal_documents_organization_number"`
+	AccessKey         string     `json:"access_key" gorm:"size:44;uniqueIndex"`
+	EmissionType      int        `json:"emission_type" gorm:"not null;default:1"` // 1 = normal, 9 = contingência off-line
+	Environment       int        `json:"environment" gorm:"not null"`
+	Status            string     `json:"status" gorm:"not null;index"`
+	StatusCode        int        `json:"status_code"`
+	StatusMessage     string     `json:"status_message"`
+	Protocol          string     `json:"protocol"`
+	QRCodeURL         string     `json:"qr_code_url"`
+	XML               string     `json:"-" gorm:"type:text"`
+	ContingencyReason string     `json:"contingency_reason"`
+	Attempts          int        `json:"attempts" gorm:"default:0"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	AuthorizedAt      *time.Time `json:"authorized_at"`
+	LastAttemptAt     *time.Time `json:"last_attempt_at"`
+	CancelReason      string     `json:"cancel_reason"`
+	CancelProtocol    string     `json:"cancel_protocol"`
+	CancelMessage     string     `json:"cancel_message"` // última resposta da SEFAZ ao evento de cancelamento
+	CancelXML         string     `json:"-" gorm:"type:text"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Sale Sale `json:"-" gorm:"foreignKey:SaleID"`
+}
+
+// FiscalSequence controla a numeração da NFC-e por organização e série
+type FiscalSequence struct {
+	OrganizationID uint      `json:"organization_id" gorm:"primaryKey;autoIncrement:false"`
+	Series         int       `json:"series" gorm:"primaryKey;autoIncrement:false"`
+	NextNumber     int       `json:"next_number" gorm:"not null;default:1"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// FiscalDocumentResponse representa a resposta do documento fiscal
+type FiscalDocumentResponse struct {
+	ID                uint       `json:"id"`
+	SaleID            uint       `json:"sale_id"`
+	Model             int        `json:"model"`
+	Series            int        `json:"series"`
+	Number            int        `json:"number"`
+	AccessKey         string     `json:"access_key"`
+	EmissionType      int        `json:"emission_type"`
+	Environment       int        `json:"environment"`
+	Status            string     `json:"status"`
+	StatusCode        int        `json:"status_code"`
+	StatusMessage     string     `json:"status_message"`
+	Protocol          string     `json:"protocol"`
+	QRCodeURL         string     `json:"qr_code_url"`
+	ContingencyReason string     `json:"contingency_reason,omitempty"`
+	Attempts          int        `json:"attempts"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	AuthorizedAt      *time.Time `json:"authorized_at"`
+	CancelReason      string     `json:"cancel_reason,omitempty"`
+	CancelProtocol    string     `json:"cancel_protocol,omitempty"`
+	CancelMessage     string     `json:"cancel_message,omitempty"`
+	CancelledAt       *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// ToResponse converte FiscalDocument para FiscalDocumentResponse
+func (f *FiscalDocument) ToResponse() FiscalDocumentResponse {
+	return FiscalDocumentResponse{
+		ID:                f.ID,
+		SaleID:            f.SaleID,
+		Model:             f.Model,
+		Series:            f.Series,
+		Number:            f.Number,
+		AccessKey:         f.AccessKey,
+		EmissionType:      f.EmissionType,
+		Environment:       f.Environment,
+		Status:            f.Status,
+		StatusCode:        f.StatusCode,
+		StatusMessage:     f.StatusMessage,
+		Protocol:          f.Protocol,
+		QRCodeURL:         f.QRCodeURL,
+		ContingencyReason: f.ContingencyReason,
+		Attempts:          f.Attempts,
+		IssuedAt:          f.IssuedAt,
+		AuthorizedAt:      f.AuthorizedAt,
+		CancelReason:      f.CancelReason,
+		CancelProtocol:    f.CancelProtocol,
+		CancelMessage:     f.CancelMessage,
+		CancelledAt:       f.CancelledAt,
+	}
+}
+
+// FiscalInvalidation registra a inutilização de uma faixa de numeração da NFC-e
+type FiscalInvalidation struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	Model          int       `json:"model" gorm:"not null;default:65"`
+	Series         int       `json:"series" gorm:"not null;index"`
+	StartNumber    int       `json:"start_number" gorm:"not null"`
+	EndNumber      int       `json:"end_number" gorm:"not null"`
+	Reason         string    `json:"reason" gorm:"not null"`
+	Environment    int       `json:"environment" gorm:"not null"`
+	Status         string    `json:"status" gorm:"not null"` // authorized, rejected
+	StatusCode     int       `json:"status_code"`
+	StatusMessage  string    `json:"status_message"`
+	Protocol       string    `json:"protocol"`
+	XML            string    `json:"-" gorm:"type:text"`
+	UserID         uint      `json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relacionamentos
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// FiscalInvalidationRequest representa a solicitação de inutilização de numeração
+type FiscalInvalidationRequest struct {
+	Series      *int   `json:"series" binding:"omitempty,gte=0,lte=999"`
+	StartNumber int    `json:"start_number" binding:"required,gt=0,lte=999999999"`
+	EndNumber   int    `json:"end_number" binding:"required,gtefield=StartNumber,lte=999999999"`
+	Reason      string `json:"reason" binding:"required,min=15,max=255"`
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"time"
+)
+
+// Status possíveis de um balanço (inventário físico)
+const (
+	InventoryCountOpen      = "open"
+	InventoryCountApproved  = "approved"
+	InventoryCountCancelled = "cancelled"
+)
+
+// Modos de lançamento de contagem
+const (
+	InventoryEntryAdd = "add" // soma à quantidade já contada (leitura item a item)
+	InventoryEntrySet = "set" // substitui a quantidade contada
+)
+
+// InventoryCount representa um balanço de uma loja: ao abrir, o saldo esperado de cada
+// produto (ou variação) na loja é congelado; a aprovação lança a diferença entre contado e
+// esperado como ajuste de estoque da loja
+type InventoryCount struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	StoreID        *uint      `json:"store_id" gorm:"index"` // nulo apenas em organizações sem lojas (estoque consolidado)
+	Description    string     `json:"description"`
+	Status         string     `json:"status" gorm:"default:open;index"` // open, approved, cancelled
+	CategoryID     *uint      `json:"category_id"`                      // escopo do balanço; nulo para todos os produtos
+	Notes          string     `json:"notes"`
+	UserID         uint       `json:"user_id" gorm:"not null"` // quem abriu o balanço
+	ApprovedBy     *uint      `json:"approved_by"`
+	ApprovedAt     *time.Time `json:"approved_at"`
+	CancelledAt    *time.Time `json:"cancelled_at"`
+	CreatedAt      time.Time  `json:"created_at"` // momento do congelamento dos saldos
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Store    *Store               `json:"-" gorm:"foreignKey:StoreID"`
+	Category *Category            `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	User     User                 `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Items    []InventoryCountItem `json:"items,omitempty" gorm:"foreignKey:InventoryCountID"`
+}
+
+type InventoryCountItem struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	InventoryCountID uint       `json:"inventory_count_id" gorm:"not null;uniqueIndex:idx_inventory_count_item"`
+	ProductID        uint       `json:"product_id" gorm:"not null;uniqueIndex:idx_inventory_count_item"`
+	VariantID        *uint      `json:"variant_id" gorm:"uniqueIndex:idx_inventory_count_item"` // produtos com variações são contados por variação
+	ExpectedQuantity float64    `json:"expected_quantity"`                                      // saldo congelado na abertura
+	CountedQuantity  *float64   `json:"counted_quantity"`                                       // nulo enquanto não contado
+	UnitCost         Money      `json:"unit_cost"`                                              // custo congelado na abertura
+	AdjustedQuantity float64    `json:"adjusted_quantity"`                                      // ajuste lançado na aprovação
+	CountedAt        *time.Time `json:"counted_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product         `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+}
+
+// InventoryCountEntry registra cada lançamento de contagem (por usuário e dispositivo)
+type InventoryCountEntry struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	InventoryCountItemID uint      `json:"inventory_count_item_id" gorm:"not null;index"`
+	Mode                 string    `json:"mode"` // add, set
+	Quantity             float64   `json:"quantity"`
+	Device               string    `json:"device"`
+	UserID               uint      `json:"user_id" gorm:"not null"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// InventoryCountRequest representa os dados de entrada para abrir um balanço
+type InventoryCountRequest struct {
+	Description string `json:"description" binding:"max=200"`
+	StoreID     *uint  `json:"store_id"` // padrão: a loja do usuário
+	CategoryID  *uint  `json:"category_id"`
+	Notes       string `json:"notes" binding:"max=1000"`
+}
+
+// InventoryCountEntriesRequest representa lançamentos de contagem enviados por um dispositivo
+type InventoryCountEntriesRequest struct {
+	Device  string                       `json:"device" binding:"max=100"`
+	Entries []InventoryCountEntryRequest `json:"entries" binding:"required,min=1,dive"`
+}
+
+type InventoryCountEntryRequest struct {
+	ProductID uint    `json:"product_id" binding:"required_without=Barcode"`
+	Barcode   string  `json:"barcode" binding:"max=50"` // o código da variação identifica a variação
+	VariantID *uint   `json:"variant_id"`               // obrigatório para produtos com variações
+	Quantity  float64 `json:"quantity" binding:"gte=0"`
+	Mode      string  `json:"mode" binding:"omitempty,oneof=add set"` // padrão: add
+}
+
+// ApproveInventoryCountRequest representa a aprovação do balanço
+type ApproveInventoryCountRequest struct {
+	ZeroUncounted bool   `json:"zero_uncounted"` // produtos não contados passam a ter saldo zero
+	Notes         string `json:"notes" binding:"max=500"`
+}
+
+// InventoryCountResponse representa a resposta do balanço
+type InventoryCountResponse struct {
+	ID          uint                         `json:"id"`
+	Description string                       `json:"description"`
+	StoreID     *uint                        `json:"store_id"`
+	StoreName   string                       `json:"store_name,omitempty"`
+	Status      string                       `json:"status"`
+	CategoryID  *uint                        `json:"category_id"`
+	Notes       string                       `json:"notes"`
+	UserID      uint                         `json:"user_id"`
+	User        UserResponse                 `json:"user,omitempty"`
+	ApprovedBy  *uint                        `json:"approved_by"`
+	ApprovedAt  *time.Time                   `json:"approved_at"`
+	CancelledAt *time.Time                   `json:"cancelled_at"`
+	Summary     InventoryCountSummary        `json:"summary"`
+	Items       []InventoryCountItemResponse `json:"items,omitempty"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+}
+
+type InventoryCountItemResponse struct {
+	ID               uint       `json:"id"`
+	ProductID        uint       `json:"product_id"`
+	ProductName      string     `json:"product_name"`
+	VariantID        *uint      `json:"variant_id"`
+	VariantName      string     `json:"variant_name,omitempty"`
+	Barcode          string     `json:"barcode"`
+	Unit             string     `json:"unit"`
+	ExpectedQuantity float64    `json:"expected_quantity"`
+	CountedQuantity  *float64   `json:"counted_quantity"`
+	Variance         float64    `json:"variance"` // contado - esperado (0 se não contado)
+	UnitCost         Money      `json:"unit_cost"`
+	VarianceCost     Money      `json:"variance_cost"` // impacto da diferença no valor do estoque
+	AdjustedQuantity float64    `json:"adjusted_quantity"`
+	CountedAt        *time.Time `json:"counted_at"`
+}
+
+// InventoryCountSummary resume o balanço e o impacto financeiro das diferenças
+type InventoryCountSummary struct {
+	TotalItems     int   `json:"total_items"`
+	CountedItems   int   `json:"counted_items"`
+	UncountedItems int   `json:"uncounted_items"`
+	VarianceItems  int   `json:"variance_items"` // itens contados com diferença
+	ExpectedValue  Money `json:"expected_value"` // valor do estoque esperado (itens contados)
+	CountedValue   Money `json:"counted_value"`  // valor do estoque contado
+	ShortageValue  Money `json:"shortage_value"` // perdas (valor negativo)
+	SurplusValue   Money `json:"surplus_value"`  // sobras
+	NetVariance    Money `json:"net_variance"`   // sobras + perdas
+}
+
+// Variance retorna a diferença entre contado e esperado (zero se o item não foi contado)
+func (i *InventoryCountItem) Variance() float64 {
+	if i.CountedQuantity == nil {
+		return 0
+	}
+	return RoundQuantity(*i.CountedQuantity - i.ExpectedQuantity)
+}
+
+// ToResponse converte InventoryCountItem para InventoryCountItemResponse
+func (i *InventoryCountItem) ToResponse() InventoryCountItemResponse {
+	response := InventoryCountItemResponse{
+		ID:               i.ID,
+		ProductID:        i.ProductID,
+		ProductName:      i.Product.Name,
+		VariantID:        i.VariantID,
+		Barcode:          i.Product.Barcode,
+		Unit:             i.Product.Unit,
+		ExpectedQuantity: i.ExpectedQuantity,
+		CountedQuantity:  i.CountedQuantity,
+		Variance:         i.Variance(),
+		UnitCost:         i.UnitCost,
+		VarianceCost:     i.UnitCost.Mul(i.Variance()),
+		AdjustedQuantity: i.AdjustedQuantity,
+		CountedAt:        i.CountedAt,
+	}
+	if i.Variant != nil {
+		response.VariantName = i.Variant.Name
+		response.Barcode = i.Variant.BarcodeValue()
+	}
+	return response
+}
+
+// ToResponse converte InventoryCount para InventoryCountResponse. O resumo considera todos
+// os itens carregados, mesmo quando a lista retornada é filtrada
+func (c *InventoryCount) ToResponse() InventoryCountResponse {
+	response := InventoryCountResponse{
+		ID:          c.ID,
+		Description: c.Description,
+		StoreID:     c.StoreID,
+		Status:      c.Status,
+		CategoryID:  c.CategoryID,
+		Notes:       c.Notes,
+		UserID:      c.UserID,
+		User:        c.User.ToResponse(),
+		ApprovedBy:  c.ApprovedBy,
+		ApprovedAt:  c.ApprovedAt,
+		CancelledAt: c.CancelledAt,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+
+	if c.Store != nil {
+		response.StoreName = c.Store.Name
+	}
+
+	summary := &response.Summary
+	for _, item := range c.Items {
+		summary.TotalItems++
+		if item.CountedQuantity == nil {
+			summary.UncountedItems++
+			continue
+		}
+		summary.CountedItems++
+		summary.ExpectedValue += item.UnitCost.Mul(item.ExpectedQuantity)
+		summary.CountedValue += item.UnitCost.Mul(*item.CountedQuantity)
+
+		variance := item.Variance()
+		if variance == 0 {
+			continue
+		}
+		summary.VarianceItems++
+		if cost := item.UnitCost.Mul(variance); cost < 0 {
+			summary.ShortageValue += cost
+		} else {
+			summary.SurplusValue += cost
+		}
+	}
+	summary.NetVariance = summary.SurplusValue + summary.ShortageValue
+	return response
+}
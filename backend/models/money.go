@@ -0,0 +1,164 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money representa um valor monetário em centavos. É armazenado como inteiro no
+// banco para evitar erros de arredondamento e serializado em JSON como reais (ex.: 12.5)
+type Money int64
+
+// NewMoney converte um valor em reais para centavos, arredondando meio centavo para cima
+func NewMoney(value float64) Money {
+	return Money(math.Round(value * 100))
+}
+
+// Float retorna o valor em reais
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
+
+// Mul multiplica o valor por uma quantidade, arredondando para centavos
+func (m Money) Mul(quantity float64) Money {
+	return Money(math.Round(float64(m) * quantity))
+}
+
+// Percent calcula a porcentagem informada do valor, arredondando para centavos
+func (m Money) Percent(percentage float64) Money {
+	return Money(math.Round(float64(m) * percentage / 100))
+}
+
+// Div divide o valor em n partes, arredondando para centavos
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return 0
+	}
+	return Money(math.Round(float64(m) / float64(n)))
+}
+
+// String formata o valor com duas casas decimais (ex.: 12.50)
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON serializa o valor em reais
+func (m Money) MarshalJSON() ([]byte, error) {
+	value := m.String()
+	value = strings.TrimSuffix(value, "0")
+	value = strings.TrimSuffix(value, ".0")
+	return []byte(value), nil
+}
+
+// UnmarshalJSON aceita o valor em reais como número ou texto
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*m = 0
+		return nil
+	}
+
+	parsed, err := parseMoney(value)
+	if err != nil {
+		return fmt.Errorf("valor monetário inválido: %s", value)
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan lê o valor em centavos do banco
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case float64:
+		// Somas e colunas numéricas podem retornar em ponto flutuante
+		*m = Money(math.Round(v))
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("tipo não suportado para Money: %T", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(value string) error {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	*m = Money(math.Round(parsed))
+	return nil
+}
+
+// Value grava o valor em centavos no banco
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// parseMoney converte um texto decimal em reais para centavos sem passar por ponto flutuante
+func parseMoney(value string) (Money, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if strings.ContainsAny(value, "eE") {
+		// Notação científica: recorrer ao ponto flutuante
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, err
+		}
+		whole, fraction = strconv.FormatFloat(parsed, 'f', 3, 64), ""
+		whole, fraction, _ = strings.Cut(whole, ".")
+	}
+	if !decimalDigits(whole) || !decimalDigits(fraction) {
+		return 0, fmt.Errorf("valor inválido: %q", value)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	reais, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	// Considerar duas casas decimais e arredondar pela terceira
+	fraction += "000"
+	cents, err := strconv.ParseInt(fraction[:2], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if fraction[2] >= '5' {
+		cents++
+	}
+
+	total := Money(reais*100 + cents)
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// decimalDigits indica se o texto contém apenas dígitos (sem sinal, espaços ou separadores)
+func decimalDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
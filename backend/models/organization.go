@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Organization representa a empresa (tenant) dona dos cadastros e das vendas. Usuários,
+// produtos, categorias e vendas pertencem a uma organização e só são visíveis a ela
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Document  string    `json:"document"` // CNPJ
+	Active    bool      `json:"active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// MaxDiscountPercentage é o desconto máximo (% do total da venda) que o operador pode
+	// conceder sem a permissão sale.discount.above_10 ou a autorização de um supervisor
+	MaxDiscountPercentage float64 `json:"max_discount_percentage" gorm:"default:10"`
+}
+
+// OrganizationRequest representa os dados de entrada para atualizar a organização
+type OrganizationRequest struct {
+	Name     string `json:"name" binding:"required,min=2,max=100"`
+	Document string `json:"document" binding:"max=18"` // CNPJ, com ou sem formatação
+
+	MaxDiscountPercentage *float64 `json:"max_discount_percentage" binding:"omitempty,gte=0,lte=100"`
+}
+
+// Store representa uma loja da organização
+type Store struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	Code           string    `json:"code"`     // código interno da loja
+	Document       string    `json:"document"` // CNPJ da filial
+	Address        string    `json:"address"`
+	Phone          string    `json:"phone"`
+	Active         bool      `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
+
+// StoreRequest representa os dados de entrada para criar/atualizar loja
+type StoreRequest struct {
+	Name     string `json:"name" binding:"required,min=2,max=100"`
+	Code     string `json:"code" binding:"max=20"`
+	Document string `json:"document" binding:"max=18"` // CNPJ, com ou sem formatação
+	Address  string `json:"address" binding:"max=255"`
+	Phone    string `json:"phone" binding:"max=20"`
+	Active   *bool  `json:"active"`
+}
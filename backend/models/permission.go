@@ -0,0 +1,187 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Permissões do sistema
+const (
+	PermissionProductManage       = "product.manage"
+	PermissionProductDelete       = "product.delete"
+	PermissionProductPriceEdit    = "product.price.edit"
+	PermissionProductExport       = "product.export"
+	PermissionCategoryManage      = "category.manage"
+	PermissionCategoryDelete      = "category.delete"
+	PermissionStockAdjust         = "stock.adjust"
+	PermissionStockView           = "stock.view"
+	PermissionStockTransfer       = "stock.transfer"
+	PermissionInventoryManage     = "inventory.manage"
+	PermissionSaleCancel          = "sale.cancel"
+	PermissionSaleDiscountAbove10 = "sale.discount.above_10"
+	PermissionSalePriceOverride   = "sale.price.override"
+	PermissionReportView          = "report.view"
+	PermissionFiscalManage        = "fiscal.manage"
+	PermissionCustomerDelete      = "customer.delete"
+	PermissionPromotionManage     = "promotion.manage"
+	PermissionSupplierManage      = "supplier.manage"
+	PermissionPurchaseManage      = "purchase.manage"
+	PermissionDeviceManage        = "device.manage"
+	PermissionCashManage          = "cash.manage"
+	PermissionCashDrawerOpen      = "cash.drawer.open"
+	PermissionUserManage          = "user.manage"
+	PermissionOrganizationManage  = "organization.manage"
+)
+
+// Permission representa uma permissão do catálogo
+type Permission struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalog é o catálogo de permissões que podem ser concedidas aos usuários
+var PermissionCatalog = []Permission{
+	{PermissionProductManage, "Cadastrar e editar produtos, variações, códigos de barras e lotes"},
+	{PermissionProductDelete, "Excluir produtos e variações"},
+	{PermissionProductPriceEdit, "Alterar preços de venda e de custo, inclusive agendados e por loja"},
+	{PermissionProductExport, "Exportar produtos e arquivos das balanças"},
+	{PermissionCategoryManage, "Cadastrar e editar categorias"},
+	{PermissionCategoryDelete, "Excluir categorias"},
+	{PermissionStockAdjust, "Ajustar o estoque manualmente"},
+	{PermissionStockView, "Consultar as movimentações de estoque"},
+	{PermissionStockTransfer, "Transferir estoque entre lojas"},
+	{PermissionInventoryManage, "Abrir, aprovar e cancelar balanços"},
+	{PermissionSaleCancel, "Cancelar vendas"},
+	{PermissionSaleDiscountAbove10, "Conceder desconto acima do limite da organização (padrão 10%)"},
+	{PermissionSalePriceOverride, "Alterar o preço do item na venda"},
+	{PermissionReportView, "Consultar relatórios, dashboard e histórico de preços"},
+	{PermissionFiscalManage, "Gerenciar documentos fiscais, contingência e inutilizações"},
+	{PermissionCustomerDelete, "Excluir clientes"},
+	{PermissionPromotionManage, "Cadastrar, editar e excluir promoções"},
+	{PermissionSupplierManage, "Cadastrar, editar e excluir fornecedores"},
+	{PermissionPurchaseManage, "Gerenciar pedidos de compra e entrada de notas"},
+	{PermissionDeviceManage, "Configurar impressoras e etiquetas das balanças"},
+	{PermissionCashManage, "Consultar caixas de outros operadores e conferir fechamentos"},
+	{PermissionCashDrawerOpen, "Abrir a gaveta fora de uma venda"},
+	{PermissionUserManage, "Gerenciar usuários e permissões"},
+	{PermissionOrganizationManage, "Gerenciar a organização e as lojas"},
+}
+
+// RolePermissions são os modelos de permissões de cada perfil. Administradores e
+// proprietários têm todas as permissões
+var RolePermissions = map[string][]string{
+	"manager": {
+		PermissionProductManage,
+		PermissionProductPriceEdit,
+		PermissionProductExport,
+		PermissionCategoryManage,
+		PermissionStockAdjust,
+		PermissionStockView,
+		PermissionStockTransfer,
+		PermissionInventoryManage,
+		PermissionSaleCancel,
+		PermissionSaleDiscountAbove10,
+		PermissionSalePriceOverride,
+		PermissionReportView,
+		PermissionFiscalManage,
+		PermissionCustomerDelete,
+		PermissionPromotionManage,
+		PermissionSupplierManage,
+		PermissionPurchaseManage,
+		PermissionDeviceManage,
+		PermissionCashManage,
+		PermissionCashDrawerOpen,
+	},
+	"cashier": {},
+}
+
+// ErrInvalidPermissions indica permissões personalizadas em formato inválido
+var ErrInvalidPermissions = errors.New("permissões do usuário em formato inválido")
+
+// ValidPermission verifica se a permissão existe no catálogo
+func ValidPermission(key string) bool {
+	for _, permission := range PermissionCatalog {
+		if permission.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleTemplate retorna as permissões padrão do perfil
+func RoleTemplate(role string) []string {
+	if role == "admin" || role == "owner" {
+		keys := make([]string, len(PermissionCatalog))
+		for i, permission := range PermissionCatalog {
+			keys[i] = permission.Key
+		}
+		return keys
+	}
+	return RolePermissions[role]
+}
+
+// PermissionOverrides retorna as permissões personalizadas do usuário, gravadas em
+// Permissions como JSON: true concede e false revoga a permissão do perfil
+func (u *User) PermissionOverrides() (map[string]bool, error) {
+	overrides := make(map[string]bool)
+	if u.Permissions == "" {
+		return overrides, nil
+	}
+	if err := json.Unmarshal([]byte(u.Permissions), &overrides); err != nil {
+		return map[string]bool{}, ErrInvalidPermissions
+	}
+	return overrides, nil
+}
+
+// SetPermissionOverrides valida e grava as permissões personalizadas do usuário
+func (u *User) SetPermissionOverrides(overrides map[string]bool) error {
+	for key := range overrides {
+		if !ValidPermission(key) {
+			return errors.New("Permissão inválida: " + key)
+		}
+	}
+	if len(overrides) == 0 {
+		u.Permissions = ""
+		return nil
+	}
+
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	u.Permissions = string(data)
+	return nil
+}
+
+// HasPermission verifica se o usuário possui a permissão, pelo modelo do perfil e pelas
+// permissões personalizadas. Administradores têm sempre todas as permissões, o que evita
+// que percam o acesso à própria gestão de usuários
+func (u *User) HasPermission(key string) bool {
+	if u.Role == "admin" || u.Role == "owner" {
+		return true
+	}
+
+	overrides, _ := u.PermissionOverrides()
+	if granted, ok := overrides[key]; ok {
+		return granted
+	}
+	for _, permission := range RoleTemplate(u.Role) {
+		if permission == key {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectivePermissions retorna as permissões do usuário em ordem alfabética
+func (u *User) EffectivePermissions() []string {
+	keys := []string{}
+	for _, permission := range PermissionCatalog {
+		if u.HasPermission(permission.Key) {
+			keys = append(keys, permission.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
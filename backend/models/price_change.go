@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Origem das alterações de preço
+const (
+	PriceChangeManual    = "manual"    // edição do produto
+	PriceChangeImport    = "import"    // importação de planilha
+	PriceChangePurchase  = "purchase"  // custo médio recalculado na entrada de mercadoria
+	PriceChangeScheduled = "scheduled" // alteração agendada aplicada
+)
+
+// Situação das alterações de preço agendadas
+const (
+	ScheduledPricePending   = "pending"   // aguardando a data de vigência
+	ScheduledPriceApplied   = "applied"   // aplicada ao produto
+	ScheduledPriceCancelled = "cancelled" // cancelada antes da vigência
+	ScheduledPriceFailed    = "failed"    // não aplicada após MaxScheduledPriceAttempts tentativas
+)
+
+// MaxScheduledPriceAttempts é o número de tentativas de aplicar a alteração agendada antes
+// de marcá-la como falha
+const MaxScheduledPriceAttempts = 3
+
+// ErrPriceChangeImmutable indica tentativa de alterar o histórico de preços
+var ErrPriceChangeImmutable = errors.New("histórico de preços não pode ser alterado ou excluído")
+
+// PriceChange representa um lançamento no histórico (somente inclusão) de preço de venda e
+// de custo do produto
+type PriceChange struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID   uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index"`
+	OldPrice         Money     `json:"old_price"`
+	NewPrice         Money     `json:"new_price"`
+	OldCostPrice     Money     `json:"old_cost_price"`
+	NewCostPrice     Money     `json:"new_cost_price"`
+	Source           string    `json:"source" gorm:"not null"`       // manual, import, purchase, scheduled
+	ScheduledPriceID *uint     `json:"scheduled_price_id,omitempty"` // agendamento aplicado, se houver
+	UserID           *uint     `json:"user_id"`
+	Notes            string    `json:"notes"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index"`
+
+	// Relacionamentos
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+	User    *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// BeforeUpdate impede a alteração de lançamentos já registrados
+func (p *PriceChange) BeforeUpdate(tx *gorm.DB) error {
+	return ErrPriceChangeImmutable
+}
+
+// BeforeDelete impede a exclusão de lançamentos já registrados
+func (p *PriceChange) BeforeDelete(tx *gorm.DB) error {
+	return ErrPriceChangeImmutable
+}
+
+// ScheduledPrice representa uma alteração de preço de venda e/ou de custo a ser aplicada ao
+// produto a partir da data de vigência
+type ScheduledPrice struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	ProductID      uint       `json:"product_id" gorm:"not null;index"`
+	Price          *Money     `json:"price"`      // nulo mantém o preço de venda
+	CostPrice      *Money     `json:"cost_price"` // nulo mantém o preço de custo
+	EffectiveAt    time.Time  `json:"effective_at" gorm:"not null;index"`
+	Status         string     `json:"status" gorm:"not null;index;default:pending"`
+	Notes          string     `json:"notes"`
+	UserID         uint       `json:"user_id" gorm:"not null"`
+	AppliedAt      *time.Time `json:"applied_at"`
+	Attempts       int        `json:"attempts" gorm:"default:0"` // tentativas de aplicação com erro
+	LastError      string     `json:"last_error,omitempty"`      // erro da última tentativa
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+	User    *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// ScheduledPriceRequest representa os dados de entrada para agendar alteração de preço
+type ScheduledPriceRequest struct {
+	Price       *Money    `json:"price" binding:"omitempty,gt=0"`
+	CostPrice   *Money    `json:"cost_price" binding:"omitempty,gte=0"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"` // ex.: 2024-05-01T00:00:00-03:00
+	Notes       string    `json:"notes" binding:"max=500"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// PrinterProfile representa uma impressora térmica configurada para os cupons
+type PrinterProfile struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	PaperWidth     int       `json:"paper_width" gorm:"not null;default:80"` // largura da bobina em mm: 58, 80
+	ConnectionType string    `json:"connection_type" gorm:"not null"`        // network, file
+	Address        string    `json:"address" gorm:"not null"`                // host[:porta] (network) ou caminho do dispositivo (file)
+	Header         string    `json:"header"`                                 // linhas do cabeçalho, separadas por quebra de linha
+	Footer         string    `json:"footer"`                                 // linhas do rodapé, separadas por quebra de linha
+	PrintQRCode    bool      `json:"print_qr_code"`
+	CutPaper       bool      `json:"cut_paper"`
+	FeedLines      int       `json:"feed_lines"`
+	IsDefault      bool      `json:"is_default" gorm:"index"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PrinterProfileRequest representa os dados de entrada para criar/atualizar impressora
+type PrinterProfileRequest struct {
+	Name           string `json:"name" binding:"required,min=2,max=100"`
+	PaperWidth     int    `json:"paper_width" binding:"required,oneof=58 80"`
+	ConnectionType string `json:"connection_type" binding:"required,oneof=network file"`
+	Address        string `json:"address" binding:"required,max=255"`
+	Header         string `json:"header" binding:"max=1000"`
+	Footer         string `json:"footer" binding:"max=1000"`
+	PrintQRCode    *bool  `json:"print_qr_code"`
+	CutPaper       *bool  `json:"cut_paper"`
+	FeedLines      *int   `json:"feed_lines" binding:"omitempty,gte=0,lte=20"`
+	IsDefault      bool   `json:"is_default"`
+	Active         *bool  `json:"active"`
+}
+
+// PrintSaleRequest representa os dados de entrada para imprimir o cupom de uma venda
+type PrintSaleRequest struct {
+	PrinterProfileID *uint `json:"printer_profile_id"`                     // usa a impressora padrão se omitido
+	Copies           int   `json:"copies" binding:"omitempty,gte=1,lte=5"` // padrão 1
+}
+
+// OpenCashDrawerRequest representa os dados para abrir a gaveta fora de uma venda
+type OpenCashDrawerRequest struct {
+	PrinterProfileID *uint                  `json:"printer_profile_id"` // usa a impressora padrão se omitido
+	Reason           string                 `json:"reason" binding:"required,max=255"`
+	Authorization    *SupervisorCredentials `json:"authorization"` // aprovação do supervisor, se o operador não tiver a permissão
+}
+
+// PrinterProfileResponse representa a resposta da impressora
+type PrinterProfileResponse struct {
+	ID             uint      `json:"id"`
+	Name           string    `json:"name"`
+	PaperWidth     int       `json:"paper_width"`
+	Columns        int       `json:"columns"`
+	ConnectionType string    `json:"connection_type"`
+	Address        string    `json:"address"`
+	Header         string    `json:"header"`
+	Footer         string    `json:"footer"`
+	PrintQRCode    bool      `json:"print_qr_code"`
+	CutPaper       bool      `json:"cut_paper"`
+	FeedLines      int       `json:"feed_lines"`
+	IsDefault      bool      `json:"is_default"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Columns retorna o número de colunas impressas na largura da bobina
+func (p *PrinterProfile) Columns() int {
+	if p.PaperWidth == 58 {
+		return 32
+	}
+	return 48
+}
+
+// ToResponse converte PrinterProfile para PrinterProfileResponse
+func (p *PrinterProfile) ToResponse() PrinterProfileResponse {
+	return PrinterProfileResponse{
+		ID:             p.ID,
+		Name:           p.Name,
+		PaperWidth:     p.PaperWidth,
+		Columns:        p.Columns(),
+		ConnectionType: p.ConnectionType,
+		Address:        p.Address,
+		Header:         p.Header,
+		Footer:         p.Footer,
+		PrintQRCode:    p.PrintQRCode,
+		CutPaper:       p.CutPaper,
+		FeedLines:      p.FeedLines,
+		IsDefault:      p.IsDefault,
+		Active:         p.Active,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
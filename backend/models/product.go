@@ -1,27 +1,50 @@
 package models
 
 import (
+	"fmt"
+	"math"
 	"time"
 )
 
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Barcode     string    `json:"barcode" gorm:"uniqueIndex"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"not null"`
-	CostPrice   float64   `json:"cost_price"`
-	Stock       int       `json:"stock" gorm:"default:0"`
-	MinStock    int       `json:"min_stock" gorm:"default:0"`
-	Unit        string    `json:"unit" gorm:"default:un"` // un, kg, l, etc
-	Active      bool      `json:"active" gorm:"default:true"`
-	CategoryID  uint      `json:"category_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	Name        string  `json:"name" gorm:"not null"`
+	Barcode     string  `json:"barcode" gorm:"uniqueIndex:idx_products_organization_barcode"` // único na organização
+	Description string  `json:"description"`
+	Price       Money   `json:"price" gorm:"not null"`
+	CostPrice   Money   `json:"cost_price"`
+	Stock       float64 `json:"stock" gorm:"default:0"`
+	MinStock    float64 `json:"min_stock" gorm:"default:0"`
+	Unit        string  `json:"unit" gorm:"default:un"` // un, kg, l, etc
+	Active      bool    `json:"active" gorm:"default:true"`
+	CategoryID  uint    `json:"category_id"`
+	HasVariants bool    `json:"has_variants" gorm:"default:false"` // estoque controlado pelas variações
+	PLU         string  `json:"plu" gorm:"index"`                  // código do produto nas balanças (etiquetas de peso/preço)
+
+	// Dados da etiqueta impressa pelas balanças
+	ShelfLifeDays int     `json:"shelf_life_days" gorm:"default:0"` // validade em dias após a pesagem
+	Tare          float64 `json:"tare" gorm:"default:0"`            // tara da embalagem em kg
+
+	// Dados fiscais usados na emissão da NFC-e
+	NCM      string  `json:"ncm" gorm:"size:8"`
+	CEST     string  `json:"cest" gorm:"size:7"`
+	CFOP     string  `json:"cfop" gorm:"size:4"`
+	CST      string  `json:"cst" gorm:"size:3"` // CST do ICMS ou CSOSN (Simples Nacional)
+	Origin   int     `json:"origin" gorm:"default:0"`
+	ICMSRate float64 `json:"icms_rate" gorm:"default:0"`
+
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_products_organization_barcode,priority:1"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	// Relacionamentos
-	Category  Category   `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	SaleItems []SaleItem `json:"-" gorm:"foreignKey:ProductID"`
+	Category      Category         `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	SaleItems     []SaleItem       `json:"-" gorm:"foreignKey:ProductID"`
+	Variants      []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
+	Barcodes      []ProductBarcode `json:"barcodes,omitempty" gorm:"foreignKey:ProductID"`
+	Batches       []ProductBatch   `json:"-" gorm:"foreignKey:ProductID"`
+	Stores        []StoreProduct   `json:"-" gorm:"foreignKey:ProductID"`
+	StoreVariants []StoreVariant   `json:"-" gorm:"foreignKey:ProductID"`
 }
 
 // ProductRequest representa os dados de entrada para criar/atualizar produto
@@ -29,13 +52,23 @@ type ProductRequest struct {
 	Name        string   `json:"name" binding:"required,min=2,max=200"`
 	Barcode     string   `json:"barcode" binding:"max=50"`
 	Description string   `json:"description" binding:"max=1000"`
-	Price       *float64 `json:"price" binding:"required,gt=0"`
-	CostPrice   *float64 `json:"cost_price" binding:"omitempty,gte=0"`
-	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
-	MinStock    *int     `json:"min_stock" binding:"omitempty,gte=0"`
+	Price       *Money   `json:"price" binding:"required,gt=0"`
+	CostPrice   *Money   `json:"cost_price" binding:"omitempty,gte=0"`
+	Stock       *float64 `json:"stock" binding:"omitempty,gte=0"`
+	MinStock    *float64 `json:"min_stock" binding:"omitempty,gte=0"`
 	Unit        string   `json:"unit" binding:"max=10"`
 	Active      *bool    `json:"active"`
 	CategoryID  *uint    `json:"category_id" binding:"required"`
+	NCM         string   `json:"ncm" binding:"omitempty,len=8,numeric"`
+	CEST        string   `json:"cest" binding:"omitempty,len=7,numeric"`
+	CFOP        string   `json:"cfop" binding:"omitempty,len=4,numeric"`
+	CST         string   `json:"cst" binding:"omitempty,min=2,max=3,numeric"`
+	Origin      *int     `json:"origin" binding:"omitempty,gte=0,lte=8"`
+	ICMSRate    *float64 `json:"icms_rate" binding:"omitempty,gte=0,lte=100"`
+	PLU         string   `json:"plu" binding:"omitempty,max=6,numeric"`
+
+	ShelfLifeDays *int     `json:"shelf_life_days" binding:"omitempty,gte=0,lte=999"`
+	Tare          *float64 `json:"tare" binding:"omitempty,gte=0,lte=9.999"`
 }
 
 // ProductResponse representa a resposta do produto
@@ -44,21 +77,52 @@ type ProductResponse struct {
 	Name        string           `json:"name"`
 	Barcode     string           `json:"barcode"`
 	Description string           `json:"description"`
-	Price       float64          `json:"price"`
-	CostPrice   float64          `json:"cost_price"`
-	Stock       int              `json:"stock"`
-	MinStock    int              `json:"min_stock"`
+	Price       Money            `json:"price"`
+	CostPrice   Money            `json:"cost_price"`
+	Stock       float64          `json:"stock"`
+	MinStock    float64          `json:"min_stock"`
 	Unit        string           `json:"unit"`
 	Active      bool             `json:"active"`
 	CategoryID  uint             `json:"category_id"`
 	Category    CategoryResponse `json:"category,omitempty"`
+	NCM         string           `json:"ncm"`
+	CEST        string           `json:"cest"`
+	CFOP        string           `json:"cfop"`
+	CST         string           `json:"cst"`
+	Origin      int              `json:"origin"`
+	ICMSRate    float64          `json:"icms_rate"`
 	CreatedAt   time.Time        `json:"created_at"`
 	UpdatedAt   time.Time        `json:"updated_at"`
 	LowStock    bool             `json:"low_stock"`
+	HasVariants bool             `json:"has_variants"`
+	PLU         string           `json:"plu"`
+
+	ShelfLifeDays int     `json:"shelf_life_days"`
+	Tare          float64 `json:"tare"`
+
+	Variants []ProductVariantResponse `json:"variants,omitempty"`
+	Barcodes []ProductBarcodeResponse `json:"barcodes,omitempty"` // códigos de barras adicionais
+	Variant  *ProductVariantResponse  `json:"variant,omitempty"`  // variação encontrada pelo código de barras
+	Store    *StoreProductResponse    `json:"store,omitempty"`    // saldo e preço na loja do usuário
+
+	// Quantidade representada pelo código de barras lido (ex.: 12 na caixa ou o peso da
+	// etiqueta da balança), apenas na busca por código de barras
+	Multiplier float64     `json:"multiplier,omitempty"`
+	ScaleLabel *ScaleLabel `json:"scale_label,omitempty"` // dados da etiqueta da balança lida
 }
 
 // ToResponse converte Product para ProductResponse
 func (p *Product) ToResponse() ProductResponse {
+	var variants []ProductVariantResponse
+	for i := range p.Variants {
+		variants = append(variants, p.Variants[i].ToResponse(p))
+	}
+
+	var barcodes []ProductBarcodeResponse
+	for i := range p.Barcodes {
+		barcodes = append(barcodes, p.Barcodes[i].ToResponse())
+	}
+
 	return ProductResponse{
 		ID:          p.ID,
 		Name:        p.Name,
@@ -72,9 +136,22 @@ func (p *Product) ToResponse() ProductResponse {
 		Active:      p.Active,
 		CategoryID:  p.CategoryID,
 		Category:    p.Category.ToResponse(),
+		NCM:         p.NCM,
+		CEST:        p.CEST,
+		CFOP:        p.CFOP,
+		CST:         p.CST,
+		Origin:      p.Origin,
+		ICMSRate:    p.ICMSRate,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 		LowStock:    p.Stock <= p.MinStock,
+		HasVariants: p.HasVariants,
+		PLU:         p.PLU,
+
+		ShelfLifeDays: p.ShelfLifeDays,
+		Tare:          p.Tare,
+		Variants:      variants,
+		Barcodes:      barcodes,
 	}
 }
 
@@ -84,9 +161,49 @@ func (p *Product) IsLowStock() bool {
 }
 
 // UpdateStock atualiza o estoque do produto
-func (p *Product) UpdateStock(quantity int) {
-	p.Stock += quantity
+func (p *Product) UpdateStock(quantity float64) {
+	p.Stock = RoundQuantity(p.Stock + quantity)
 	if p.Stock < 0 {
 		p.Stock = 0
 	}
-}
\ No newline at end of file
+}
+
+// WeightedAverageCost calcula o custo médio ponderado do produto após a entrada da
+// quantidade informada ao custo unitário informado. Saldo negativo é tratado como zero
+func (p *Product) WeightedAverageCost(quantity float64, unitCost Money) Money {
+	stock := p.Stock
+	if stock < 0 {
+		stock = 0
+	}
+	if stock+quantity <= 0 {
+		return unitCost
+	}
+	return Money(math.Round((float64(p.CostPrice)*stock + float64(unitCost)*quantity) / (stock + quantity)))
+}
+
+// fractionalUnits lista as unidades vendidas em quantidades fracionadas (pesados e medidos)
+var fractionalUnits = map[string]bool{
+	"kg": true,
+	"g":  true,
+	"l":  true,
+	"ml": true,
+	"m":  true,
+}
+
+// IsFractionalUnit verifica se a unidade aceita quantidades fracionadas
+func IsFractionalUnit(unit string) bool {
+	return fractionalUnits[unit]
+}
+
+// RoundQuantity arredonda uma quantidade para três casas decimais (grama/mililitro)
+func RoundQuantity(quantity float64) float64 {
+	return math.Round(quantity*1000) / 1000
+}
+
+// ValidateQuantity verifica se a quantidade é compatível com a unidade do produto
+func (p *Product) ValidateQuantity(quantity float64) error {
+	if !IsFractionalUnit(p.Unit) && quantity != math.Trunc(quantity) {
+		return fmt.Errorf("Quantidade fracionada não permitida para %s (unidade: %s)", p.Name, p.Unit)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"pdv-backend/validators"
+)
+
+// ProductBarcode representa um código de barras adicional do produto (DUN-14 da caixa,
+// códigos internos...). O multiplicador indica quantas unidades o código representa
+type ProductBarcode struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_product_barcodes_organization_barcode,priority:1"`
+	ProductID      uint      `json:"product_id" gorm:"not null;index"`
+	VariantID      *uint     `json:"variant_id" gorm:"index"`                                                                  // obrigatório para produtos com variações
+	Barcode        string    `json:"barcode" gorm:"not null;uniqueIndex:idx_product_barcodes_organization_barcode,priority:2"` // único na organização
+	Multiplier     float64   `json:"multiplier" gorm:"not null"`                                                               // ex.: 12 para a caixa com 12 unidades
+	Description    string    `json:"description"`                                                                              // ex.: "Caixa com 12"
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product         `json:"-" gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+}
+
+// ProductBarcodeRequest representa os dados de entrada para cadastrar um código de barras adicional
+type ProductBarcodeRequest struct {
+	Barcode     string   `json:"barcode" binding:"required,max=50"`
+	Multiplier  *float64 `json:"multiplier" binding:"omitempty,gt=0"` // padrão: 1
+	Description string   `json:"description" binding:"max=100"`
+	VariantID   *uint    `json:"variant_id"`
+}
+
+// ProductBarcodeResponse representa a resposta do código de barras adicional
+type ProductBarcodeResponse struct {
+	ID          uint      `json:"id"`
+	ProductID   uint      `json:"product_id"`
+	VariantID   *uint     `json:"variant_id"`
+	Barcode     string    `json:"barcode"`
+	Type        string    `json:"type"` // EAN-8, UPC-A, EAN-13, DUN-14 ou vazio para códigos internos
+	Multiplier  float64   `json:"multiplier"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToResponse converte ProductBarcode para ProductBarcodeResponse
+func (b *ProductBarcode) ToResponse() ProductBarcodeResponse {
+	return ProductBarcodeResponse{
+		ID:          b.ID,
+		ProductID:   b.ProductID,
+		VariantID:   b.VariantID,
+		Barcode:     b.Barcode,
+		Type:        validators.GTINType(b.Barcode),
+		Multiplier:  b.Multiplier,
+		Description: b.Description,
+		CreatedAt:   b.CreatedAt,
+	}
+}
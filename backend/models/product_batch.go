@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrBatchQuantity indica saída maior que o saldo do lote
+var ErrBatchQuantity = errors.New("Quantidade maior que o saldo do lote")
+
+// ErrBatchStore indica movimentação de um lote em loja diferente da sua
+var ErrBatchStore = errors.New("Lote pertence a outra loja")
+
+// ProductBatch representa um lote do produto (ou da variação) com data de validade, no
+// saldo de uma loja. A soma do saldo dos lotes nunca passa do estoque do produto: o
+// restante é estoque sem lote. Lotes transferidos entre lojas mantêm número e validade,
+// com um registro por loja; lotes sem loja atendem qualquer loja
+type ProductBatch struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	ProductID      uint      `json:"product_id" gorm:"not null;index"`
+	VariantID      *uint     `json:"variant_id,omitempty" gorm:"index"`
+	StoreID        *uint     `json:"store_id" gorm:"index"`
+	Code           string    `json:"code" gorm:"not null"` // número do lote
+	ExpiryDate     time.Time `json:"expiry_date" gorm:"not null;index"`
+	Quantity       float64   `json:"quantity" gorm:"default:0"` // saldo atual do lote
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product         `json:"-" gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	Store   *Store          `json:"-" gorm:"foreignKey:StoreID"`
+}
+
+// InStore indica se o lote pode ser movimentado na loja informada (sem loja, qualquer lote)
+func (b *ProductBatch) InStore(storeID *uint) bool {
+	return storeID == nil || b.StoreID == nil || *b.StoreID == *storeID
+}
+
+// ProductBatchMovement registra a parte de uma movimentação de estoque atribuída a um lote,
+// permitindo devolver ao lote de origem o estoque de uma venda cancelada
+type ProductBatchMovement struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	BatchID         uint      `json:"batch_id" gorm:"not null;index"`
+	StockMovementID uint      `json:"stock_movement_id" gorm:"not null;index"`
+	StoreID         *uint     `json:"store_id" gorm:"index"`    // loja da movimentação de estoque
+	Quantity        float64   `json:"quantity" gorm:"not null"` // positivo para entradas, negativo para saídas
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relacionamentos
+	Batch ProductBatch `json:"-" gorm:"foreignKey:BatchID"`
+}
+
+// ProductBatchRequest representa os dados de entrada para criar/atualizar lote
+type ProductBatchRequest struct {
+	Code       string   `json:"code" binding:"required,max=50"`
+	ExpiryDate string   `json:"expiry_date" binding:"required"`    // YYYY-MM-DD
+	VariantID  *uint    `json:"variant_id"`                        // obrigatório para produtos com variações
+	StoreID    *uint    `json:"store_id"`                          // apenas na criação; padrão: a loja do usuário
+	Quantity   *float64 `json:"quantity" binding:"omitempty,gt=0"` // apenas na criação
+	FromStock  bool     `json:"from_stock"`                        // usa estoque já existente sem lote, sem registrar entrada
+}
+
+// ProductBatchResponse representa a resposta do lote
+type ProductBatchResponse struct {
+	ID           uint      `json:"id"`
+	ProductID    uint      `json:"product_id"`
+	ProductName  string    `json:"product_name,omitempty"`
+	VariantID    *uint     `json:"variant_id,omitempty"`
+	VariantName  string    `json:"variant_name,omitempty"`
+	StoreID      *uint     `json:"store_id"`
+	StoreName    string    `json:"store_name,omitempty"`
+	Code         string    `json:"code"`
+	ExpiryDate   string    `json:"expiry_date"` // YYYY-MM-DD
+	Quantity     float64   `json:"quantity"`
+	DaysToExpiry int       `json:"days_to_expiry"` // negativo para lotes vencidos
+	Expired      bool      `json:"expired"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DaysToExpiry retorna quantos dias faltam para o vencimento do lote a partir da data
+// informada (zero vence no dia, negativo já vencido)
+func (b *ProductBatch) DaysToExpiry(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	expiry := time.Date(b.ExpiryDate.Year(), b.ExpiryDate.Month(), b.ExpiryDate.Day(), 0, 0, 0, 0, time.Local)
+	return int(math.Round(expiry.Sub(today).Hours() / 24))
+}
+
+// ToResponse converte ProductBatch para ProductBatchResponse; os nomes do produto, da
+// variação e da loja são preenchidos quando carregados
+func (b *ProductBatch) ToResponse() ProductBatchResponse {
+	days := b.DaysToExpiry(time.Now())
+	response := ProductBatchResponse{
+		ID:           b.ID,
+		ProductID:    b.ProductID,
+		ProductName:  b.Product.Name,
+		VariantID:    b.VariantID,
+		StoreID:      b.StoreID,
+		Code:         b.Code,
+		ExpiryDate:   b.ExpiryDate.Format("2006-01-02"),
+		Quantity:     b.Quantity,
+		DaysToExpiry: days,
+		Expired:      days < 0,
+		CreatedAt:    b.CreatedAt,
+		UpdatedAt:    b.UpdatedAt,
+	}
+	if b.Variant != nil {
+		response.VariantName = b.Variant.Name
+	}
+	if b.Store != nil {
+		response.StoreName = b.Store.Name
+	}
+	return response
+}
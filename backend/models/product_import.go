@@ -0,0 +1,35 @@
+package models
+
+// Ações aplicadas a cada linha da importação de produtos
+const (
+	ProductImportCreate = "create"
+	ProductImportUpdate = "update"
+)
+
+// ProductImportResponse representa o relatório da importação de produtos. Em simulação
+// (dry_run) ou havendo erros, nada é gravado
+type ProductImportResponse struct {
+	Error     string               `json:"error,omitempty"`
+	DryRun    bool                 `json:"dry_run"`
+	Applied   bool                 `json:"applied"`
+	TotalRows int                  `json:"total_rows"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Columns   map[string]string    `json:"columns"` // campo do produto → coluna da planilha
+	Rows      []ProductImportRow   `json:"rows"`
+	Errors    []ProductImportError `json:"errors"`
+}
+
+type ProductImportRow struct {
+	Row       int    `json:"row"` // linha na planilha (o cabeçalho é a linha 1)
+	Action    string `json:"action"`
+	ProductID uint   `json:"product_id,omitempty"`
+	Barcode   string `json:"barcode"`
+	Name      string `json:"name"`
+}
+
+type ProductImportError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
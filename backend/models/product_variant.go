@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrVariantRequired indica movimentação de estoque sem variação em produto com variações
+var ErrVariantRequired = errors.New("Produto possui variações: informe a variação")
+
+// VariantAttributes guarda os atributos da variação (ex.: {"tamanho": "42", "cor": "preto"})
+// como JSON no banco
+type VariantAttributes map[string]string
+
+// Value implementa driver.Valuer
+func (a VariantAttributes) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(a))
+	return string(data), err
+}
+
+// Scan implementa sql.Scanner
+func (a *VariantAttributes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = VariantAttributes{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return errors.New("tipo inválido para atributos da variação")
+	}
+	attributes := map[string]string{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &attributes); err != nil {
+			return err
+		}
+	}
+	*a = attributes
+	return nil
+}
+
+// Label monta o nome da variação a partir dos atributos, em ordem alfabética das chaves
+// (ex.: "preto / 42")
+func (a VariantAttributes) Label() string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value := strings.TrimSpace(a[key]); value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, " / ")
+}
+
+// ProductVariant representa uma variação do produto (tamanho, cor...) com código de barras,
+// preço e estoque próprios. O estoque do produto pai é a soma do estoque das variações
+type ProductVariant struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	OrganizationID uint              `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_product_variants_organization_barcode,priority:1"`
+	ProductID      uint              `json:"product_id" gorm:"not null;index"`
+	Name           string            `json:"name" gorm:"not null"` // ex.: "42 / Preto"
+	Attributes     VariantAttributes `json:"attributes" gorm:"type:text"`
+	Barcode        *string           `json:"barcode" gorm:"uniqueIndex:idx_product_variants_organization_barcode,priority:2"` // único na organização
+	Price          *Money            `json:"price"`                                                                           // nulo usa o preço do produto
+	Stock          float64           `json:"stock" gorm:"default:0"`
+	MinStock       float64           `json:"min_stock" gorm:"default:0"`
+	Active         bool              `json:"active"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+}
+
+// ProductVariantRequest representa os dados de entrada para criar/atualizar variação
+type ProductVariantRequest struct {
+	Name       string            `json:"name" binding:"max=100"` // vazio monta o nome pelos atributos
+	Attributes map[string]string `json:"attributes"`
+	Barcode    string            `json:"barcode" binding:"max=50"`
+	Price      *Money            `json:"price" binding:"omitempty,gt=0"`
+	Stock      *float64          `json:"stock" binding:"omitempty,gte=0"` // estoque inicial (apenas na criação)
+	MinStock   *float64          `json:"min_stock" binding:"omitempty,gte=0"`
+	Active     *bool             `json:"active"`
+}
+
+// ProductVariantResponse representa a resposta da variação
+type ProductVariantResponse struct {
+	ID         uint              `json:"id"`
+	ProductID  uint              `json:"product_id"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes"`
+	Barcode    string            `json:"barcode"`
+	Price      *Money            `json:"price"`           // preço próprio, se houver
+	FinalPrice Money             `json:"effective_price"` // preço praticado na venda
+	Stock      float64           `json:"stock"`
+	MinStock   float64           `json:"min_stock"`
+	LowStock   bool              `json:"low_stock"`
+	Active     bool              `json:"active"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// EffectivePrice retorna o preço de venda da variação: o próprio ou o do produto
+func (v *ProductVariant) EffectivePrice(product *Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// BarcodeValue retorna o código de barras da variação ou vazio
+func (v *ProductVariant) BarcodeValue() string {
+	if v.Barcode == nil {
+		return ""
+	}
+	return *v.Barcode
+}
+
+// ToResponse converte ProductVariant para ProductVariantResponse, usando o produto pai para
+// resolver o preço efetivo
+func (v *ProductVariant) ToResponse(product *Product) ProductVariantResponse {
+	attributes := map[string]string(v.Attributes)
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	return ProductVariantResponse{
+		ID:         v.ID,
+		ProductID:  v.ProductID,
+		Name:       v.Name,
+		Attributes: attributes,
+		Barcode:    v.BarcodeValue(),
+		Price:      v.Price,
+		FinalPrice: v.EffectivePrice(product),
+		Stock:      v.Stock,
+		MinStock:   v.MinStock,
+		LowStock:   v.Stock <= v.MinStock,
+		Active:     v.Active,
+		CreatedAt:  v.CreatedAt,
+		UpdatedAt:  v.UpdatedAt,
+	}
+}
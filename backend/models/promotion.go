@@ -0,0 +1,205 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipos de promoção
+const (
+	PromotionPercentage = "percentage"  // percentual de desconto em um produto ou categoria
+	PromotionComboPrice = "combo_price" // preço fixo para um conjunto de produtos
+	PromotionBuyXPayY   = "buy_x_pay_y" // leve X pague Y de um produto ou categoria
+)
+
+// Promotion representa uma regra de preço aplicada automaticamente na venda
+type Promotion struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	Name           string     `json:"name" gorm:"not null"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type" gorm:"not null;index"` // percentage, combo_price, buy_x_pay_y
+	ProductID      *uint      `json:"product_id" gorm:"index"`    // produto alvo (percentage, buy_x_pay_y)
+	CategoryID     *uint      `json:"category_id" gorm:"index"`   // categoria alvo (percentage, buy_x_pay_y)
+	Percentage     float64    `json:"percentage"`                 // percentual de desconto (percentage)
+	BuyQuantity    int        `json:"buy_quantity"`               // leve X (buy_x_pay_y)
+	PayQuantity    int        `json:"pay_quantity"`               // pague Y (buy_x_pay_y)
+	ComboPrice     Money      `json:"combo_price"`                // preço de cada combo (combo_price)
+	StartsAt       *time.Time `json:"starts_at"`                  // início da vigência
+	EndsAt         *time.Time `json:"ends_at"`                    // fim da vigência (dia inclusivo)
+	StartTime      string     `json:"start_time"`                 // início da janela diária (HH:MM)
+	EndTime        string     `json:"end_time"`                   // fim da janela diária (HH:MM)
+	Weekdays       string     `json:"weekdays"`                   // dias da semana separados por vírgula (0 = domingo); vazio = todos
+	Priority       int        `json:"priority" gorm:"default:0"`  // em caso de empate vence a maior prioridade
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Items []PromotionItem `json:"items,omitempty" gorm:"foreignKey:PromotionID"`
+}
+
+// PromotionItem representa um produto que compõe um combo
+type PromotionItem struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	PromotionID uint    `json:"promotion_id" gorm:"not null;index"`
+	ProductID   uint    `json:"product_id" gorm:"not null"`
+	Quantity    float64 `json:"quantity" gorm:"not null"`
+
+	// Relacionamentos
+	Product Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// PromotionRequest representa os dados de entrada para criar/atualizar promoção
+type PromotionRequest struct {
+	Name        string                 `json:"name" binding:"required,min=2,max=100"`
+	Description string                 `json:"description" binding:"max=500"`
+	Type        string                 `json:"type" binding:"required,oneof=percentage combo_price buy_x_pay_y"`
+	ProductID   *uint                  `json:"product_id"`
+	CategoryID  *uint                  `json:"category_id"`
+	Percentage  float64                `json:"percentage" binding:"omitempty,gt=0,lte=100"`
+	BuyQuantity int                    `json:"buy_quantity" binding:"omitempty,gte=2"`
+	PayQuantity int                    `json:"pay_quantity" binding:"omitempty,gte=1"`
+	ComboPrice  Money                  `json:"combo_price" binding:"omitempty,gt=0"`
+	Items       []PromotionItemRequest `json:"items" binding:"omitempty,dive"`
+	StartsAt    string                 `json:"starts_at"`  // YYYY-MM-DD
+	EndsAt      string                 `json:"ends_at"`    // YYYY-MM-DD
+	StartTime   string                 `json:"start_time"` // HH:MM
+	EndTime     string                 `json:"end_time"`   // HH:MM
+	Weekdays    []int                  `json:"weekdays" binding:"omitempty,dive,gte=0,lte=6"`
+	Priority    int                    `json:"priority"`
+	Active      *bool                  `json:"active"`
+}
+
+// PromotionItemRequest representa um produto do combo
+type PromotionItemRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// PromotionResponse representa a resposta da promoção
+type PromotionResponse struct {
+	ID          uint                    `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Type        string                  `json:"type"`
+	ProductID   *uint                   `json:"product_id"`
+	CategoryID  *uint                   `json:"category_id"`
+	Percentage  float64                 `json:"percentage"`
+	BuyQuantity int                     `json:"buy_quantity"`
+	PayQuantity int                     `json:"pay_quantity"`
+	ComboPrice  Money                   `json:"combo_price"`
+	Items       []PromotionItemResponse `json:"items"`
+	StartsAt    *time.Time              `json:"starts_at"`
+	EndsAt      *time.Time              `json:"ends_at"`
+	StartTime   string                  `json:"start_time"`
+	EndTime     string                  `json:"end_time"`
+	Weekdays    []int                   `json:"weekdays"`
+	Priority    int                     `json:"priority"`
+	Active      bool                    `json:"active"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// PromotionItemResponse representa um produto do combo na resposta
+type PromotionItemResponse struct {
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+}
+
+// ToResponse converte Promotion para PromotionResponse
+func (p *Promotion) ToResponse() PromotionResponse {
+	items := make([]PromotionItemResponse, len(p.Items))
+	for i, item := range p.Items {
+		items[i] = PromotionItemResponse{
+			ProductID:   item.ProductID,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	return PromotionResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Type:        p.Type,
+		ProductID:   p.ProductID,
+		CategoryID:  p.CategoryID,
+		Percentage:  p.Percentage,
+		BuyQuantity: p.BuyQuantity,
+		PayQuantity: p.PayQuantity,
+		ComboPrice:  p.ComboPrice,
+		Items:       items,
+		StartsAt:    p.StartsAt,
+		EndsAt:      p.EndsAt,
+		StartTime:   p.StartTime,
+		EndTime:     p.EndTime,
+		Weekdays:    p.WeekdayList(),
+		Priority:    p.Priority,
+		Active:      p.Active,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// WeekdayList retorna os dias da semana em que a promoção vale (vazio = todos)
+func (p *Promotion) WeekdayList() []int {
+	weekdays := []int{}
+	for _, part := range strings.Split(p.Weekdays, ",") {
+		if day, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			weekdays = append(weekdays, day)
+		}
+	}
+	return weekdays
+}
+
+// ActiveAt verifica se a promoção está ativa, vigente e dentro da janela de dia/horário
+func (p *Promotion) ActiveAt(at time.Time) bool {
+	if !p.Active {
+		return false
+	}
+
+	at = at.Local()
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !at.Before(p.EndsAt.AddDate(0, 0, 1)) {
+		return false
+	}
+
+	if weekdays := p.WeekdayList(); len(weekdays) > 0 {
+		found := false
+		for _, day := range weekdays {
+			if time.Weekday(day) == at.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Janela diária (happy hour); início maior que o fim atravessa a meia-noite
+	if p.StartTime != "" && p.EndTime != "" {
+		now := at.Format("15:04")
+		if p.StartTime <= p.EndTime {
+			return now >= p.StartTime && now < p.EndTime
+		}
+		return now >= p.StartTime || now < p.EndTime
+	}
+	return true
+}
+
+// Matches verifica se a promoção de produto/categoria se aplica ao produto informado
+func (p *Promotion) Matches(productID, categoryID uint) bool {
+	if p.ProductID != nil {
+		return *p.ProductID == productID
+	}
+	if p.CategoryID != nil {
+		return *p.CategoryID == categoryID
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+)
+
+// Status possíveis de uma NF-e de entrada
+const (
+	PurchaseInvoicePending  = "pending"
+	PurchaseInvoiceImported = "imported"
+)
+
+// Formas de associação de um item da NF-e a um produto cadastrado
+const (
+	InvoiceMatchSupplierCode = "supplier_code"
+	InvoiceMatchBarcode      = "barcode"
+	InvoiceMatchNone         = "none"
+)
+
+// PurchaseInvoice guarda o XML da NF-e de fornecedor importada para entrada de mercadorias
+type PurchaseInvoice struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID   uint       `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_purchase_invoices_organization_access_key,priority:1"`
+	AccessKey        string     `json:"access_key" gorm:"size:44;uniqueIndex:idx_purchase_invoices_organization_access_key,priority:2;not null"`
+	Number           int        `json:"number"`
+	Series           int        `json:"series"`
+	IssuedAt         time.Time  `json:"issued_at"`
+	SupplierID       *uint      `json:"supplier_id" gorm:"index"`
+	SupplierDocument string     `json:"supplier_document"`
+	SupplierName     string     `json:"supplier_name"`
+	Total            Money      `json:"total"`
+	XML              string     `json:"-" gorm:"type:text"`
+	Status           string     `json:"status" gorm:"default:pending;index"` // pending, imported
+	PurchaseOrderID  *uint      `json:"purchase_order_id"`                   // pedido gerado na confirmação
+	UserID           uint       `json:"user_id" gorm:"not null"`
+	ImportedAt       *time.Time `json:"imported_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ConfirmPurchaseInvoiceRequest representa as decisões do usuário para dar entrada na NF-e.
+// Itens não informados usam a associação automática (código do fornecedor ou código de barras)
+type ConfirmPurchaseInvoiceRequest struct {
+	CategoryID *uint                               `json:"category_id"` // categoria padrão dos produtos novos
+	Notes      string                              `json:"notes" binding:"max=500"`
+	Items      []ConfirmPurchaseInvoiceItemRequest `json:"items" binding:"omitempty,dive"`
+}
+
+type ConfirmPurchaseInvoiceItemRequest struct {
+	Line       int      `json:"line" binding:"required,gt=0"` // nItem da NF-e
+	ProductID  *uint    `json:"product_id"`                   // associar a um produto existente
+	VariantID  *uint    `json:"variant_id"`                   // variação do produto (obrigatória em produtos com variações)
+	Create     bool     `json:"create"`                       // cadastrar novo produto
+	Skip       bool     `json:"skip"`                         // não dar entrada no item
+	UnitFactor *float64 `json:"unit_factor" binding:"omitempty,gt=0"`
+
+	// Dados do produto novo (create); vazios usam a proposta da NF-e
+	Name       string `json:"name" binding:"max=200"`
+	Barcode    string `json:"barcode" binding:"max=50"`
+	Unit       string `json:"unit" binding:"max=10"`
+	CategoryID *uint  `json:"category_id"`
+	Price      *Money `json:"price" binding:"omitempty,gt=0"`
+}
+
+// PurchaseInvoiceResponse representa a resposta da NF-e importada, com a prévia dos itens
+type PurchaseInvoiceResponse struct {
+	ID               uint                          `json:"id"`
+	AccessKey        string                        `json:"access_key"`
+	Number           int                           `json:"number"`
+	Series           int                           `json:"series"`
+	IssuedAt         time.Time                     `json:"issued_at"`
+	Status           string                        `json:"status"`
+	SupplierID       *uint                         `json:"supplier_id"` // nulo se o fornecedor ainda não está cadastrado
+	SupplierDocument string                        `json:"supplier_document"`
+	SupplierName     string                        `json:"supplier_name"`
+	Total            Money                         `json:"total"`
+	PurchaseOrderID  *uint                         `json:"purchase_order_id"`
+	ImportedAt       *time.Time                    `json:"imported_at"`
+	Items            []PurchaseInvoiceItemResponse `json:"items"`
+	Warnings         []string                      `json:"warnings"`
+	CreatedAt        time.Time                     `json:"created_at"`
+}
+
+type PurchaseInvoiceItemResponse struct {
+	Line          int              `json:"line"`
+	SupplierCode  string           `json:"supplier_code"`
+	Barcode       string           `json:"barcode"`
+	Description   string           `json:"description"`
+	NCM           string           `json:"ncm"`
+	CFOP          string           `json:"cfop"`
+	Unit          string           `json:"unit"`     // unidade comercial da NF-e
+	Quantity      float64          `json:"quantity"` // quantidade comercial da NF-e
+	Total         Money            `json:"total"`    // custo total do item (com frete, IPI, ST...)
+	Match         string           `json:"match"`    // supplier_code, barcode, none
+	ProductID     *uint            `json:"product_id"`
+	Product       *ProductResponse `json:"product,omitempty"`
+	VariantID     *uint            `json:"variant_id,omitempty"`
+	VariantName   string           `json:"variant_name,omitempty"`
+	UnitFactor    float64          `json:"unit_factor"`
+	StockQuantity float64          `json:"stock_quantity"` // quantidade na unidade do produto
+	UnitCost      Money            `json:"unit_cost"`      // custo por unidade do produto
+	Proposed      *ProductRequest  `json:"proposed,omitempty"`
+}
+
+// ToResponse converte PurchaseInvoice para PurchaseInvoiceResponse (sem os itens)
+func (p *PurchaseInvoice) ToResponse() PurchaseInvoiceResponse {
+	return PurchaseInvoiceResponse{
+		ID:               p.ID,
+		AccessKey:        p.AccessKey,
+		Number:           p.Number,
+		Series:           p.Series,
+		IssuedAt:         p.IssuedAt,
+		Status:           p.Status,
+		SupplierID:       p.SupplierID,
+		SupplierDocument: p.SupplierDocument,
+		SupplierName:     p.SupplierName,
+		Total:            p.Total,
+		PurchaseOrderID:  p.PurchaseOrderID,
+		ImportedAt:       p.ImportedAt,
+		Items:            []PurchaseInvoiceItemResponse{},
+		Warnings:         []string{},
+		CreatedAt:        p.CreatedAt,
+	}
+}
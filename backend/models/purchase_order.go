@@ -0,0 +1,160 @@
+package models
+
+import (
+	"time"
+)
+
+// Status possíveis de um pedido de compra
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	SupplierID     uint       `json:"supplier_id" gorm:"not null;index"`
+	Status         string     `json:"status" gorm:"default:draft;index"` // draft, sent, partially_received, received, cancelled
+	Total          Money      `json:"total" gorm:"default:0"`            // soma dos itens pedidos
+	Notes          string     `json:"notes"`
+	ExpectedAt     *time.Time `json:"expected_at"` // previsão de entrega
+	SentAt         *time.Time `json:"sent_at"`
+	ReceivedAt     *time.Time `json:"received_at"` // data do recebimento completo
+	UserID         uint       `json:"user_id" gorm:"not null"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	Supplier Supplier            `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
+	User     User                `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Items    []PurchaseOrderItem `json:"items,omitempty" gorm:"foreignKey:PurchaseOrderID"`
+}
+
+type PurchaseOrderItem struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID  uint      `json:"purchase_order_id" gorm:"not null;index"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index"`
+	VariantID        *uint     `json:"variant_id" gorm:"index"`
+	VariantName      string    `json:"variant_name"`              // nome da variação no momento do pedido
+	Quantity         float64   `json:"quantity" gorm:"not null"`  // quantidade pedida
+	ReceivedQuantity float64   `json:"received_quantity"`         // quantidade já recebida
+	UnitCost         Money     `json:"unit_cost" gorm:"not null"` // custo unitário negociado
+	Total            Money     `json:"total" gorm:"not null"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// PurchaseOrderRequest representa os dados de entrada para criar/atualizar pedido de compra
+type PurchaseOrderRequest struct {
+	SupplierID uint                       `json:"supplier_id" binding:"required"`
+	ExpectedAt string                     `json:"expected_at"` // YYYY-MM-DD
+	Notes      string                     `json:"notes" binding:"max=1000"`
+	Items      []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	VariantID *uint   `json:"variant_id"` // obrigatório para produtos com variações
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	UnitCost  Money   `json:"unit_cost" binding:"gte=0"`
+}
+
+// ReceivePurchaseOrderRequest representa um recebimento (total ou parcial) do pedido.
+// Sem itens, recebe todo o saldo pendente pelo custo do pedido
+type ReceivePurchaseOrderRequest struct {
+	Items []ReceivePurchaseOrderItemRequest `json:"items" binding:"omitempty,dive"`
+	Notes string                            `json:"notes" binding:"max=500"`
+}
+
+type ReceivePurchaseOrderItemRequest struct {
+	ItemID   uint    `json:"item_id" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	UnitCost *Money  `json:"unit_cost" binding:"omitempty,gte=0"` // custo efetivo, se diferente do pedido
+}
+
+// PurchaseOrderResponse representa a resposta do pedido de compra
+type PurchaseOrderResponse struct {
+	ID         uint                        `json:"id"`
+	SupplierID uint                        `json:"supplier_id"`
+	Supplier   SupplierResponse            `json:"supplier"`
+	Status     string                      `json:"status"`
+	Total      Money                       `json:"total"`
+	Notes      string                      `json:"notes"`
+	ExpectedAt *time.Time                  `json:"expected_at"`
+	SentAt     *time.Time                  `json:"sent_at"`
+	ReceivedAt *time.Time                  `json:"received_at"`
+	UserID     uint                        `json:"user_id"`
+	User       UserResponse                `json:"user,omitempty"`
+	Items      []PurchaseOrderItemResponse `json:"items"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	UpdatedAt  time.Time                   `json:"updated_at"`
+}
+
+type PurchaseOrderItemResponse struct {
+	ID               uint            `json:"id"`
+	ProductID        uint            `json:"product_id"`
+	Product          ProductResponse `json:"product,omitempty"`
+	VariantID        *uint           `json:"variant_id,omitempty"`
+	VariantName      string          `json:"variant_name,omitempty"`
+	Quantity         float64         `json:"quantity"`
+	ReceivedQuantity float64         `json:"received_quantity"`
+	PendingQuantity  float64         `json:"pending_quantity"`
+	UnitCost         Money           `json:"unit_cost"`
+	Total            Money           `json:"total"`
+}
+
+// ToResponse converte PurchaseOrder para PurchaseOrderResponse
+func (p *PurchaseOrder) ToResponse() PurchaseOrderResponse {
+	items := make([]PurchaseOrderItemResponse, len(p.Items))
+	for i, item := range p.Items {
+		items[i] = item.ToResponse()
+	}
+
+	return PurchaseOrderResponse{
+		ID:         p.ID,
+		SupplierID: p.SupplierID,
+		Supplier:   p.Supplier.ToResponse(),
+		Status:     p.Status,
+		Total:      p.Total,
+		Notes:      p.Notes,
+		ExpectedAt: p.ExpectedAt,
+		SentAt:     p.SentAt,
+		ReceivedAt: p.ReceivedAt,
+		UserID:     p.UserID,
+		User:       p.User.ToResponse(),
+		Items:      items,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+// ToResponse converte PurchaseOrderItem para PurchaseOrderItemResponse
+func (i *PurchaseOrderItem) ToResponse() PurchaseOrderItemResponse {
+	return PurchaseOrderItemResponse{
+		ID:               i.ID,
+		ProductID:        i.ProductID,
+		Product:          i.Product.ToResponse(),
+		VariantID:        i.VariantID,
+		VariantName:      i.VariantName,
+		Quantity:         i.Quantity,
+		ReceivedQuantity: i.ReceivedQuantity,
+		PendingQuantity:  i.PendingQuantity(),
+		UnitCost:         i.UnitCost,
+		Total:            i.Total,
+	}
+}
+
+// PendingQuantity retorna a quantidade ainda não recebida do item
+func (i *PurchaseOrderItem) PendingQuantity() float64 {
+	pending := RoundQuantity(i.Quantity - i.ReceivedQuantity)
+	if pending < 0 {
+		return 0
+	}
+	return pending
+}
@@ -4,84 +4,172 @@ import (
 	"time"
 )
 
+// CompletedSaleStatuses são as situações de venda contabilizadas como vendidas: a venda
+// pendente de cancelamento continua valendo até o cancelamento ser concluído
+var CompletedSaleStatuses = []string{"completed", "cancel_pending"}
+
 type Sale struct {
 	ID             uint      `json:"id" gorm:"primaryKey"`
-	Total          float64   `json:"total" gorm:"not null"`
-	Discount       float64   `json:"discount" gorm:"default:0"`
-	Tax            float64   `json:"tax" gorm:"default:0"`
-	FinalTotal     float64   `json:"final_total" gorm:"not null"`
-	PaymentType    string    `json:"payment_type" gorm:"not null"` // dinheiro, cartao_credito, cartao_debito, pix
-	AmountReceived *float64  `json:"amount_received" gorm:"default:null"` // valor recebido (apenas para dinheiro)
-	Change         *float64  `json:"change" gorm:"default:null"` // troco (apenas para dinheiro)
-	Status         string    `json:"status" gorm:"default:completed"` // completed, cancelled
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	StoreID        *uint     `json:"store_id" gorm:"index"`     // loja em que a venda foi registrada
+	Total          Money     `json:"total" gorm:"not null"`     // soma dos itens, já com os descontos das promoções
+	Discount       Money     `json:"discount" gorm:"default:0"` // desconto informado pelo operador
+	Tax            Money     `json:"tax" gorm:"default:0"`
+	FinalTotal     Money     `json:"final_total" gorm:"not null"`
+	PaymentType    string    `json:"payment_type" gorm:"not null"`        // dinheiro, cartao_credito, cartao_debito, pix, misto
+	AmountReceived *Money    `json:"amount_received" gorm:"default:null"` // valor recebido (apenas para dinheiro)
+	Change         *Money    `json:"change" gorm:"default:null"`          // troco (apenas para dinheiro)
+	Status         string    `json:"status" gorm:"default:completed"`     // completed, cancelled, cancel_pending
 	UserID         uint      `json:"user_id" gorm:"not null"`
+	CashSessionID  *uint     `json:"cash_session_id" gorm:"index"` // sessão de caixa em que a venda foi registrada
+	CustomerID     *uint     `json:"customer_id" gorm:"index"`     // cliente identificado na venda (opcional)
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 
 	// Relacionamentos
-	User      User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	SaleItems []SaleItem `json:"sale_items,omitempty" gorm:"foreignKey:SaleID"`
+	User      User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Customer  *Customer     `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	SaleItems []SaleItem    `json:"sale_items,omitempty" gorm:"foreignKey:SaleID"`
+	Payments  []SalePayment `json:"payments,omitempty" gorm:"foreignKey:SaleID"`
+
+	FiscalDocument *FiscalDocument           `json:"fiscal_document,omitempty" gorm:"foreignKey:SaleID"`
+	Authorizations []SupervisorAuthorization `json:"authorizations,omitempty" gorm:"foreignKey:SaleID"`
 }
 
 type SaleItem struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	SaleID    uint      `json:"sale_id" gorm:"not null"`
-	ProductID uint      `json:"product_id" gorm:"not null"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	UnitPrice float64   `json:"unit_price" gorm:"not null"`
-	Total     float64   `json:"total" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SaleID         uint      `json:"sale_id" gorm:"not null"`
+	ProductID      uint      `json:"product_id" gorm:"not null"`
+	VariantID      *uint     `json:"variant_id" gorm:"index"`
+	VariantName    string    `json:"variant_name"`    // nome da variação no momento da venda
+	VariantBarcode string    `json:"variant_barcode"` // código de barras da variação no momento da venda
+	Quantity       float64   `json:"quantity" gorm:"not null"`
+	UnitPrice      Money     `json:"unit_price" gorm:"not null"`
+	OriginalPrice  *Money    `json:"original_price"`            // preço de tabela, quando alterado na venda
+	Discount       Money     `json:"discount" gorm:"default:0"` // desconto da promoção aplicada ao item
+	Total          Money     `json:"total" gorm:"not null"`     // quantidade x preço unitário - desconto
+	PromotionID    *uint     `json:"promotion_id" gorm:"index"`
+	PromotionName  string    `json:"promotion_name"` // nome da promoção no momento da venda
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	// Relacionamentos
 	Sale    Sale    `json:"-" gorm:"foreignKey:SaleID"`
 	Product Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
 }
 
+// SalePayment representa uma parcela do pagamento da venda em uma forma de pagamento
+type SalePayment struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SaleID         uint      `json:"sale_id" gorm:"not null;index"`
+	PaymentType    string    `json:"payment_type" gorm:"not null;index"` // dinheiro, cartao_credito, cartao_debito, pix
+	Amount         Money     `json:"amount" gorm:"not null"`             // valor efetivamente abatido da venda
+	AmountReceived Money     `json:"amount_received"`                    // valor entregue pelo cliente nesta forma
+	Change         Money     `json:"change" gorm:"default:0"`            // troco devolvido (apenas dinheiro)
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 // SaleRequest representa os dados de entrada para criar uma venda
 type SaleRequest struct {
-	Items              []SaleItemRequest `json:"items" binding:"required,min=1"`
-	PaymentMethod      string            `json:"payment_method" binding:"required,oneof=dinheiro cartao_credito cartao_debito pix"`
+	Items              []SaleItemRequest    `json:"items" binding:"required,min=1"`
+	PaymentMethod      string               `json:"payment_method" binding:"required_without=Payments,omitempty,oneof=dinheiro cartao_credito cartao_debito pix"`
+	Payments           []SalePaymentRequest `json:"payments" binding:"omitempty,dive"`
+	CustomerID         *uint                `json:"customer_id"`
+	DiscountPercentage *float64             `json:"discount_percentage" binding:"omitempty,gte=0"`
+	AmountReceived     *Money               `json:"amount_received" binding:"omitempty,gte=0"`
+	Discount           *Money               `json:"discount" binding:"omitempty,gte=0"`
+	Tax                *Money               `json:"tax" binding:"omitempty,gte=0"`
+	PaymentType        string               `json:"payment_type" binding:"omitempty,oneof=dinheiro cartao_credito cartao_debito pix"`
+
+	// Authorization são as credenciais do supervisor que aprova o desconto acima do limite
+	// ou a alteração de preços, quando o operador não tem a permissão
+	Authorization *SupervisorCredentials `json:"authorization"`
+}
+
+// SalePreviewRequest representa o carrinho a ser precificado sem registrar a venda
+type SalePreviewRequest struct {
+	Items              []SaleItemRequest `json:"items" binding:"required,min=1,dive"`
 	DiscountPercentage *float64          `json:"discount_percentage" binding:"omitempty,gte=0"`
-	AmountReceived     *float64          `json:"amount_received" binding:"omitempty,gte=0"`
-	Discount           *float64          `json:"discount" binding:"omitempty,gte=0"`
-	Tax                *float64          `json:"tax" binding:"omitempty,gte=0"`
-	PaymentType        string            `json:"payment_type" binding:"omitempty,oneof=dinheiro cartao_credito cartao_debito pix"`
+	Discount           *Money            `json:"discount" binding:"omitempty,gte=0"`
+	Tax                *Money            `json:"tax" binding:"omitempty,gte=0"`
+}
+
+// SalePaymentRequest representa uma forma de pagamento usada na venda
+type SalePaymentRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=dinheiro cartao_credito cartao_debito pix"`
+	Amount        Money  `json:"amount" binding:"required,gt=0"`
+}
+
+// CancelSaleRequest representa os dados do cancelamento de uma venda
+type CancelSaleRequest struct {
+	Reason        string                 `json:"reason" binding:"omitempty,max=255"` // obrigatória (mín. 15 caracteres) se houver NFC-e
+	Authorization *SupervisorCredentials `json:"authorization"`                      // aprovação do supervisor, se o operador não tiver a permissão
 }
 
 type SaleItemRequest struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+	ProductID uint    `json:"product_id" binding:"required_without=Barcode"`
+	Barcode   string  `json:"barcode" binding:"max=50"` // alternativa ao produto; a quantidade é multiplicada pela do código
+	VariantID *uint   `json:"variant_id"`               // obrigatório para produtos com variações
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	UnitPrice *Money  `json:"unit_price"` // preço alterado pelo operador (exige permissão ou autorização)
+
+	LabelTotal *Money `json:"-"` // preço impresso na etiqueta de preço da balança, cobrado como total do item
 }
 
 // SaleResponse representa a resposta da venda
 type SaleResponse struct {
-	ID             uint               `json:"id"`
-	Total          float64            `json:"total"`
-	Discount       float64            `json:"discount"`
-	Tax            float64            `json:"tax"`
-	FinalTotal     float64            `json:"final_total"`
-	PaymentType    string             `json:"payment_type"`
-	AmountReceived *float64           `json:"amount_received,omitempty"`
-	Change         *float64           `json:"change,omitempty"`
-	Status         string             `json:"status"`
-	UserID         uint               `json:"user_id"`
-	User           UserResponse       `json:"user,omitempty"`
-	SaleItems      []SaleItemResponse `json:"sale_items,omitempty"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
+	ID             uint                              `json:"id"`
+	Total          Money                             `json:"total"`
+	Discount       Money                             `json:"discount"`
+	Tax            Money                             `json:"tax"`
+	FinalTotal     Money                             `json:"final_total"`
+	PaymentType    string                            `json:"payment_type"`
+	AmountReceived *Money                            `json:"amount_received,omitempty"`
+	Change         *Money                            `json:"change,omitempty"`
+	Status         string                            `json:"status"`
+	UserID         uint                              `json:"user_id"`
+	StoreID        *uint                             `json:"store_id"`
+	CashSessionID  *uint                             `json:"cash_session_id"`
+	CustomerID     *uint                             `json:"customer_id"`
+	Customer       *CustomerResponse                 `json:"customer,omitempty"`
+	User           UserResponse                      `json:"user,omitempty"`
+	SaleItems      []SaleItemResponse                `json:"sale_items,omitempty"`
+	Payments       []SalePayment                     `json:"payments,omitempty"`
+	FiscalDocument *FiscalDocumentResponse           `json:"fiscal_document,omitempty"`
+	Authorizations []SupervisorAuthorizationResponse `json:"authorizations,omitempty"`
+	CreatedAt      time.Time                         `json:"created_at"`
+	UpdatedAt      time.Time                         `json:"updated_at"`
 }
 
 type SaleItemResponse struct {
-	ID        uint            `json:"id"`
-	SaleID    uint            `json:"sale_id"`
-	ProductID uint            `json:"product_id"`
-	Quantity  int             `json:"quantity"`
-	UnitPrice float64         `json:"unit_price"`
-	Total     float64         `json:"total"`
-	Product   ProductResponse `json:"product,omitempty"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID             uint            `json:"id"`
+	SaleID         uint            `json:"sale_id"`
+	ProductID      uint            `json:"product_id"`
+	VariantID      *uint           `json:"variant_id,omitempty"`
+	VariantName    string          `json:"variant_name,omitempty"`
+	VariantBarcode string          `json:"variant_barcode,omitempty"`
+	Quantity       float64         `json:"quantity"`
+	UnitPrice      Money           `json:"unit_price"`
+	OriginalPrice  *Money          `json:"original_price,omitempty"`
+	Discount       Money           `json:"discount"`
+	Total          Money           `json:"total"`
+	PromotionID    *uint           `json:"promotion_id"`
+	PromotionName  string          `json:"promotion_name,omitempty"`
+	Product        ProductResponse `json:"product,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// SalePreviewResponse representa o carrinho precificado sem registrar a venda
+type SalePreviewResponse struct {
+	Items             []SaleItemResponse `json:"items"`
+	Subtotal          Money              `json:"subtotal"`           // soma dos itens sem desconto
+	PromotionDiscount Money              `json:"promotion_discount"` // descontos das promoções
+	Total             Money              `json:"total"`              // subtotal - descontos das promoções
+	Discount          Money              `json:"discount"`           // desconto informado pelo operador
+	Tax               Money              `json:"tax"`
+	FinalTotal        Money              `json:"final_total"`
 }
 
 // ToResponse converte Sale para SaleResponse
@@ -91,6 +179,23 @@ func (s *Sale) ToResponse() SaleResponse {
 		saleItems[i] = item.ToResponse()
 	}
 
+	var customer *CustomerResponse
+	if s.Customer != nil {
+		response := s.Customer.ToResponse()
+		customer = &response
+	}
+
+	var fiscalDocument *FiscalDocumentResponse
+	if s.FiscalDocument != nil {
+		response := s.FiscalDocument.ToResponse()
+		fiscalDocument = &response
+	}
+
+	var authorizations []SupervisorAuthorizationResponse
+	for i := range s.Authorizations {
+		authorizations = append(authorizations, s.Authorizations[i].ToResponse())
+	}
+
 	return SaleResponse{
 		ID:             s.ID,
 		Total:          s.Total,
@@ -102,8 +207,15 @@ func (s *Sale) ToResponse() SaleResponse {
 		Change:         s.Change,
 		Status:         s.Status,
 		UserID:         s.UserID,
+		StoreID:        s.StoreID,
+		CashSessionID:  s.CashSessionID,
+		CustomerID:     s.CustomerID,
 		User:           s.User.ToResponse(),
+		Customer:       customer,
 		SaleItems:      saleItems,
+		Payments:       s.Payments,
+		FiscalDocument: fiscalDocument,
+		Authorizations: authorizations,
 		CreatedAt:      s.CreatedAt,
 		UpdatedAt:      s.UpdatedAt,
 	}
@@ -112,22 +224,52 @@ func (s *Sale) ToResponse() SaleResponse {
 // ToResponse converte SaleItem para SaleItemResponse
 func (si *SaleItem) ToResponse() SaleItemResponse {
 	return SaleItemResponse{
-		ID:        si.ID,
-		SaleID:    si.SaleID,
-		ProductID: si.ProductID,
-		Quantity:  si.Quantity,
-		UnitPrice: si.UnitPrice,
-		Total:     si.Total,
-		Product:   si.Product.ToResponse(),
-		CreatedAt: si.CreatedAt,
-		UpdatedAt: si.UpdatedAt,
+		ID:             si.ID,
+		SaleID:         si.SaleID,
+		ProductID:      si.ProductID,
+		VariantID:      si.VariantID,
+		VariantName:    si.VariantName,
+		VariantBarcode: si.VariantBarcode,
+		Quantity:       si.Quantity,
+		UnitPrice:      si.UnitPrice,
+		OriginalPrice:  si.OriginalPrice,
+		Discount:       si.Discount,
+		Total:          si.Total,
+		PromotionID:    si.PromotionID,
+		PromotionName:  si.PromotionName,
+		Product:        si.Product.ToResponse(),
+		CreatedAt:      si.CreatedAt,
+		UpdatedAt:      si.UpdatedAt,
 	}
 }
 
+// Description retorna o nome do item vendido: produto seguido da variação, se houver
+func (si *SaleItem) Description() string {
+	if si.VariantName == "" {
+		return si.Product.Name
+	}
+	return si.Product.Name + " " + si.VariantName
+}
+
+// Barcode retorna o código de barras do item vendido: o da variação ou o do produto
+func (si *SaleItem) Barcode() string {
+	if si.VariantBarcode != "" {
+		return si.VariantBarcode
+	}
+	return si.Product.Barcode
+}
+
+// PaymentBreakdown representa o faturamento de uma forma de pagamento
+type PaymentBreakdown struct {
+	PaymentType string `json:"payment_type"`
+	Sales       int64  `json:"sales"`
+	Total       Money  `json:"total"`
+}
+
 // CalculateTotal calcula o total da venda
 func (s *Sale) CalculateTotal() {
 	s.FinalTotal = s.Total - s.Discount + s.Tax
 	if s.FinalTotal < 0 {
 		s.FinalTotal = 0
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/validators"
+)
+
+// Valor embutido na etiqueta da balança
+const (
+	ScaleValuePrice  = "price"  // preço total do item
+	ScaleValueWeight = "weight" // peso (ou quantidade) do item
+)
+
+// scaleLabelLength é o tamanho das etiquetas de balança (EAN-13 de circulação restrita)
+const scaleLabelLength = 13
+
+// ScaleLabelLayout descreve o formato das etiquetas impressas pelas balanças: o prefixo que
+// identifica a etiqueta e as posições (a partir de 1) do código do produto (PLU) e do valor.
+// Ex.: 2CCCC0TTTTTTD tem prefixo "2", código na posição 2 com 4 dígitos e preço na posição 7
+// com 6 dígitos
+type ScaleLabelLayout struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	Prefix         string    `json:"prefix" gorm:"not null"` // ex.: "2", "20"
+	CodeStart      int       `json:"code_start" gorm:"not null"`
+	CodeLength     int       `json:"code_length" gorm:"not null"`
+	ValueStart     int       `json:"value_start" gorm:"not null"`
+	ValueLength    int       `json:"value_length" gorm:"not null"`
+	ValueType      string    `json:"value_type" gorm:"not null"` // price, weight
+	ValueDecimals  int       `json:"value_decimals"`             // casas decimais do valor (2 para preço, 3 para kg)
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ScaleLabelLayoutRequest representa os dados de entrada para criar/atualizar formato de etiqueta
+type ScaleLabelLayoutRequest struct {
+	Name          string `json:"name" binding:"required,min=2,max=100"`
+	Prefix        string `json:"prefix" binding:"required,max=3,numeric"`
+	CodeStart     int    `json:"code_start" binding:"required,gte=2,lte=12"`
+	CodeLength    int    `json:"code_length" binding:"required,gte=1,lte=6"`
+	ValueStart    int    `json:"value_start" binding:"required,gte=2,lte=12"`
+	ValueLength   int    `json:"value_length" binding:"required,gte=1,lte=7"`
+	ValueType     string `json:"value_type" binding:"required,oneof=price weight"`
+	ValueDecimals *int   `json:"value_decimals" binding:"omitempty,gte=0,lte=3"` // padrão: 2 para preço, 3 para peso
+	Active        *bool  `json:"active"`
+}
+
+// ScaleLabelLayoutResponse representa a resposta do formato de etiqueta
+type ScaleLabelLayoutResponse struct {
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	Prefix        string    `json:"prefix"`
+	CodeStart     int       `json:"code_start"`
+	CodeLength    int       `json:"code_length"`
+	ValueStart    int       `json:"value_start"`
+	ValueLength   int       `json:"value_length"`
+	ValueType     string    `json:"value_type"`
+	ValueDecimals int       `json:"value_decimals"`
+	Pattern       string    `json:"pattern"` // ex.: 2CCCC0TTTTTTD (C: código, T: preço, P: peso, D: dígito)
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ScaleLabel representa os dados lidos de uma etiqueta de balança
+type ScaleLabel struct {
+	LayoutID  uint    `json:"layout_id"`
+	PLU       string  `json:"plu"`
+	ValueType string  `json:"value_type"`
+	Weight    float64 `json:"weight,omitempty"` // etiquetas de peso
+	Price     Money   `json:"price,omitempty"`  // etiquetas de preço
+}
+
+// Validate verifica se as posições do código e do valor cabem na etiqueta, sem sobrepor o
+// prefixo, uma à outra ou o dígito verificador
+func (l *ScaleLabelLayout) Validate() error {
+	prefixEnd := len(l.Prefix)
+	codeEnd := l.CodeStart + l.CodeLength - 1
+	valueEnd := l.ValueStart + l.ValueLength - 1
+
+	if l.CodeStart <= prefixEnd || l.ValueStart <= prefixEnd {
+		return errors.New("Código e valor devem começar após o prefixo")
+	}
+	if codeEnd >= scaleLabelLength || valueEnd >= scaleLabelLength {
+		return errors.New("Código e valor devem terminar antes do dígito verificador (posição 13)")
+	}
+	if l.CodeStart <= valueEnd && l.ValueStart <= codeEnd {
+		return errors.New("Posições do código e do valor se sobrepõem")
+	}
+	return nil
+}
+
+// Pattern descreve o formato da etiqueta (ex.: 2CCCC0TTTTTTD)
+func (l *ScaleLabelLayout) Pattern() string {
+	pattern := []byte(strings.Repeat("0", scaleLabelLength))
+	copy(pattern, l.Prefix)
+	valueChar := byte('T')
+	if l.ValueType == ScaleValueWeight {
+		valueChar = 'P'
+	}
+	for i := l.CodeStart; i < l.CodeStart+l.CodeLength && i <= scaleLabelLength; i++ {
+		pattern[i-1] = 'C'
+	}
+	for i := l.ValueStart; i < l.ValueStart+l.ValueLength && i <= scaleLabelLength; i++ {
+		pattern[i-1] = valueChar
+	}
+	pattern[scaleLabelLength-1] = 'D'
+	return string(pattern)
+}
+
+// Parse lê a etiqueta no formato, verificando prefixo e dígito verificador. O PLU é
+// retornado sem zeros à esquerda
+func (l *ScaleLabelLayout) Parse(code string) (ScaleLabel, bool) {
+	label := ScaleLabel{LayoutID: l.ID, ValueType: l.ValueType}
+	if len(code) != scaleLabelLength || !strings.HasPrefix(code, l.Prefix) || !validators.ValidateGTIN(code) {
+		return label, false
+	}
+
+	label.PLU = NormalizePLU(code[l.CodeStart-1 : l.CodeStart-1+l.CodeLength])
+	value, err := strconv.ParseInt(code[l.ValueStart-1:l.ValueStart-1+l.ValueLength], 10, 64)
+	if err != nil || label.PLU == "" {
+		return label, false
+	}
+
+	scale := math.Pow10(l.ValueDecimals)
+	if l.ValueType == ScaleValueWeight {
+		label.Weight = RoundQuantity(float64(value) / scale)
+	} else {
+		label.Price = Money(math.Round(float64(value) * 100 / scale))
+	}
+	return label, true
+}
+
+// Quantity retorna a quantidade vendida pela etiqueta: o peso lido ou, nas etiquetas de
+// preço, o preço total dividido pelo preço do produto (arredondado a três casas)
+func (s ScaleLabel) Quantity(product *Product) float64 {
+	if s.ValueType == ScaleValueWeight {
+		return s.Weight
+	}
+	if product.Price <= 0 {
+		return 0
+	}
+	return RoundQuantity(float64(s.Price) / float64(product.Price))
+}
+
+// NormalizePLU remove os zeros à esquerda do código da balança
+func NormalizePLU(plu string) string {
+	return strings.TrimLeft(strings.TrimSpace(plu), "0")
+}
+
+// ToResponse converte ScaleLabelLayout para ScaleLabelLayoutResponse
+func (l *ScaleLabelLayout) ToResponse() ScaleLabelLayoutResponse {
+	return ScaleLabelLayoutResponse{
+		ID:            l.ID,
+		Name:          l.Name,
+		Prefix:        l.Prefix,
+		CodeStart:     l.CodeStart,
+		CodeLength:    l.CodeLength,
+		ValueStart:    l.ValueStart,
+		ValueLength:   l.ValueLength,
+		ValueType:     l.ValueType,
+		ValueDecimals: l.ValueDecimals,
+		Pattern:       l.Pattern(),
+		Active:        l.Active,
+		CreatedAt:     l.CreatedAt,
+		UpdatedAt:     l.UpdatedAt,
+	}
+}
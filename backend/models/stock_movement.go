@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de movimentação de estoque
+const (
+	StockMovementSale           = "sale"            // saída por venda
+	StockMovementCancellation   = "cancellation"    // retorno por cancelamento de venda
+	StockMovementManualAdd      = "manual_add"      // entrada manual
+	StockMovementManualSubtract = "manual_subtract" // saída manual
+	StockMovementManualSet      = "manual_set"      // definição manual do saldo
+	StockMovementAdjustment     = "adjustment"      // ajuste (cadastro, edição ou balanço)
+	StockMovementPurchase       = "purchase"        // entrada por compra
+	StockMovementTransferOut    = "transfer_out"    // saída por transferência entre lojas
+	StockMovementTransferIn     = "transfer_in"     // entrada por transferência entre lojas
+)
+
+// ErrStockMovementImmutable indica tentativa de alterar o histórico de estoque
+var ErrStockMovementImmutable = errors.New("movimentações de estoque não podem ser alteradas ou excluídas")
+
+// StockMovement representa um lançamento no histórico (somente inclusão) de estoque do produto
+type StockMovement struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ProductID     uint      `json:"product_id" gorm:"not null;index"`
+	VariantID     *uint     `json:"variant_id,omitempty" gorm:"index"` // variação movimentada, se houver
+	StoreID       *uint     `json:"store_id,omitempty" gorm:"index"`   // loja movimentada, se houver
+	Type          string    `json:"type" gorm:"not null;index"`
+	Quantity      float64   `json:"quantity" gorm:"not null"` // positivo para entradas, negativo para saídas
+	StockBefore   float64   `json:"stock_before"`
+	StockAfter    float64   `json:"stock_after"`
+	ReferenceType string    `json:"reference_type,omitempty"` // ex.: sale
+	ReferenceID   *uint     `json:"reference_id,omitempty"`
+	UserID        *uint     `json:"user_id"`
+	Notes         string    `json:"notes"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+
+	// Relacionamentos
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+	User    *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// BeforeUpdate impede a alteração de movimentações já registradas
+func (m *StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete impede a exclusão de movimentações já registradas
+func (m *StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// StockPosition representa o saldo de um produto em uma data
+type StockPosition struct {
+	ProductID    uint    `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	Barcode      string  `json:"barcode"`
+	CurrentStock float64 `json:"current_stock"`
+	Stock        float64 `json:"stock"`
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"time"
+)
+
+// Status possíveis de uma transferência entre lojas
+const (
+	StockTransferDraft     = "draft"
+	StockTransferShipped   = "shipped"
+	StockTransferReceived  = "received"
+	StockTransferCancelled = "cancelled"
+)
+
+// StockTransfer representa a transferência de mercadorias entre duas lojas da organização.
+// O envio baixa o estoque da loja de origem e o recebimento dá entrada na loja de destino;
+// enquanto isso as quantidades ficam em trânsito, fora do saldo de qualquer loja
+type StockTransfer struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID     uint       `json:"organization_id" gorm:"not null;default:0;index"`
+	OriginStoreID      uint       `json:"origin_store_id" gorm:"not null;index"`
+	DestinationStoreID uint       `json:"destination_store_id" gorm:"not null;index"`
+	Status             string     `json:"status" gorm:"default:draft;index"` // draft, shipped, received, cancelled
+	Notes              string     `json:"notes"`
+	UserID             uint       `json:"user_id" gorm:"not null"`
+	ShippedAt          *time.Time `json:"shipped_at"`
+	ShippedByID        *uint      `json:"shipped_by_id"`
+	ReceivedAt         *time.Time `json:"received_at"`
+	ReceivedByID       *uint      `json:"received_by_id"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relacionamentos
+	OriginStore      Store               `json:"-" gorm:"foreignKey:OriginStoreID"`
+	DestinationStore Store               `json:"-" gorm:"foreignKey:DestinationStoreID"`
+	User             User                `json:"-" gorm:"foreignKey:UserID"`
+	Items            []StockTransferItem `json:"items,omitempty" gorm:"foreignKey:StockTransferID"`
+}
+
+type StockTransferItem struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	StockTransferID  uint      `json:"stock_transfer_id" gorm:"not null;index"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index"`
+	VariantID        *uint     `json:"variant_id" gorm:"index"`  // obrigatória em produtos com variações
+	Quantity         float64   `json:"quantity" gorm:"not null"` // quantidade enviada
+	ReceivedQuantity *float64  `json:"received_quantity"`        // quantidade conferida no recebimento
+	Difference       float64   `json:"difference"`               // enviada - recebida (positiva para faltas)
+	Notes            string    `json:"notes"`                    // observação da divergência
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product         `json:"-" gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+}
+
+// StockTransferRequest representa os dados de entrada para criar/atualizar transferência
+type StockTransferRequest struct {
+	OriginStoreID      uint                       `json:"origin_store_id" binding:"required"`
+	DestinationStoreID uint                       `json:"destination_store_id" binding:"required"`
+	Notes              string                     `json:"notes" binding:"max=1000"`
+	Items              []StockTransferItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type StockTransferItemRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	VariantID *uint   `json:"variant_id"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReceiveStockTransferRequest representa a conferência do recebimento. Itens não informados
+// são considerados recebidos integralmente
+type ReceiveStockTransferRequest struct {
+	Items []ReceiveStockTransferItemRequest `json:"items" binding:"omitempty,dive"`
+	Notes string                            `json:"notes" binding:"max=500"`
+}
+
+type ReceiveStockTransferItemRequest struct {
+	ItemID           uint    `json:"item_id" binding:"required"`
+	ReceivedQuantity float64 `json:"received_quantity" binding:"gte=0"`
+	Notes            string  `json:"notes" binding:"max=255"`
+}
+
+// StockTransferResponse representa a resposta da transferência
+type StockTransferResponse struct {
+	ID                   uint                        `json:"id"`
+	OriginStoreID        uint                        `json:"origin_store_id"`
+	OriginStoreName      string                      `json:"origin_store_name"`
+	DestinationStoreID   uint                        `json:"destination_store_id"`
+	DestinationStoreName string                      `json:"destination_store_name"`
+	Status               string                      `json:"status"`
+	Notes                string                      `json:"notes"`
+	UserID               uint                        `json:"user_id"`
+	User                 UserResponse                `json:"user,omitempty"`
+	ShippedAt            *time.Time                  `json:"shipped_at"`
+	ShippedByID          *uint                       `json:"shipped_by_id"`
+	ReceivedAt           *time.Time                  `json:"received_at"`
+	ReceivedByID         *uint                       `json:"received_by_id"`
+	HasDiscrepancy       bool                        `json:"has_discrepancy"`
+	Items                []StockTransferItemResponse `json:"items"`
+	CreatedAt            time.Time                   `json:"created_at"`
+	UpdatedAt            time.Time                   `json:"updated_at"`
+}
+
+type StockTransferItemResponse struct {
+	ID               uint            `json:"id"`
+	ProductID        uint            `json:"product_id"`
+	Product          ProductResponse `json:"product,omitempty"`
+	VariantID        *uint           `json:"variant_id"`
+	VariantName      string          `json:"variant_name,omitempty"`
+	Quantity         float64         `json:"quantity"`
+	ReceivedQuantity *float64        `json:"received_quantity"`
+	Difference       float64         `json:"difference"`
+	Notes            string          `json:"notes"`
+}
+
+// StockInTransit representa a quantidade de um produto enviada e ainda não recebida por uma loja
+type StockInTransit struct {
+	ProductID            uint    `json:"product_id"`
+	ProductName          string  `json:"product_name"`
+	VariantID            *uint   `json:"variant_id"`
+	VariantName          string  `json:"variant_name,omitempty"`
+	DestinationStoreID   uint    `json:"destination_store_id"`
+	DestinationStoreName string  `json:"destination_store_name"`
+	Quantity             float64 `json:"quantity"`
+	Transfers            int64   `json:"transfers"`
+}
+
+// ToResponse converte StockTransfer para StockTransferResponse
+func (t *StockTransfer) ToResponse() StockTransferResponse {
+	items := make([]StockTransferItemResponse, len(t.Items))
+	hasDiscrepancy := false
+	for i, item := range t.Items {
+		items[i] = item.ToResponse()
+		if item.Difference != 0 {
+			hasDiscrepancy = true
+		}
+	}
+
+	return StockTransferResponse{
+		ID:                   t.ID,
+		OriginStoreID:        t.OriginStoreID,
+		OriginStoreName:      t.OriginStore.Name,
+		DestinationStoreID:   t.DestinationStoreID,
+		DestinationStoreName: t.DestinationStore.Name,
+		Status:               t.Status,
+		Notes:                t.Notes,
+		UserID:               t.UserID,
+		User:                 t.User.ToResponse(),
+		ShippedAt:            t.ShippedAt,
+		ShippedByID:          t.ShippedByID,
+		ReceivedAt:           t.ReceivedAt,
+		ReceivedByID:         t.ReceivedByID,
+		HasDiscrepancy:       hasDiscrepancy,
+		Items:                items,
+		CreatedAt:            t.CreatedAt,
+		UpdatedAt:            t.UpdatedAt,
+	}
+}
+
+// ToResponse converte StockTransferItem para StockTransferItemResponse
+func (i *StockTransferItem) ToResponse() StockTransferItemResponse {
+	response := StockTransferItemResponse{
+		ID:               i.ID,
+		ProductID:        i.ProductID,
+		Product:          i.Product.ToResponse(),
+		VariantID:        i.VariantID,
+		Quantity:         i.Quantity,
+		ReceivedQuantity: i.ReceivedQuantity,
+		Difference:       i.Difference,
+		Notes:            i.Notes,
+	}
+	if i.Variant != nil {
+		response.VariantName = i.Variant.Name
+	}
+	return response
+}
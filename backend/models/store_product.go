@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+// StoreProduct representa o produto em uma loja: saldo de estoque da loja, estoque mínimo
+// e preço de venda próprios. Em produtos com variações o saldo da loja é a soma das
+// variações. O estoque do produto (Product.Stock) é o consolidado da organização
+type StoreProduct struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StoreID   uint      `json:"store_id" gorm:"not null;uniqueIndex:idx_store_product"`
+	ProductID uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_store_product;index"`
+	Stock     float64   `json:"stock" gorm:"default:0"`
+	MinStock  *float64  `json:"min_stock"` // nulo usa o estoque mínimo do produto
+	Price     *Money    `json:"price"`     // nulo usa o preço do produto
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Store   Store   `json:"-" gorm:"foreignKey:StoreID"`
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+}
+
+// StoreProductRequest representa os dados de entrada para configurar o produto na loja
+type StoreProductRequest struct {
+	MinStock *float64 `json:"min_stock" binding:"omitempty,gte=0"` // nulo volta a usar o do produto
+	Price    *Money   `json:"price" binding:"omitempty,gt=0"`      // nulo volta a usar o do produto
+}
+
+// StoreProductResponse representa o saldo e o preço do produto em uma loja
+type StoreProductResponse struct {
+	StoreID        uint      `json:"store_id"`
+	StoreName      string    `json:"store_name"`
+	ProductID      uint      `json:"product_id"`
+	Stock          float64   `json:"stock"`
+	MinStock       float64   `json:"min_stock"`       // estoque mínimo considerado na loja
+	Price          *Money    `json:"price"`           // preço próprio da loja, se houver
+	EffectivePrice Money     `json:"effective_price"` // preço praticado na venda
+	LowStock       bool      `json:"low_stock"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// EffectiveMinStock retorna o estoque mínimo da loja: o próprio ou o do produto
+func (sp *StoreProduct) EffectiveMinStock(product *Product) float64 {
+	if sp.MinStock != nil {
+		return *sp.MinStock
+	}
+	return product.MinStock
+}
+
+// EffectivePrice retorna o preço de venda na loja: o próprio ou o do produto
+func (sp *StoreProduct) EffectivePrice(product *Product) Money {
+	if sp.Price != nil {
+		return *sp.Price
+	}
+	return product.Price
+}
+
+// ToResponse converte StoreProduct para StoreProductResponse
+func (sp *StoreProduct) ToResponse(product *Product) StoreProductResponse {
+	minStock := sp.EffectiveMinStock(product)
+	return StoreProductResponse{
+		StoreID:        sp.StoreID,
+		StoreName:      sp.Store.Name,
+		ProductID:      product.ID,
+		Stock:          sp.Stock,
+		MinStock:       minStock,
+		Price:          sp.Price,
+		EffectivePrice: sp.EffectivePrice(product),
+		LowStock:       sp.Stock <= minStock,
+		UpdatedAt:      sp.UpdatedAt,
+	}
+}
+
+// StoreVariant representa o saldo de uma variação em uma loja. A soma das variações da loja é
+// o saldo do produto na loja (StoreProduct.Stock); o estoque da variação
+// (ProductVariant.Stock) é o consolidado da organização
+type StoreVariant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StoreID   uint      `json:"store_id" gorm:"not null;uniqueIndex:idx_store_variant"`
+	VariantID uint      `json:"variant_id" gorm:"not null;uniqueIndex:idx_store_variant;index"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	Stock     float64   `json:"stock" gorm:"default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// Ações que, sem a permissão correspondente, exigem a autorização de um supervisor
+const (
+	AuthorizationSaleCancel    = "sale_cancel"    // cancelamento de venda
+	AuthorizationSaleDiscount  = "sale_discount"  // desconto acima do limite da organização
+	AuthorizationPriceOverride = "price_override" // alteração do preço do item na venda
+	AuthorizationDrawerOpen    = "drawer_open"    // abertura da gaveta fora de uma venda
+)
+
+// Situações da autorização
+const (
+	AuthorizationApproved = "approved" // aprovada pelo supervisor
+	AuthorizationDenied   = "denied"   // recusada por credenciais inválidas do supervisor
+)
+
+// Limite de credenciais inválidas seguidas antes de bloquear o supervisor para autorizações
+const (
+	MaxAuthorizationFailures = 5
+	AuthorizationLockout     = 15 * time.Minute
+)
+
+// SupervisorAuthorization registra a aprovação, por um supervisor, de uma ação protegida
+// solicitada por um operador sem a permissão necessária, ou a tentativa recusada por
+// credenciais inválidas (ApprovedByID é então o supervisor cujas credenciais foram usadas)
+type SupervisorAuthorization struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;index"`
+	Action         string    `json:"action" gorm:"not null;index"`
+	Permission     string    `json:"permission" gorm:"not null"`
+	Status         string    `json:"status" gorm:"not null;default:approved;index"`
+	RequestedByID  uint      `json:"requested_by_id" gorm:"not null;index"`
+	ApprovedByID   uint      `json:"approved_by_id" gorm:"not null;index"`
+	SaleID         *uint     `json:"sale_id" gorm:"index"`
+	CashSessionID  *uint     `json:"cash_session_id" gorm:"index"`
+	Details        string    `json:"details"` // ex.: desconto concedido ou preços alterados
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+
+	// Relacionamentos
+	RequestedBy User `json:"-" gorm:"foreignKey:RequestedByID"`
+	ApprovedBy  User `json:"-" gorm:"foreignKey:ApprovedByID"`
+}
+
+// SupervisorCredentials representa as credenciais informadas pelo supervisor para aprovar a
+// ação no próprio terminal do operador: e-mail e PIN ou, sem PIN cadastrado, a senha
+type SupervisorCredentials struct {
+	Email    string `json:"email" binding:"required,email"`
+	PIN      string `json:"pin" binding:"omitempty,numeric,min=4,max=8"`
+	Password string `json:"password"`
+}
+
+// SupervisorAuthorizationResponse representa a resposta da autorização
+type SupervisorAuthorizationResponse struct {
+	ID              uint      `json:"id"`
+	Action          string    `json:"action"`
+	Permission      string    `json:"permission"`
+	Status          string    `json:"status"`
+	RequestedByID   uint      `json:"requested_by_id"`
+	RequestedByName string    `json:"requested_by_name"`
+	ApprovedByID    uint      `json:"approved_by_id"`
+	ApprovedByName  string    `json:"approved_by_name"`
+	SaleID          *uint     `json:"sale_id"`
+	CashSessionID   *uint     `json:"cash_session_id"`
+	Details         string    `json:"details"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ToResponse converte SupervisorAuthorization para SupervisorAuthorizationResponse
+func (a *SupervisorAuthorization) ToResponse() SupervisorAuthorizationResponse {
+	return SupervisorAuthorizationResponse{
+		ID:              a.ID,
+		Action:          a.Action,
+		Permission:      a.Permission,
+		Status:          a.Status,
+		RequestedByID:   a.RequestedByID,
+		RequestedByName: a.RequestedBy.Name,
+		ApprovedByID:    a.ApprovedByID,
+		ApprovedByName:  a.ApprovedBy.Name,
+		SaleID:          a.SaleID,
+		CashSessionID:   a.CashSessionID,
+		Details:         a.Details,
+		CreatedAt:       a.CreatedAt,
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+)
+
+type Supplier struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;default:0;uniqueIndex:idx_suppliers_organization_document,priority:1"`
+	Name           string    `json:"name" gorm:"not null"`                                                       // razão social
+	TradeName      string    `json:"trade_name"`                                                                 // nome fantasia
+	DocumentType   string    `json:"document_type"`                                                              // cpf, cnpj
+	Document       *string   `json:"document" gorm:"uniqueIndex:idx_suppliers_organization_document,priority:2"` // único na organização
+	StateReg       string    `json:"state_registration"`                                                         // inscrição estadual
+	ContactName    string    `json:"contact_name"`
+	Phone          string    `json:"phone"`
+	Email          string    `json:"email"`
+	ZipCode        string    `json:"zip_code"`
+	Street         string    `json:"street"`
+	Number         string    `json:"number"`
+	Complement     string    `json:"complement"`
+	District       string    `json:"district"`
+	City           string    `json:"city"`
+	State          string    `json:"state"`
+	Notes          string    `json:"notes"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SupplierRequest representa os dados de entrada para criar/atualizar fornecedor
+type SupplierRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=200"`
+	TradeName   string `json:"trade_name" binding:"max=200"`
+	Document    string `json:"document" binding:"max=20"`
+	StateReg    string `json:"state_registration" binding:"max=20"`
+	ContactName string `json:"contact_name" binding:"max=100"`
+	Phone       string `json:"phone" binding:"max=20"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	ZipCode     string `json:"zip_code" binding:"max=10"`
+	Street      string `json:"street" binding:"max=200"`
+	Number      string `json:"number" binding:"max=20"`
+	Complement  string `json:"complement" binding:"max=100"`
+	District    string `json:"district" binding:"max=100"`
+	City        string `json:"city" binding:"max=100"`
+	State       string `json:"state" binding:"omitempty,len=2"`
+	Notes       string `json:"notes" binding:"max=1000"`
+	Active      *bool  `json:"active"`
+}
+
+// SupplierResponse representa a resposta do fornecedor
+type SupplierResponse struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	TradeName    string    `json:"trade_name"`
+	DocumentType string    `json:"document_type"`
+	Document     string    `json:"document"`
+	StateReg     string    `json:"state_registration"`
+	ContactName  string    `json:"contact_name"`
+	Phone        string    `json:"phone"`
+	Email        string    `json:"email"`
+	ZipCode      string    `json:"zip_code"`
+	Street       string    `json:"street"`
+	Number       string    `json:"number"`
+	Complement   string    `json:"complement"`
+	District     string    `json:"district"`
+	City         string    `json:"city"`
+	State        string    `json:"state"`
+	Notes        string    `json:"notes"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ToResponse converte Supplier para SupplierResponse
+func (s *Supplier) ToResponse() SupplierResponse {
+	document := ""
+	if s.Document != nil {
+		document = *s.Document
+	}
+
+	return SupplierResponse{
+		ID:           s.ID,
+		Name:         s.Name,
+		TradeName:    s.TradeName,
+		DocumentType: s.DocumentType,
+		Document:     document,
+		StateReg:     s.StateReg,
+		ContactName:  s.ContactName,
+		Phone:        s.Phone,
+		Email:        s.Email,
+		ZipCode:      s.ZipCode,
+		Street:       s.Street,
+		Number:       s.Number,
+		Complement:   s.Complement,
+		District:     s.District,
+		City:         s.City,
+		State:        s.State,
+		Notes:        s.Notes,
+		Active:       s.Active,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
+
+// SupplierProduct associa o código do produto no fornecedor (cProd da NF-e) a um produto
+// cadastrado. UnitFactor converte a unidade comercial do fornecedor para a unidade do
+// produto (ex.: caixa com 12 unidades = 12)
+type SupplierProduct struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	SupplierID   uint      `json:"supplier_id" gorm:"not null;uniqueIndex:idx_supplier_product_code"`
+	SupplierCode string    `json:"supplier_code" gorm:"not null;uniqueIndex:idx_supplier_product_code"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index"`
+	VariantID    *uint     `json:"variant_id"` // variação recebida, em produtos com variações
+	UnitFactor   float64   `json:"unit_factor" gorm:"not null;default:1"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relacionamentos
+	Product Product         `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+}
@@ -8,18 +8,24 @@ import (
 )
 
 type User struct {
-	ID             uint      `json:"id" gorm:"primaryKey"`
-	OrganizationID *uint     `json:"organization_id" gorm:"index"` // Referência à organização
-	StoreID        *uint     `json:"store_id" gorm:"index"`        // Loja específica (para multi-loja)
-	Name           string    `json:"name" gorm:"not null"`
-	Email          string    `json:"email" gorm:"uniqueIndex;not null"`
-	Password       string    `json:"-" gorm:"not null"`
-	Role           string    `json:"role" gorm:"default:cashier"` // admin, manager, cashier, owner
-	Permissions    string    `json:"permissions" gorm:"type:text"` // JSON com permissões específicas
-	Active         bool      `json:"active" gorm:"default:true"`
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID *uint      `json:"organization_id" gorm:"index"` // Referência à organização
+	StoreID        *uint      `json:"store_id" gorm:"index"`        // Loja específica (para multi-loja)
+	Name           string     `json:"name" gorm:"not null"`
+	Email          string     `json:"email" gorm:"uniqueIndex;not null"`
+	Password       string     `json:"-" gorm:"not null"`
+	PIN            string     `json:"-"`                            // hash do PIN usado para autorizar ações de outros operadores
+	Role           string     `json:"role" gorm:"default:cashier"`  // admin, manager, cashier, owner
+	Permissions    string     `json:"permissions" gorm:"type:text"` // JSON com permissões personalizadas (ver PermissionOverrides)
+	Active         bool       `json:"active" gorm:"default:true"`
 	LastLogin      *time.Time `json:"last_login"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Credenciais inválidas seguidas ao autorizar ações e o bloqueio resultante
+	AuthorizationFailures    int        `json:"-" gorm:"not null;default:0"`
+	AuthorizationLockedUntil *time.Time `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // BeforeCreate hook para hashear a senha antes de salvar
@@ -52,34 +58,73 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// SetPIN define o PIN de autorização do usuário (vazio remove o PIN)
+func (u *User) SetPIN(pin string) error {
+	if pin == "" {
+		u.PIN = ""
+		return nil
+	}
+	hashedPIN, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PIN = string(hashedPIN)
+	return nil
+}
+
+// CheckPIN verifica se o PIN fornecido está correto
+func (u *User) CheckPIN(pin string) bool {
+	if u.PIN == "" || pin == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PIN), []byte(pin)) == nil
+}
+
+// AuthorizationLocked indica se o usuário está bloqueado para autorizar ações por excesso de
+// credenciais inválidas
+func (u *User) AuthorizationLocked(now time.Time) bool {
+	return u.AuthorizationLockedUntil != nil && now.Before(*u.AuthorizationLockedUntil)
+}
+
 // UserResponse representa a resposta do usuário sem a senha
 type UserResponse struct {
-	ID             uint       `json:"id"`
-	OrganizationID *uint      `json:"organization_id"`
-	StoreID        *uint      `json:"store_id"`
-	Name           string     `json:"name"`
-	Email          string     `json:"email"`
-	Role           string     `json:"role"`
-	Permissions    string     `json:"permissions"`
-	Active         bool       `json:"active"`
-	LastLogin      *time.Time `json:"last_login"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID                   uint            `json:"id"`
+	OrganizationID       *uint           `json:"organization_id"`
+	StoreID              *uint           `json:"store_id"`
+	Name                 string          `json:"name"`
+	Email                string          `json:"email"`
+	Role                 string          `json:"role"`
+	Permissions          map[string]bool `json:"permissions"`                          // permissões personalizadas
+	EffectivePermissions []string        `json:"effective_permissions"`                // permissões do perfil com as personalizadas
+	HasPIN               bool            `json:"has_pin"`                              // possui PIN de autorização
+	LockedUntil          *time.Time      `json:"authorization_locked_until,omitempty"` // bloqueado para autorizar até
+	Active               bool            `json:"active"`
+	LastLogin            *time.Time      `json:"last_login"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
 // ToResponse converte User para UserResponse
 func (u *User) ToResponse() UserResponse {
+	overrides, _ := u.PermissionOverrides()
+	var lockedUntil *time.Time
+	if u.AuthorizationLocked(time.Now()) {
+		lockedUntil = u.AuthorizationLockedUntil
+	}
 	return UserResponse{
-		ID:             u.ID,
-		OrganizationID: u.OrganizationID,
-		StoreID:        u.StoreID,
-		Name:           u.Name,
-		Email:          u.Email,
-		Role:           u.Role,
-		Permissions:    u.Permissions,
-		Active:         u.Active,
-		LastLogin:      u.LastLogin,
-		CreatedAt:      u.CreatedAt,
-		UpdatedAt:      u.UpdatedAt,
+		ID:                   u.ID,
+		OrganizationID:       u.OrganizationID,
+		StoreID:              u.StoreID,
+		Name:                 u.Name,
+		Email:                u.Email,
+		Role:                 u.Role,
+		Permissions:          overrides,
+		EffectivePermissions: u.EffectivePermissions(),
+		HasPIN:               u.PIN != "",
+		LockedUntil:          lockedUntil,
+		Active:               u.Active,
+		LastLogin:            u.LastLogin,
+		CreatedAt:            u.CreatedAt,
+		UpdatedAt:            u.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
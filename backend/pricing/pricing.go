@@ -0,0 +1,187 @@
+package pricing
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pdv-backend/models"
+)
+
+// defaultInterval é o intervalo padrão de verificação das alterações agendadas
+const defaultInterval = time.Minute
+
+// Change descreve a origem de uma alteração de preço a ser registrada no histórico
+type Change struct {
+	Source           string
+	UserID           uint
+	ScheduledPriceID *uint
+	Notes            string
+}
+
+// Record registra no histórico a alteração do preço de venda ou de custo do produto em
+// relação aos valores anteriores. Não registra nada se os preços não mudaram
+func Record(tx *gorm.DB, product *models.Product, oldPrice, oldCostPrice models.Money, change Change) error {
+	if product.Price == oldPrice && product.CostPrice == oldCostPrice {
+		return nil
+	}
+
+	record := models.PriceChange{
+		OrganizationID:   product.OrganizationID,
+		ProductID:        product.ID,
+		OldPrice:         oldPrice,
+		NewPrice:         product.Price,
+		OldCostPrice:     oldCostPrice,
+		NewCostPrice:     product.CostPrice,
+		Source:           change.Source,
+		ScheduledPriceID: change.ScheduledPriceID,
+		Notes:            change.Notes,
+	}
+	if change.UserID != 0 {
+		record.UserID = &change.UserID
+	}
+	return tx.Create(&record).Error
+}
+
+// ApplyDue aplica as alterações agendadas com vigência até o instante informado, na ordem
+// de vigência. Um agendamento com erro não impede os seguintes: o erro é registrado nele e
+// a aplicação é tentada de novo na próxima verificação, até MaxScheduledPriceAttempts
+// tentativas. Retorna quantas foram aplicadas
+func ApplyDue(db *gorm.DB, now time.Time) (int, error) {
+	var due []models.ScheduledPrice
+	if err := db.Where("status = ? AND effective_at <= ?", models.ScheduledPricePending, now).
+		Order("effective_at ASC, id ASC").Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, schedule := range due {
+		ok, err := apply(db, schedule.ID, now)
+		if err != nil {
+			log.Printf("Erro ao aplicar o preço agendado %d: %v", schedule.ID, err)
+			if err := recordFailure(db, schedule, err); err != nil {
+				log.Printf("Erro ao registrar a falha do preço agendado %d: %v", schedule.ID, err)
+			}
+			continue
+		}
+		if ok {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// recordFailure registra o erro da tentativa no agendamento, marcando-o como falha ao
+// atingir MaxScheduledPriceAttempts tentativas
+func recordFailure(db *gorm.DB, schedule models.ScheduledPrice, cause error) error {
+	updates := map[string]interface{}{
+		"attempts":   schedule.Attempts + 1,
+		"last_error": cause.Error(),
+	}
+	if schedule.Attempts+1 >= models.MaxScheduledPriceAttempts {
+		updates["status"] = models.ScheduledPriceFailed
+	}
+	return db.Model(&schedule).Where("status = ?", models.ScheduledPricePending).Updates(updates).Error
+}
+
+// apply aplica um agendamento em transação própria. Agendamentos já processados são
+// ignorados e os de produtos excluídos são cancelados
+func apply(db *gorm.DB, scheduleID uint, now time.Time) (bool, error) {
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var schedule models.ScheduledPrice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, scheduleID).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if schedule.Status != models.ScheduledPricePending {
+		tx.Rollback()
+		return false, nil
+	}
+
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, schedule.ProductID).Error; err != nil {
+		tx.Rollback()
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+		return false, db.Model(&schedule).Update("status", models.ScheduledPriceCancelled).Error
+	}
+
+	oldPrice, oldCostPrice := product.Price, product.CostPrice
+	if schedule.Price != nil {
+		product.Price = *schedule.Price
+	}
+	if schedule.CostPrice != nil {
+		product.CostPrice = *schedule.CostPrice
+	}
+
+	if err := tx.Model(&product).Updates(map[string]interface{}{
+		"price":      product.Price,
+		"cost_price": product.CostPrice,
+	}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := Record(tx, &product, oldPrice, oldCostPrice, Change{
+		Source:           models.PriceChangeScheduled,
+		UserID:           schedule.UserID,
+		ScheduledPriceID: &schedule.ID,
+		Notes:            schedule.Notes,
+	}); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Model(&schedule).Updates(map[string]interface{}{
+		"status":     models.ScheduledPriceApplied,
+		"applied_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit().Error
+}
+
+// StartScheduler aplica periodicamente as alterações de preço agendadas. O intervalo é
+// definido em PRICE_SCHEDULER_INTERVAL_SECONDS (padrão 60; zero desativa)
+func StartScheduler(db *gorm.DB) {
+	interval := defaultInterval
+	if value := os.Getenv("PRICE_SCHEDULER_INTERVAL_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("PRICE_SCHEDULER_INTERVAL_SECONDS inválido, usando %s", defaultInterval)
+		} else {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			applied, err := ApplyDue(db, time.Now())
+			if err != nil {
+				log.Printf("Erro ao buscar preços agendados: %v", err)
+			}
+			if applied > 0 {
+				log.Printf("%d alteração(ões) de preço agendada(s) aplicada(s)", applied)
+			}
+			<-ticker.C
+		}
+	}()
+}
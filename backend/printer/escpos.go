@@ -0,0 +1,239 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Comandos ESC/POS
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	lf  = 0x0A
+)
+
+// Alinhamentos
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+// codePagePC850 seleciona a tabela PC850 (Multilingual), que cobre os acentos do português
+const codePagePC850 = 2
+
+// Builder monta um fluxo de bytes ESC/POS
+type Builder struct {
+	buf     bytes.Buffer
+	columns int
+}
+
+// NewBuilder cria um fluxo para uma bobina com o número de colunas informado
+func NewBuilder(columns int) *Builder {
+	b := &Builder{columns: columns}
+	b.Init()
+	return b
+}
+
+// Columns retorna o número de colunas da bobina
+func (b *Builder) Columns() int {
+	return b.columns
+}
+
+// Init reinicia a impressora e seleciona a tabela de caracteres
+func (b *Builder) Init() *Builder {
+	b.buf.Write([]byte{esc, '@'})
+	b.buf.Write([]byte{esc, 't', codePagePC850})
+	return b
+}
+
+// Align define o alinhamento das próximas linhas
+func (b *Builder) Align(align byte) *Builder {
+	b.buf.Write([]byte{esc, 'a', align})
+	return b
+}
+
+// Bold liga ou desliga o negrito
+func (b *Builder) Bold(on bool) *Builder {
+	b.buf.Write([]byte{esc, 'E', boolByte(on)})
+	return b
+}
+
+// DoubleSize liga ou desliga a fonte com altura e largura duplas
+func (b *Builder) DoubleSize(on bool) *Builder {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	b.buf.Write([]byte{gs, '!', size})
+	return b
+}
+
+// Text escreve o texto sem quebra de linha
+func (b *Builder) Text(text string) *Builder {
+	b.buf.Write(EncodePC850(text))
+	return b
+}
+
+// Line escreve o texto seguido de quebra de linha, quebrando linhas maiores que a bobina
+func (b *Builder) Line(text string) *Builder {
+	for _, line := range Wrap(text, b.columns) {
+		b.Text(line)
+		b.buf.WriteByte(lf)
+	}
+	return b
+}
+
+// Columns2 escreve um texto à esquerda e outro à direita na mesma linha
+func (b *Builder) Columns2(left, right string) *Builder {
+	space := b.columns - utf8.RuneCountInString(right) - 1
+	if space < 1 {
+		space = 1
+	}
+	left = truncate(left, space)
+	padding := b.columns - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if padding < 1 {
+		padding = 1
+	}
+	b.Text(left + strings.Repeat(" ", padding) + right)
+	b.buf.WriteByte(lf)
+	return b
+}
+
+// Separator escreve uma linha tracejada na largura da bobina
+func (b *Builder) Separator() *Builder {
+	return b.Line(strings.Repeat("-", b.columns))
+}
+
+// Feed avança o papel n linhas
+func (b *Builder) Feed(lines int) *Builder {
+	if lines > 0 {
+		b.buf.Write([]byte{esc, 'd', byte(lines)})
+	}
+	return b
+}
+
+// QRCode imprime um QR Code (modelo 2, correção de erro M) com o tamanho de módulo informado
+func (b *Builder) QRCode(data string, moduleSize byte) *Builder {
+	if moduleSize < 1 || moduleSize > 16 {
+		moduleSize = 4
+	}
+	payload := []byte(data)
+	length := len(payload) + 3
+
+	b.buf.Write([]byte{gs, '(', 'k', 4, 0, 49, 65, 50, 0})      // modelo 2
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 67, moduleSize}) // tamanho do módulo
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 69, 49})         // correção de erro M
+	b.buf.Write([]byte{gs, '(', 'k', byte(length % 256), byte(length / 256), 49, 80, 48})
+	b.buf.Write(payload)
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 81, 48}) // imprimir
+	b.buf.WriteByte(lf)
+	return b
+}
+
+// Cut avança o papel e aciona o corte parcial da guilhotina
+func (b *Builder) Cut() *Builder {
+	b.buf.Write([]byte{gs, 'V', 66, 0})
+	return b
+}
+
+// OpenDrawer envia o pulso de abertura da gaveta ligada ao conector da impressora
+func (b *Builder) OpenDrawer() *Builder {
+	b.buf.Write([]byte{esc, 'p', 0, 25, 250})
+	return b
+}
+
+// Bytes retorna o fluxo ESC/POS montado
+func (b *Builder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// pc850 mapeia os caracteres acentuados usados em português para a tabela PC850
+var pc850 = map[rune]byte{
+	'á': 0xA0, 'à': 0x85, 'â': 0x83, 'ã': 0xC6, 'ä': 0x84,
+	'é': 0x82, 'è': 0x8A, 'ê': 0x88, 'ë': 0x89,
+	'í': 0xA1, 'ì': 0x8D, 'î': 0x8C, 'ï': 0x8B,
+	'ó': 0xA2, 'ò': 0x95, 'ô': 0x93, 'õ': 0xE4, 'ö': 0x94,
+	'ú': 0xA3, 'ù': 0x97, 'û': 0x96, 'ü': 0x81,
+	'ç': 0x87, 'ñ': 0xA4,
+	'Á': 0xB5, 'À': 0xB7, 'Â': 0xB6, 'Ã': 0xC7, 'Ä': 0x8E,
+	'É': 0x90, 'È': 0xD4, 'Ê': 0xD2, 'Ë': 0xD3,
+	'Í': 0xD6, 'Ì': 0xDE, 'Î': 0xD7, 'Ï': 0xD8,
+	'Ó': 0xE0, 'Ò': 0xE3, 'Ô': 0xE2, 'Õ': 0xE5, 'Ö': 0x99,
+	'Ú': 0xE9, 'Ù': 0xEB, 'Û': 0xEA, 'Ü': 0x9A,
+	'Ç': 0x80, 'Ñ': 0xA5,
+	'º': 0xA7, 'ª': 0xA6, '°': 0xF8, '§': 0xF5, '£': 0x9C,
+}
+
+// EncodePC850 converte o texto UTF-8 para a tabela PC850; caracteres sem representação
+// são substituídos por '?'
+func EncodePC850(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			encoded = append(encoded, ' ')
+		case r < 0x20:
+			continue
+		case r < 0x80:
+			encoded = append(encoded, byte(r))
+		default:
+			if c, ok := pc850[r]; ok {
+				encoded = append(encoded, c)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+	return encoded
+}
+
+// Wrap quebra o texto em linhas de até width caracteres, preferindo quebrar entre palavras
+func Wrap(text string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > width {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		switch {
+		case len(current) == 0:
+			current = runes
+		case len(current)+1+len(runes) <= width:
+			current = append(append(current, ' '), runes...)
+		default:
+			lines = append(lines, string(current))
+			current = runes
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
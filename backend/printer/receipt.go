@@ -0,0 +1,234 @@
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdv-backend/models"
+	"pdv-backend/validators"
+)
+
+// DefaultColumns é o número de colunas de uma bobina de 80mm na fonte padrão
+const DefaultColumns = 48
+
+// Layout define como o cupom é impresso
+type Layout struct {
+	Columns   int      // colunas da bobina
+	Header    []string // linhas do cabeçalho (razão social, CNPJ, endereço)
+	Footer    []string // mensagens do rodapé
+	QRCode    bool     // imprimir QR Code da NFC-e
+	Cut       bool     // acionar guilhotina ao final
+	FeedLines int      // linhas em branco antes do corte
+}
+
+var paymentLabels = map[string]string{
+	"dinheiro":       "Dinheiro",
+	"cartao_credito": "Cartão de Crédito",
+	"cartao_debito":  "Cartão de Débito",
+	"pix":            "PIX",
+	"misto":          "Misto",
+}
+
+// RenderSale monta o fluxo ESC/POS do cupom da venda
+func RenderSale(sale models.SaleResponse, layout Layout) []byte {
+	if layout.Columns <= 0 {
+		layout.Columns = DefaultColumns
+	}
+	b := NewBuilder(layout.Columns)
+	document := sale.FiscalDocument
+
+	// Cabeçalho
+	b.Align(AlignCenter)
+	for i, line := range layout.Header {
+		if i == 0 {
+			b.Bold(true).Line(line).Bold(false)
+			continue
+		}
+		b.Line(line)
+	}
+	b.Separator()
+	if document != nil {
+		b.Bold(true).Line("Documento Auxiliar da Nota Fiscal de Consumidor Eletrônica").Bold(false)
+		if document.Environment == 2 {
+			b.Line("EMITIDA EM AMBIENTE DE HOMOLOGAÇÃO - SEM VALOR FISCAL")
+		}
+	} else {
+		b.Bold(true).Line("CUPOM NÃO FISCAL").Bold(false)
+	}
+	if sale.Status == "cancelled" {
+		b.DoubleSize(true).Line("CANCELADA").DoubleSize(false)
+	}
+	b.Separator()
+
+	// Itens
+	b.Align(AlignLeft)
+	b.Columns2("# DESCRIÇÃO", "TOTAL")
+	for i, item := range sale.SaleItems {
+		name, barcode := item.Product.Name, item.Product.Barcode
+		if item.VariantID != nil {
+			name += " " + item.VariantName
+		}
+		if item.VariantBarcode != "" {
+			barcode = item.VariantBarcode
+		}
+		b.Line(fmt.Sprintf("%03d %s", i+1, name))
+		detail := fmt.Sprintf("    %s %s x %s", formatQuantity(item.Quantity), unitLabel(item.Product.Unit), formatMoney(item.UnitPrice))
+		if barcode != "" && layout.Columns >= DefaultColumns {
+			detail = fmt.Sprintf("    %s  %s %s x %s", barcode, formatQuantity(item.Quantity), unitLabel(item.Product.Unit), formatMoney(item.UnitPrice))
+		}
+		b.Columns2(detail, formatMoney(item.Total+item.Discount))
+		if item.Discount > 0 {
+			b.Columns2("    Desc. "+item.PromotionName, "-"+formatMoney(item.Discount))
+		}
+	}
+	b.Separator()
+
+	// Totais
+	b.Line(fmt.Sprintf("Qtd. total de itens %d", len(sale.SaleItems)))
+	b.Columns2("Subtotal R$", formatMoney(sale.Total))
+	if sale.Discount > 0 {
+		b.Columns2("Desconto R$", "-"+formatMoney(sale.Discount))
+	}
+	if sale.Tax > 0 {
+		b.Columns2("Acréscimo R$", formatMoney(sale.Tax))
+	}
+	b.Bold(true).Columns2("VALOR A PAGAR R$", formatMoney(sale.FinalTotal)).Bold(false)
+
+	// Pagamentos
+	b.Columns2("FORMA DE PAGAMENTO", "VALOR PAGO R$")
+	var change models.Money
+	if len(sale.Payments) > 0 {
+		for _, payment := range sale.Payments {
+			paid := payment.AmountReceived
+			if paid == 0 {
+				paid = payment.Amount
+			}
+			b.Columns2(paymentLabel(payment.PaymentType), formatMoney(paid))
+			change += payment.Change
+		}
+	} else {
+		paid := sale.FinalTotal
+		if sale.AmountReceived != nil {
+			paid = *sale.AmountReceived
+		}
+		b.Columns2(paymentLabel(sale.PaymentType), formatMoney(paid))
+		if sale.Change != nil {
+			change = *sale.Change
+		}
+	}
+	if change > 0 {
+		b.Columns2("Troco R$", formatMoney(change))
+	}
+	b.Separator()
+
+	// Consumidor
+	b.Align(AlignCenter)
+	if sale.Customer != nil && sale.Customer.Document != "" {
+		b.Line(fmt.Sprintf("CONSUMIDOR %s %s", strings.ToUpper(sale.Customer.DocumentType), validators.FormatDocument(sale.Customer.Document)))
+		if sale.Customer.Name != "" {
+			b.Line(sale.Customer.Name)
+		}
+	} else {
+		b.Line("CONSUMIDOR NÃO IDENTIFICADO")
+	}
+
+	// Identificação da NFC-e
+	if document != nil {
+		b.Separator()
+		b.Bold(true).Line(fmt.Sprintf("NFC-e nº %09d Série %03d", document.Number, document.Series)).Bold(false)
+		b.Line("Emissão " + formatDateTime(document.IssuedAt))
+		if document.Status == models.FiscalStatusContingency {
+			b.Bold(true).Line("EMITIDA EM CONTINGÊNCIA").Bold(false)
+			b.Line("Pendente de autorização")
+		}
+		b.Line("Consulte pela Chave de Acesso")
+		b.Line(formatAccessKey(document.AccessKey))
+		if document.Protocol != "" && document.AuthorizedAt != nil {
+			b.Line("Protocolo de autorização " + document.Protocol)
+			b.Line("Data de autorização " + formatDateTime(*document.AuthorizedAt))
+		}
+		if document.Status == models.FiscalStatusCancelled && document.CancelProtocol != "" {
+			b.Line("Protocolo de cancelamento " + document.CancelProtocol)
+		}
+		if layout.QRCode && document.QRCodeURL != "" {
+			moduleSize := byte(5)
+			if layout.Columns < DefaultColumns {
+				moduleSize = 3
+			}
+			b.QRCode(document.QRCodeURL, moduleSize)
+		}
+	}
+
+	// Rodapé
+	b.Separator()
+	b.Line(fmt.Sprintf("Venda nº %d - %s", sale.ID, formatDateTime(sale.CreatedAt)))
+	if sale.User.Name != "" {
+		b.Line("Operador: " + sale.User.Name)
+	}
+	for _, line := range layout.Footer {
+		b.Line(line)
+	}
+
+	b.Feed(layout.FeedLines)
+	if layout.Cut {
+		b.Cut()
+	}
+	return b.Bytes()
+}
+
+func paymentLabel(paymentType string) string {
+	if label, ok := paymentLabels[paymentType]; ok {
+		return label
+	}
+	return paymentType
+}
+
+func unitLabel(unit string) string {
+	if unit == "" {
+		return "UN"
+	}
+	return strings.ToUpper(unit)
+}
+
+// formatMoney formata o valor no padrão brasileiro (1.234,56)
+func formatMoney(m models.Money) string {
+	value := m.String()
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	integer, decimals, _ := strings.Cut(value, ".")
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	result := grouped.String() + "," + decimals
+	if negative {
+		return "-" + result
+	}
+	return result
+}
+
+// formatQuantity exibe quantidades inteiras sem casas decimais e fracionadas com até 3 casas
+func formatQuantity(quantity float64) string {
+	return strings.Replace(strconv.FormatFloat(models.RoundQuantity(quantity), 'f', -1, 64), ".", ",", 1)
+}
+
+func formatDateTime(t time.Time) string {
+	return t.Local().Format("02/01/2006 15:04:05")
+}
+
+// formatAccessKey agrupa a chave de acesso em blocos de 4 dígitos
+func formatAccessKey(key string) string {
+	var groups []string
+	for len(key) > 4 {
+		groups = append(groups, key[:4])
+		key = key[4:]
+	}
+	return strings.Join(append(groups, key), " ")
+}
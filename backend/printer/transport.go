@@ -0,0 +1,193 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Tipos de conexão com a impressora
+const (
+	ConnectionNetwork = "network" // impressora em rede, porta RAW (9100)
+	ConnectionFile    = "file"    // dispositivo (/dev/usb/lp0) ou arquivo de spool
+)
+
+// DefaultPort é a porta RAW padrão das impressoras térmicas em rede
+const DefaultPort = "9100"
+
+// Destinos permitidos quando as variáveis PRINTER_DEVICE_PATHS (padrões de caminho),
+// PRINTER_NETWORKS (redes CIDR) e PRINTER_PORTS não estão definidas: dispositivos de
+// impressora e portas RAW em redes privadas
+var (
+	defaultDevicePaths = []string{"/dev/usb/lp*", "/dev/lp*"}
+	defaultNetworks    = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	defaultPorts       = []string{"9100", "9101", "9102"}
+)
+
+// ErrUnsupportedConnection indica um tipo de conexão desconhecido
+var ErrUnsupportedConnection = errors.New("Tipo de conexão da impressora não suportado")
+
+// ErrTargetNotAllowed indica um endereço de impressora fora dos destinos permitidos
+var ErrTargetNotAllowed = errors.New("Endereço da impressora não permitido")
+
+// ValidateTarget verifica se o endereço da impressora está entre os destinos permitidos
+func ValidateTarget(connection, target string) error {
+	switch connection {
+	case ConnectionNetwork:
+		_, err := resolveNetworkTarget(target)
+		return err
+	case ConnectionFile:
+		return validateFileTarget(target)
+	}
+	return ErrUnsupportedConnection
+}
+
+// Send envia o fluxo ESC/POS para a impressora pelo tipo de conexão informado
+func Send(connection, target string, data []byte, timeout time.Duration) error {
+	switch connection {
+	case ConnectionNetwork:
+		return SendNetwork(target, data, timeout)
+	case ConnectionFile:
+		return SendFile(target, data)
+	}
+	return ErrUnsupportedConnection
+}
+
+// SendNetwork envia o fluxo por TCP (RAW/JetDirect). Sem porta no endereço, usa a 9100
+func SendNetwork(address string, data []byte, timeout time.Duration) error {
+	resolved, err := resolveNetworkTarget(address)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", resolved, timeout)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar na impressora %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("erro ao enviar dados para a impressora %s: %w", address, err)
+	}
+	return nil
+}
+
+// SendFile grava o fluxo no dispositivo ou arquivo informado. Arquivos comuns recebem os
+// cupons em sequência
+func SendFile(path string, data []byte) error {
+	if err := validateFileTarget(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir %s: %w", path, err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("erro ao gravar em %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// resolveNetworkTarget resolve o endereço (sem porta, usa a 9100) e retorna o IP e a porta
+// a conectar, desde que a porta e todos os IPs do nome estejam entre os permitidos. A
+// conexão usa o IP verificado, e não o nome, para que uma nova resolução não escape da
+// verificação
+func resolveNetworkTarget(address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, DefaultPort
+	}
+	if host == "" || !contains(listEnv("PRINTER_PORTS", defaultPorts), port) {
+		return "", fmt.Errorf("%w: porta %s", ErrTargetNotAllowed, port)
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("erro ao resolver o endereço da impressora %s: %w", host, err)
+	}
+
+	var networks []*net.IPNet
+	for _, cidr := range listEnv("PRINTER_NETWORKS", defaultNetworks) {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	for _, ip := range ips {
+		if !inNetworks(ip, networks) {
+			return "", fmt.Errorf("%w: %s fora das redes permitidas", ErrTargetNotAllowed, ip)
+		}
+	}
+	return net.JoinHostPort(ips[0].String(), port), nil
+}
+
+// validateFileTarget verifica se o caminho é absoluto, sem componentes relativos, e
+// corresponde a um dos padrões permitidos. Se já existir, o destino real (seguindo links
+// simbólicos) também precisa corresponder
+func validateFileTarget(path string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf("%w: caminho %s inválido", ErrTargetNotAllowed, path)
+	}
+
+	patterns := listEnv("PRINTER_DEVICE_PATHS", defaultDevicePaths)
+	if !matchesAny(path, patterns) {
+		return fmt.Errorf("%w: %s", ErrTargetNotAllowed, path)
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil && !matchesAny(real, patterns) {
+		return fmt.Errorf("%w: %s", ErrTargetNotAllowed, path)
+	}
+	return nil
+}
+
+// listEnv retorna os valores separados por vírgula da variável de ambiente ou, se vazia,
+// os valores padrão
+func listEnv(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func matchesAny(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func inNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
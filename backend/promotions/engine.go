@@ -0,0 +1,202 @@
+package promotions
+
+import (
+	"math"
+	"sort"
+
+	"pdv-backend/models"
+)
+
+// Item representa um item da venda a ser precificado
+type Item struct {
+	ProductID  uint
+	CategoryID uint
+	Quantity   float64
+	UnitPrice  models.Money
+	Total      models.Money // valor fechado do item (ex.: etiqueta de preço); se zero, preço × quantidade
+}
+
+// Gross retorna o valor do item sem desconto
+func (i Item) Gross() models.Money {
+	if i.Total > 0 {
+		return i.Total
+	}
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// Result representa o desconto aplicado a um item e a promoção que o originou
+type Result struct {
+	Discount  models.Money
+	Promotion *models.Promotion
+}
+
+// Apply calcula o desconto de cada item a partir das promoções vigentes. Cada item recebe no
+// máximo uma promoção: combos são avaliados primeiro (por prioridade) e os itens restantes,
+// agrupados por produto, recebem a promoção de produto/categoria de maior desconto
+func Apply(active []models.Promotion, items []Item) []Result {
+	results := make([]Result, len(items))
+	claimed := make([]bool, len(items))
+
+	promotions := make([]*models.Promotion, len(active))
+	for i := range active {
+		promotions[i] = &active[i]
+	}
+	sort.SliceStable(promotions, func(a, b int) bool {
+		return promotions[a].Priority > promotions[b].Priority
+	})
+
+	// Combos
+	for _, promotion := range promotions {
+		if promotion.Type == models.PromotionComboPrice {
+			applyCombo(promotion, items, claimed, results)
+		}
+	}
+
+	// Promoções de produto/categoria, avaliadas sobre a quantidade total de cada produto
+	var order []uint
+	groups := make(map[uint][]int)
+	for i, item := range items {
+		if claimed[i] {
+			continue
+		}
+		if _, ok := groups[item.ProductID]; !ok {
+			order = append(order, item.ProductID)
+		}
+		groups[item.ProductID] = append(groups[item.ProductID], i)
+	}
+
+	for _, productID := range order {
+		lines := groups[productID]
+		first := items[lines[0]]
+
+		var best *models.Promotion
+		var bestDiscounts []models.Money
+		var bestTotal models.Money
+		for _, promotion := range promotions {
+			if promotion.Type == models.PromotionComboPrice || !promotion.Matches(first.ProductID, first.CategoryID) {
+				continue
+			}
+
+			discounts := productDiscounts(promotion, items, lines)
+			var total models.Money
+			for _, discount := range discounts {
+				total += discount
+			}
+			if total > bestTotal {
+				best, bestDiscounts, bestTotal = promotion, discounts, total
+			}
+		}
+
+		if best != nil {
+			for j, line := range lines {
+				results[line] = Result{Discount: bestDiscounts[j], Promotion: best}
+			}
+		}
+	}
+
+	return results
+}
+
+// productDiscounts calcula o desconto de uma promoção de produto/categoria para cada linha
+func productDiscounts(promotion *models.Promotion, items []Item, lines []int) []models.Money {
+	discounts := make([]models.Money, len(lines))
+	weights := make([]models.Money, len(lines))
+	var quantity float64
+	for j, line := range lines {
+		weights[j] = items[line].Gross()
+		quantity += items[line].Quantity
+	}
+
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		for j, line := range lines {
+			discounts[j] = items[line].Gross().Percent(promotion.Percentage)
+		}
+	case models.PromotionBuyXPayY:
+		if promotion.BuyQuantity <= promotion.PayQuantity || promotion.PayQuantity < 1 {
+			return discounts
+		}
+		sets := math.Floor(models.RoundQuantity(quantity) / float64(promotion.BuyQuantity))
+		free := sets * float64(promotion.BuyQuantity-promotion.PayQuantity)
+		discounts = split(items[lines[0]].UnitPrice.Mul(free), weights)
+	}
+	return discounts
+}
+
+// applyCombo aplica o preço do combo quantas vezes o conjunto completo estiver no carrinho.
+// As linhas dos produtos do combo ficam reservadas e não recebem outras promoções
+func applyCombo(promotion *models.Promotion, items []Item, claimed []bool, results []Result) {
+	if len(promotion.Items) == 0 {
+		return
+	}
+
+	available := make(map[uint]float64)
+	prices := make(map[uint]models.Money)
+	for i, item := range items {
+		if claimed[i] {
+			continue
+		}
+		available[item.ProductID] = models.RoundQuantity(available[item.ProductID] + item.Quantity)
+		if _, ok := prices[item.ProductID]; !ok {
+			prices[item.ProductID] = item.UnitPrice
+		}
+	}
+
+	sets := math.Inf(1)
+	var regular models.Money
+	for _, component := range promotion.Items {
+		if component.Quantity <= 0 {
+			return
+		}
+		sets = math.Min(sets, math.Floor(models.RoundQuantity(available[component.ProductID]/component.Quantity)))
+		regular += prices[component.ProductID].Mul(component.Quantity)
+	}
+	if sets < 1 || regular <= promotion.ComboPrice {
+		return
+	}
+	discount := (regular - promotion.ComboPrice).Mul(sets)
+
+	var lines []int
+	var weights []models.Money
+	for i, item := range items {
+		if claimed[i] {
+			continue
+		}
+		for _, component := range promotion.Items {
+			if component.ProductID == item.ProductID {
+				lines = append(lines, i)
+				weights = append(weights, item.Gross())
+				break
+			}
+		}
+	}
+
+	for j, value := range split(discount, weights) {
+		claimed[lines[j]] = true
+		results[lines[j]] = Result{Discount: value, Promotion: promotion}
+	}
+}
+
+// split rateia um valor proporcionalmente aos pesos, lançando a diferença de
+// arredondamento no último item
+func split(value models.Money, weights []models.Money) []models.Money {
+	shares := make([]models.Money, len(weights))
+	var total models.Money
+	for _, weight := range weights {
+		total += weight
+	}
+	if value == 0 || total == 0 {
+		return shares
+	}
+
+	var distributed models.Money
+	for i, weight := range weights {
+		if i == len(weights)-1 {
+			shares[i] = value - distributed
+			break
+		}
+		shares[i] = models.Money(math.Round(float64(value) * float64(weight) / float64(total)))
+		distributed += shares[i]
+	}
+	return shares
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"pdv-backend/controllers"
 	"pdv-backend/middleware"
+	"pdv-backend/models"
 	"gorm.io/gorm"
 )
 
@@ -31,6 +32,7 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 		{
 			authProtected.GET("/profile", controllers.GetProfile)
 			authProtected.PUT("/change-password", controllers.ChangePassword)
+			authProtected.PUT("/pin", controllers.ChangePIN)
 		}
 	}
 
@@ -45,10 +47,30 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 			products.GET("/", controllers.GetProducts)
 			products.GET("/:id", controllers.GetProduct)
 			products.GET("/barcode/:barcode", controllers.GetProductByBarcode)
-			products.POST("/", middleware.ManagerOrAdminMiddleware(), controllers.CreateProduct)
-			products.PUT("/:id", middleware.ManagerOrAdminMiddleware(), controllers.UpdateProduct)
-			products.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteProduct)
-			products.PUT("/:id/stock", middleware.ManagerOrAdminMiddleware(), controllers.UpdateStock)
+			products.GET("/export", middleware.RequirePermission(models.PermissionProductExport), controllers.ExportProducts)
+			products.GET("/scale-export", middleware.RequirePermission(models.PermissionProductExport), controllers.ExportScaleItems)
+			products.POST("/import", middleware.RequirePermission(models.PermissionProductManage, models.PermissionProductPriceEdit), controllers.ImportProducts)
+			products.POST("/", middleware.RequirePermission(models.PermissionProductManage), controllers.CreateProduct)
+			products.PUT("/:id", middleware.RequirePermission(models.PermissionProductManage), controllers.UpdateProduct)
+			products.DELETE("/:id", middleware.RequirePermission(models.PermissionProductDelete), controllers.DeleteProduct)
+			products.PUT("/:id/stock", middleware.RequirePermission(models.PermissionStockAdjust), controllers.UpdateStock)
+			products.GET("/:id/movements", middleware.RequirePermission(models.PermissionStockView), controllers.GetProductMovements)
+			products.GET("/:id/variants", controllers.GetProductVariants)
+			products.POST("/:id/variants", middleware.RequirePermission(models.PermissionProductManage), controllers.CreateProductVariant)
+			products.PUT("/:id/variants/:variant_id", middleware.RequirePermission(models.PermissionProductManage), controllers.UpdateProductVariant)
+			products.DELETE("/:id/variants/:variant_id", middleware.RequirePermission(models.PermissionProductDelete), controllers.DeleteProductVariant)
+			products.GET("/:id/barcodes", controllers.GetProductBarcodes)
+			products.POST("/:id/barcodes", middleware.RequirePermission(models.PermissionProductManage), controllers.CreateProductBarcode)
+			products.DELETE("/:id/barcodes/:barcode_id", middleware.RequirePermission(models.PermissionProductManage), controllers.DeleteProductBarcode)
+			products.GET("/:id/batches", controllers.GetProductBatches)
+			products.POST("/:id/batches", middleware.RequirePermission(models.PermissionProductManage), controllers.CreateProductBatch)
+			products.PUT("/:id/batches/:batch_id", middleware.RequirePermission(models.PermissionProductManage), controllers.UpdateProductBatch)
+			products.GET("/:id/price-history", middleware.RequirePermission(models.PermissionReportView), controllers.GetProductPriceHistory)
+			products.GET("/:id/scheduled-prices", middleware.RequirePermission(models.PermissionProductPriceEdit), controllers.GetScheduledPrices)
+			products.POST("/:id/scheduled-prices", middleware.RequirePermission(models.PermissionProductPriceEdit), controllers.CreateScheduledPrice)
+			products.DELETE("/:id/scheduled-prices/:schedule_id", middleware.RequirePermission(models.PermissionProductPriceEdit), controllers.CancelScheduledPrice)
+			products.GET("/:id/stores", controllers.GetProductStores)
+			products.PUT("/:id/stores/:store_id", middleware.RequirePermission(models.PermissionProductManage), controllers.UpdateProductStore)
 		}
 
 		// Categorias
@@ -56,9 +78,9 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 		{
 			categories.GET("/", controllers.GetCategories)
 			categories.GET("/:id", controllers.GetCategory)
-			categories.POST("/", middleware.ManagerOrAdminMiddleware(), controllers.CreateCategory)
-			categories.PUT("/:id", middleware.ManagerOrAdminMiddleware(), controllers.UpdateCategory)
-			categories.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteCategory)
+			categories.POST("/", middleware.RequirePermission(models.PermissionCategoryManage), controllers.CreateCategory)
+			categories.PUT("/:id", middleware.RequirePermission(models.PermissionCategoryManage), controllers.UpdateCategory)
+			categories.DELETE("/:id", middleware.RequirePermission(models.PermissionCategoryDelete), controllers.DeleteCategory)
 		}
 
 		// Vendas
@@ -67,13 +89,136 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 			sales.GET("/", controllers.GetSales)
 			sales.GET("/:id", controllers.GetSale)
 			sales.POST("/", controllers.CreateSale)
-			sales.PUT("/:id/cancel", middleware.ManagerOrAdminMiddleware(), controllers.CancelSale)
-			sales.GET("/report", middleware.ManagerOrAdminMiddleware(), controllers.GetSalesReport)
+			sales.POST("/preview", controllers.PreviewSale)
+			sales.PUT("/:id/cancel", controllers.CancelSale)
+			sales.GET("/report", middleware.RequirePermission(models.PermissionReportView), controllers.GetSalesReport)
+			sales.GET("/:id/fiscal", controllers.GetSaleFiscalDocument)
+			sales.GET("/:id/fiscal/xml", controllers.GetSaleFiscalXML)
+			sales.POST("/:id/fiscal/emit", controllers.EmitSaleFiscalDocument)
+			sales.POST("/:id/print", controllers.PrintSale)
 		}
 
-		// Usuários (apenas admin)
+		// Documentos fiscais (NFC-e)
+		fiscalDocuments := protected.Group("/fiscal")
+		fiscalDocuments.Use(middleware.RequirePermission(models.PermissionFiscalManage))
+		{
+			fiscalDocuments.GET("/documents", controllers.GetFiscalDocuments)
+			fiscalDocuments.POST("/contingency/transmit", controllers.TransmitContingencyDocuments)
+			fiscalDocuments.GET("/invalidations", controllers.GetFiscalInvalidations)
+			fiscalDocuments.POST("/invalidations", controllers.CreateFiscalInvalidation)
+		}
+
+		// Clientes
+		customers := protected.Group("/customers")
+		{
+			customers.GET("/", controllers.GetCustomers)
+			customers.GET("/:id", controllers.GetCustomer)
+			customers.GET("/:id/sales", controllers.GetCustomerSales)
+			customers.POST("/", controllers.CreateCustomer)
+			customers.PUT("/:id", controllers.UpdateCustomer)
+			customers.DELETE("/:id", middleware.RequirePermission(models.PermissionCustomerDelete), controllers.DeleteCustomer)
+		}
+
+		// Promoções
+		promotions := protected.Group("/promotions")
+		{
+			promotions.GET("/", controllers.GetPromotions)
+			promotions.GET("/:id", controllers.GetPromotion)
+			promotions.POST("/", middleware.RequirePermission(models.PermissionPromotionManage), controllers.CreatePromotion)
+			promotions.PUT("/:id", middleware.RequirePermission(models.PermissionPromotionManage), controllers.UpdatePromotion)
+			promotions.DELETE("/:id", middleware.RequirePermission(models.PermissionPromotionManage), controllers.DeletePromotion)
+		}
+
+		// Fornecedores
+		suppliers := protected.Group("/suppliers")
+		{
+			suppliers.GET("/", controllers.GetSuppliers)
+			suppliers.GET("/:id", controllers.GetSupplier)
+			suppliers.POST("/", middleware.RequirePermission(models.PermissionSupplierManage), controllers.CreateSupplier)
+			suppliers.PUT("/:id", middleware.RequirePermission(models.PermissionSupplierManage), controllers.UpdateSupplier)
+			suppliers.DELETE("/:id", middleware.RequirePermission(models.PermissionSupplierManage), controllers.DeleteSupplier)
+		}
+
+		// Compras (pedidos de compra e recebimento de mercadorias)
+		purchases := protected.Group("/purchases")
+		purchases.Use(middleware.RequirePermission(models.PermissionPurchaseManage))
+		{
+			purchases.GET("/", controllers.GetPurchaseOrders)
+			purchases.GET("/:id", controllers.GetPurchaseOrder)
+			purchases.POST("/", controllers.CreatePurchaseOrder)
+			purchases.PUT("/:id", controllers.UpdatePurchaseOrder)
+			purchases.POST("/:id/send", controllers.SendPurchaseOrder)
+			purchases.POST("/:id/receive", controllers.ReceivePurchaseOrder)
+			purchases.POST("/:id/cancel", controllers.CancelPurchaseOrder)
+
+			// Entrada de mercadorias pelo XML da NF-e do fornecedor
+			purchases.POST("/import-nfe", controllers.ImportPurchaseInvoice)
+			purchases.GET("/import-nfe/:id", controllers.GetPurchaseInvoice)
+			purchases.POST("/import-nfe/:id/confirm", controllers.ConfirmPurchaseInvoice)
+		}
+
+		// Transferências de estoque entre lojas
+		stockTransfers := protected.Group("/stock-transfers")
+		stockTransfers.Use(middleware.RequirePermission(models.PermissionStockTransfer))
+		{
+			stockTransfers.GET("/", controllers.GetStockTransfers)
+			stockTransfers.GET("/in-transit", controllers.GetStockInTransit)
+			stockTransfers.GET("/:id", controllers.GetStockTransfer)
+			stockTransfers.POST("/", controllers.CreateStockTransfer)
+			stockTransfers.PUT("/:id", controllers.UpdateStockTransfer)
+			stockTransfers.POST("/:id/ship", controllers.ShipStockTransfer)
+			stockTransfers.POST("/:id/receive", controllers.ReceiveStockTransfer)
+			stockTransfers.POST("/:id/cancel", controllers.CancelStockTransfer)
+		}
+
+		// Balanço (inventário físico)
+		inventoryCounts := protected.Group("/inventory-counts")
+		{
+			inventoryCounts.GET("/", controllers.GetInventoryCounts)
+			inventoryCounts.GET("/:id", controllers.GetInventoryCount)
+			inventoryCounts.GET("/:id/report", middleware.RequirePermission(models.PermissionInventoryManage), controllers.ExportInventoryCountReport)
+			inventoryCounts.POST("/", middleware.RequirePermission(models.PermissionInventoryManage), controllers.CreateInventoryCount)
+			inventoryCounts.POST("/:id/entries", controllers.AddInventoryCountEntries)
+			inventoryCounts.POST("/:id/approve", middleware.RequirePermission(models.PermissionInventoryManage), controllers.ApproveInventoryCount)
+			inventoryCounts.POST("/:id/cancel", middleware.RequirePermission(models.PermissionInventoryManage), controllers.CancelInventoryCount)
+		}
+
+		// Impressoras térmicas (cupom ESC/POS)
+		printers := protected.Group("/printers")
+		{
+			printers.GET("/", controllers.GetPrinterProfiles)
+			printers.GET("/:id", controllers.GetPrinterProfile)
+			printers.POST("/", middleware.RequirePermission(models.PermissionDeviceManage), controllers.CreatePrinterProfile)
+			printers.PUT("/:id", middleware.RequirePermission(models.PermissionDeviceManage), controllers.UpdatePrinterProfile)
+			printers.DELETE("/:id", middleware.RequirePermission(models.PermissionDeviceManage), controllers.DeletePrinterProfile)
+		}
+
+		// Formatos de etiqueta das balanças
+		scaleLayouts := protected.Group("/scale-layouts")
+		{
+			scaleLayouts.GET("/", controllers.GetScaleLabelLayouts)
+			scaleLayouts.POST("/", middleware.RequirePermission(models.PermissionDeviceManage), controllers.CreateScaleLabelLayout)
+			scaleLayouts.PUT("/:id", middleware.RequirePermission(models.PermissionDeviceManage), controllers.UpdateScaleLabelLayout)
+			scaleLayouts.DELETE("/:id", middleware.RequirePermission(models.PermissionDeviceManage), controllers.DeleteScaleLabelLayout)
+		}
+
+		// Caixa (abertura, sangria/suprimento e fechamento)
+		cashSessions := protected.Group("/cash-sessions")
+		{
+			cashSessions.GET("/", controllers.GetCashSessions)
+			cashSessions.GET("/current", controllers.GetCurrentCashSession)
+			cashSessions.POST("/open", controllers.OpenCashSession)
+			cashSessions.GET("/:id", controllers.GetCashSession)
+			cashSessions.GET("/:id/report", controllers.GetCashSessionReport)
+			cashSessions.POST("/:id/movements", controllers.AddCashMovement)
+			cashSessions.POST("/:id/close", controllers.CloseCashSession)
+			cashSessions.POST("/:id/open-drawer", controllers.OpenCashDrawer)
+			cashSessions.PUT("/:id/reconcile", middleware.RequirePermission(models.PermissionCashManage), controllers.ReconcileCashSession)
+		}
+
+		// Usuários
 		users := protected.Group("/users")
-		users.Use(middleware.AdminMiddleware())
+		users.Use(middleware.RequirePermission(models.PermissionUserManage))
 		{
 			users.GET("/", controllers.GetUsers)
 			users.GET("/:id", controllers.GetUser)
@@ -82,13 +227,47 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 			users.DELETE("/:id", controllers.DeleteUser)
 		}
 
-		// Dashboard (gerentes e admins)
+		// Catálogo de permissões e modelos por perfil
+		permissions := protected.Group("/permissions")
+		permissions.Use(middleware.RequirePermission(models.PermissionUserManage))
+		{
+			permissions.GET("/", controllers.GetPermissions)
+		}
+
+		// Autorizações de supervisor concedidas a operadores
+		supervisorAuthorizations := protected.Group("/supervisor-authorizations")
+		supervisorAuthorizations.Use(middleware.RequirePermission(models.PermissionReportView))
+		{
+			supervisorAuthorizations.GET("/", controllers.GetSupervisorAuthorizations)
+		}
+
+		// Organização e lojas
+		organization := protected.Group("/organization")
+		organization.Use(middleware.RequirePermission(models.PermissionOrganizationManage))
+		{
+			organization.GET("/", controllers.GetOrganization)
+			organization.PUT("/", controllers.UpdateOrganization)
+		}
+
+		stores := protected.Group("/stores")
+		stores.Use(middleware.RequirePermission(models.PermissionOrganizationManage))
+		{
+			stores.GET("/", controllers.GetStores)
+			stores.GET("/:id", controllers.GetStore)
+			stores.POST("/", controllers.CreateStore)
+			stores.PUT("/:id", controllers.UpdateStore)
+			stores.DELETE("/:id", controllers.DeleteStore)
+		}
+
+		// Dashboard
 		dashboard := protected.Group("/dashboard")
-		dashboard.Use(middleware.ManagerOrAdminMiddleware())
+		dashboard.Use(middleware.RequirePermission(models.PermissionReportView))
 		{
 			dashboard.GET("/stats", controllers.GetDashboardStats)
 			dashboard.GET("/low-stock", controllers.GetLowStockProducts)
+			dashboard.GET("/expiring", controllers.GetExpiringBatches)
 			dashboard.GET("/top-products", controllers.GetTopProducts)
+			dashboard.GET("/stock-position", controllers.GetStockPosition)
 		}
 
 
@@ -0,0 +1,246 @@
+package scale
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pdv-backend/models"
+)
+
+// Formatos de arquivo de carga das balanças
+const (
+	FormatToledo   = "toledo"   // Toledo MGV (ITENSMGV.TXT e TARA.TXT)
+	FormatFilizola = "filizola" // Filizola (CADTXT.TXT)
+)
+
+// ErrUnsupportedFormat indica um formato de balança desconhecido
+var ErrUnsupportedFormat = errors.New("Formato de balança não suportado (use toledo ou filizola)")
+
+// Limites dos campos numéricos dos arquivos
+const (
+	maxCode          = 999999
+	maxShelfLifeDays = 999
+)
+
+// Item representa um produto a ser carregado na balança
+type Item struct {
+	Code          int          // PLU
+	Name          string       // descrição impressa na etiqueta
+	Price         models.Money // preço por kg (ou por unidade)
+	Weighed       bool         // vendido por peso
+	ShelfLifeDays int          // validade em dias (0 para não imprimir)
+	Tare          float64      // tara em kg
+}
+
+// Write grava o arquivo compactado (zip) de carga da balança no formato informado
+func Write(w io.Writer, format string, items []Item) error {
+	files, err := Files(format, items)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Files monta os arquivos de carga da balança no formato informado (nome → conteúdo)
+func Files(format string, items []Item) (map[string][]byte, error) {
+	for _, item := range items {
+		if err := item.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case FormatToledo:
+		return toledoFiles(items), nil
+	case FormatFilizola:
+		return filizolaFiles(items), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func (i Item) validate() error {
+	switch {
+	case i.Code <= 0 || i.Code > maxCode:
+		return fmt.Errorf("Código de balança inválido para %s: %d", i.Name, i.Code)
+	case i.Price <= 0 || i.Price > 999999:
+		return fmt.Errorf("Preço fora do limite da balança (até 9999,99) para %s", i.Name)
+	case i.ShelfLifeDays < 0 || i.ShelfLifeDays > maxShelfLifeDays:
+		return fmt.Errorf("Validade fora do limite da balança (até 999 dias) para %s", i.Name)
+	case i.Tare < 0 || i.Tare > 9.999:
+		return fmt.Errorf("Tara fora do limite da balança (até 9,999 kg) para %s", i.Name)
+	}
+	return nil
+}
+
+// toledoFiles monta a carga do Toledo MGV. Cada linha do ITENSMGV.TXT contém:
+// departamento(2) tipo(1: 0 peso, 1 unidade) código(6) preço(6, centavos) validade(3)
+// descritivo 1(25) descritivo 2(25) info extra(6) imagem(4) info nutricional(6)
+// imprime validade(1) imprime embalagem(1) fornecedor(4) lote(12) EAN especial(11)
+// versão do preço(1) som(4) tara(4, código do TARA.TXT). O TARA.TXT contém:
+// código(4) tara(6, gramas) descrição(20)
+func toledoFiles(items []Item) map[string][]byte {
+	tares := tareCodes(items)
+
+	var itemsFile strings.Builder
+	for _, item := range items {
+		kind := "1"
+		if item.Weighed {
+			kind = "0"
+		}
+		printValidity := "0"
+		if item.ShelfLifeDays > 0 {
+			printValidity = "1"
+		}
+		first, second := splitDescription(item.Name, 25)
+
+		itemsFile.WriteString("01")
+		itemsFile.WriteString(kind)
+		itemsFile.WriteString(digits(item.Code, 6))
+		itemsFile.WriteString(digits(int(item.Price), 6))
+		itemsFile.WriteString(digits(item.ShelfLifeDays, 3))
+		itemsFile.WriteString(text(first, 25))
+		itemsFile.WriteString(text(second, 25))
+		itemsFile.WriteString("000000" + "0000" + "000000")
+		itemsFile.WriteString(printValidity + "1")
+		itemsFile.WriteString("0000" + "000000000000" + "00000000000" + "0" + "0000")
+		itemsFile.WriteString(digits(tares[grams(item.Tare)], 4))
+		itemsFile.WriteString("\r\n")
+	}
+
+	var tareFile strings.Builder
+	for _, tare := range sortedTares(tares) {
+		tareFile.WriteString(digits(tares[tare], 4))
+		tareFile.WriteString(digits(tare, 6))
+		tareFile.WriteString(text(fmt.Sprintf("TARA %d G", tare), 20))
+		tareFile.WriteString("\r\n")
+	}
+
+	return map[string][]byte{
+		"ITENSMGV.TXT": []byte(itemsFile.String()),
+		"TARA.TXT":     []byte(tareFile.String()),
+	}
+}
+
+// filizolaFiles monta a carga da Filizola. Cada linha do CADTXT.TXT contém:
+// código(6) tipo(1: P peso, U unidade) descrição(22) preço(7, centavos) validade(3)
+// tara(5, gramas)
+func filizolaFiles(items []Item) map[string][]byte {
+	var file strings.Builder
+	for _, item := range items {
+		kind := "U"
+		if item.Weighed {
+			kind = "P"
+		}
+		file.WriteString(digits(item.Code, 6))
+		file.WriteString(kind)
+		file.WriteString(text(item.Name, 22))
+		file.WriteString(digits(int(item.Price), 7))
+		file.WriteString(digits(item.ShelfLifeDays, 3))
+		file.WriteString(digits(grams(item.Tare), 5))
+		file.WriteString("\r\n")
+	}
+	return map[string][]byte{"CADTXT.TXT": []byte(file.String())}
+}
+
+// tareCodes numera as taras distintas (em gramas) na ordem crescente; tara zero usa o código 0
+func tareCodes(items []Item) map[int]int {
+	codes := map[int]int{0: 0}
+	var values []int
+	for _, item := range items {
+		tare := grams(item.Tare)
+		if _, found := codes[tare]; !found {
+			codes[tare] = 0
+			values = append(values, tare)
+		}
+	}
+	sort.Ints(values)
+	for i, value := range values {
+		codes[value] = i + 1
+	}
+	return codes
+}
+
+func sortedTares(codes map[int]int) []int {
+	var values []int
+	for value := range codes {
+		if value != 0 {
+			values = append(values, value)
+		}
+	}
+	sort.Ints(values)
+	return values
+}
+
+func grams(kg float64) int {
+	return int(math.Round(kg * 1000))
+}
+
+// digits formata o número com zeros à esquerda no tamanho informado
+func digits(value, size int) string {
+	formatted := strconv.Itoa(value)
+	if len(formatted) >= size {
+		return formatted[len(formatted)-size:]
+	}
+	return strings.Repeat("0", size-len(formatted)) + formatted
+}
+
+// text normaliza o texto para as balanças (maiúsculas, sem acentos) e completa com espaços
+func text(value string, size int) string {
+	value = strings.ToUpper(accents.Replace(strings.Join(strings.Fields(value), " ")))
+	runes := []rune(value)
+	for i, r := range runes {
+		if r > 127 {
+			runes[i] = ' '
+		}
+	}
+	if len(runes) > size {
+		runes = runes[:size]
+	}
+	return string(runes) + strings.Repeat(" ", size-len(runes))
+}
+
+// splitDescription quebra a descrição em duas linhas, sem cortar palavras quando possível
+func splitDescription(value string, size int) (string, string) {
+	value = strings.Join(strings.Fields(value), " ")
+	runes := []rune(value)
+	if len(runes) <= size {
+		return value, ""
+	}
+	cut := size
+	if space := strings.LastIndex(string(runes[:size+1]), " "); space > 0 {
+		cut = len([]rune(string(runes[:size+1])[:space]))
+	}
+	return string(runes[:cut]), strings.TrimSpace(string(runes[cut:]))
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "Á", "A", "À", "A", "Â", "A", "Ã", "A",
+	"é", "e", "ê", "e", "É", "E", "Ê", "E",
+	"í", "i", "Í", "I",
+	"ó", "o", "ô", "o", "õ", "o", "Ó", "O", "Ô", "O", "Õ", "O",
+	"ú", "u", "ü", "u", "Ú", "U", "Ü", "U",
+	"ç", "c", "Ç", "C",
+)
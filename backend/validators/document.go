@@ -0,0 +1,86 @@
+package validators
+
+import (
+	"strings"
+)
+
+// OnlyDigits remove pontuação e espaços, mantendo apenas os dígitos
+func OnlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// allSameDigits identifica sequências como 111.111.111-11, que passam no cálculo mas são inválidas
+func allSameDigits(value string) bool {
+	return strings.Count(value, value[:1]) == len(value)
+}
+
+// ValidateCPF verifica os dígitos verificadores de um CPF (com ou sem pontuação)
+func ValidateCPF(cpf string) bool {
+	cpf = OnlyDigits(cpf)
+	if len(cpf) != 11 || allSameDigits(cpf) {
+		return false
+	}
+
+	for _, size := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < size; i++ {
+			sum += int(cpf[i]-'0') * (size + 1 - i)
+		}
+		digit := (sum * 10) % 11
+		if digit == 10 {
+			digit = 0
+		}
+		if digit != int(cpf[size]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateCNPJ verifica os dígitos verificadores de um CNPJ (com ou sem pontuação)
+func ValidateCNPJ(cnpj string) bool {
+	cnpj = OnlyDigits(cnpj)
+	if len(cnpj) != 14 || allSameDigits(cnpj) {
+		return false
+	}
+
+	weights := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	for _, size := range []int{12, 13} {
+		sum := 0
+		offset := len(weights) - size
+		for i := 0; i < size; i++ {
+			sum += int(cnpj[i]-'0') * weights[offset+i]
+		}
+		digit := sum % 11
+		if digit < 2 {
+			digit = 0
+		} else {
+			digit = 11 - digit
+		}
+		if digit != int(cnpj[size]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FormatDocument formata um CPF (000.000.000-00) ou CNPJ (00.000.000/0000-00)
+func FormatDocument(document string) string {
+	digits := OnlyDigits(document)
+	switch len(digits) {
+	case 11:
+		return digits[:3] + "." + digits[3:6] + "." + digits[6:9] + "-" + digits[9:]
+	case 14:
+		return digits[:2] + "." + digits[2:5] + "." + digits[5:8] + "/" + digits[8:12] + "-" + digits[12:]
+	default:
+		return document
+	}
+}
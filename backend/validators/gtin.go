@@ -0,0 +1,50 @@
+package validators
+
+// Tipos de GTIN reconhecidos pelo tamanho do código
+const (
+	GTIN8  = "EAN-8"
+	GTIN12 = "UPC-A"
+	GTIN13 = "EAN-13"
+	GTIN14 = "DUN-14"
+)
+
+// GTINType retorna o tipo de GTIN pelo tamanho do código numérico, ou vazio se o código não
+// tiver formato de GTIN (códigos internos)
+func GTINType(code string) string {
+	if code == "" || OnlyDigits(code) != code {
+		return ""
+	}
+	switch len(code) {
+	case 8:
+		return GTIN8
+	case 12:
+		return GTIN12
+	case 13:
+		return GTIN13
+	case 14:
+		return GTIN14
+	}
+	return ""
+}
+
+// GTINCheckDigit calcula o dígito verificador (módulo 10, pesos 3 e 1 a partir da direita)
+// para o código sem o dígito
+func GTINCheckDigit(body string) int {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		digit := int(body[len(body)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10 - sum%10) % 10
+}
+
+// ValidateGTIN verifica o formato e o dígito verificador de um EAN-8, UPC-A, EAN-13 ou DUN-14
+func ValidateGTIN(code string) bool {
+	if GTINType(code) == "" {
+		return false
+	}
+	return GTINCheckDigit(code[:len(code)-1]) == int(code[len(code)-1]-'0')
+}